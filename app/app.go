@@ -1,9 +1,14 @@
 package app
 
 import (
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -13,14 +18,19 @@ import (
 	"github.com/jhseong7/gimbap/dependency"
 	"github.com/jhseong7/gimbap/engine"
 	gin_engine "github.com/jhseong7/gimbap/engine/gin"
+	"github.com/jhseong7/gimbap/interceptor"
 	"github.com/jhseong7/gimbap/microservice"
 	"github.com/jhseong7/gimbap/module"
+	"github.com/jhseong7/gimbap/openapi"
 	"github.com/jhseong7/gimbap/provider"
+	"github.com/jhseong7/gimbap/route"
 	"github.com/jhseong7/gimbap/util"
+	"github.com/jhseong7/gimbap/versioning"
+	"github.com/jhseong7/gimbap/view"
 )
 
 const (
-	DefaultPort int = 8080
+	DefaultPort int = engine.DefaultPort
 )
 
 type (
@@ -48,6 +58,44 @@ type (
 		// microservice list
 		microservices []*microservice.MicroServiceProvider
 
+		// Global interceptors (instances or providers)
+		interceptors []interface{}
+
+		// Global exception filters (instances or providers)
+		exceptionFilters []interface{}
+
+		// Global middlewares (native middlewares or providers)
+		middlewares []interface{}
+
+		// OpenAPI document generation. (nil if disabled)
+		openApiOption   *openapi.Option
+		openApiDocument *openapi.Document
+
+		// API versioning. (nil if disabled)
+		versioningOption *versioning.Option
+
+		// Renderer of the views returned by the handlers. (nil if disabled)
+		views *view.Renderer
+
+		// Route table of the registered controllers
+		routes []route.RouteInfo
+
+		// Setup functions of the engine (middlewares, statics, mounts) run in the order of the calls when the app runs
+		engineSetups []func()
+
+		// flag to check if the app is already set up (by Handler or Run)
+		isSetUp bool
+
+		// Runtime options given to Run after the app is set up by Handler. (nil to run with the provided runtime options)
+		runOption *RuntimeOptions
+
+		// Closed once the server engine listens
+		readyFlag chan struct{}
+		readyOnce *sync.Once
+
+		// Set once the app starts to shut down (fails the readiness)
+		draining *atomic.Bool
+
 		// flag, channels to hold the shutdown signal until all the components stop
 		shutdownFlag     chan string
 		stopFlag         chan bool // Signal to trigger the stop of the app
@@ -65,6 +113,39 @@ type (
 		Port      int
 		TLSOption *engine.TLSOption
 
+		// Host or IP to bind (all interfaces if empty) and the network of the listener. (tcp, tcp4, tcp6 or unix)
+		Host    string
+		Network string
+
+		// Path of the Unix domain socket to listen on, instead of the port
+		SocketPath string
+
+		// Listener created by the user. The address options are ignored if set.
+		Listener net.Listener
+
+		// Use the socket passed by systemd socket activation. (selected by the name if given)
+		SocketActivation     bool
+		SocketActivationName string
+
+		// Serve the app on several listeners with their own TLS options. (e.g. https on 443 and a redirect from http on 80)
+		// The address and TLS options above are ignored if set.
+		Listeners []engine.ListenerOption
+
+		// Protocols served in addition to HTTP/1.1. (h2c and HTTP/3, net/http based engines only)
+		Protocols engine.ProtocolOption
+
+		// Called with the actual address once the server listens. (e.g. the port chosen for Port 0)
+		OnReady func(addr net.Addr)
+
+		// Staged shutdown: readiness endpoint, pre-stop delay and the report of the drained requests
+		Shutdown ShutdownOption
+
+		// Restart without dropping connections on a signal. (e.g. SIGUSR2 to upgrade the binary)
+		Restart RestartOption
+
+		// Serve the app with several processes sharing the ports. (SO_REUSEPORT)
+		Prefork PreforkOption
+
 		// Option injector with provided values from the app module
 		WithProvided interface{}
 	}
@@ -83,15 +164,39 @@ Private Methods
 
 */
 
-// Register the controller instances to the engine.
-func (app *GimbapApp) registerControllerInstances() {
-	// For all controllers
+// Get all controllers of the app module.
+func (app *GimbapApp) getControllers() []*controller.Controller {
+	controllers := []*controller.Controller{}
+
 	for _, rc := range app.appModule.GetProviderMapOfHandler(controller.HandlerName) {
 		c, ok := rc.(*controller.Controller)
 		if !ok {
 			app.logger.Panicf("Failed to cast controller: %s", reflect.TypeOf(rc).String())
 		}
 
+		controllers = append(controllers, c)
+	}
+
+	return controllers
+}
+
+// Build the controller specs to register to the engine.
+//
+// The specs are versioned, documented (OpenAPI) and validated before they are given to the engine.
+func (app *GimbapApp) buildControllerSpecs() []engine.ControllerSpec {
+	// Global interceptors and exception filters are applied to all controllers
+	globalInterceptors := app.resolveInterceptors(app.interceptors)
+
+	// The views are rendered by the outermost interceptor, so the other interceptors get the view results
+	if app.views != nil {
+		globalInterceptors = append([]interceptor.IInterceptor{app.views}, globalInterceptors...)
+	}
+	globalExceptionFilters := app.resolveExceptionFilters(app.exceptionFilters)
+
+	specs := []engine.ControllerSpec{}
+
+	// For all controllers
+	for _, c := range app.getControllers() {
 		// Get the return type of the instantiator (this will be the controller's type)
 		instanceType, ok := util.DeriveTypeFromInstantiator(c.Instantiator)
 		if !ok {
@@ -110,9 +215,62 @@ func (app *GimbapApp) registerControllerInstances() {
 			app.logger.Panicf("Controller instance does not implement IController: %s", instanceType.String())
 		}
 
-		// Register the controller
-		app.serverEngine.RegisterController(c.RootPath, inst)
+		// Merge the interceptors. (global --> controller)
+		interceptors := append([]interceptor.IInterceptor{}, globalInterceptors...)
+		interceptors = append(interceptors, app.resolveInterceptors(c.Interceptors)...)
+
+		// Merge the exception filters. (controller --> global)
+		exceptionFilters := app.resolveExceptionFilters(c.ExceptionFilters)
+		exceptionFilters = append(exceptionFilters, globalExceptionFilters...)
+
+		// The injectables given as providers only to the routes are instantiated once the routes are known
+		routeSpecs := inst.GetRouteSpecs()
+		app.instantiateRouteInjectables(routeSpecs)
+
+		specs = append(specs, engine.ControllerSpec{
+			Name:             c.Name,
+			RootPath:         c.RootPath,
+			Routes:           app.resolveRouteSpecs(routeSpecs),
+			Interceptors:     interceptors,
+			ExceptionFilters: exceptionFilters,
+			Middlewares:      app.resolveMiddlewares(c.Middlewares),
+			Versions:         c.Versions,
+			Metadata:         c.Metadata,
+		})
+	}
+
+	prefix := app.globalApiPrefix()
+
+	// Apply the versions to the paths and rewrite the requests to the requested version
+	if app.versioningOption != nil {
+		specs = versioning.ApplyVersions(*app.versioningOption, specs)
+
+		if rewriter := versioning.NewPathRewriter(*app.versioningOption, prefix, specs); rewriter != nil {
+			app.setPathRewriter(rewriter)
+		}
 	}
+
+	// Generate the OpenAPI document from the specs and serve it if the path is set
+	if app.openApiOption != nil {
+		app.openApiDocument = openapi.Generate(*app.openApiOption, prefix, specs)
+
+		if app.openApiOption.Path != "" {
+			specs = append(specs, openapi.DocumentControllerSpec(*app.openApiOption, prefix, app.openApiDocument))
+		}
+	}
+
+	// Validate all routes before handing them to the engine
+	registry := route.NewRouteRegistry(prefix)
+	for _, spec := range specs {
+		registry.Add(spec)
+	}
+
+	if err := registry.Validate(); err != nil {
+		app.logger.Panicf("Invalid routes found:\n%s", err)
+	}
+	app.routes = registry.Routes()
+
+	return specs
 }
 
 // Internal function to get each active microservice, and handler with the given handler function
@@ -180,17 +338,126 @@ func (app *GimbapApp) stopMicroServices() {
 	})
 }
 
+// Get the listener options of the engine. (the address, the listeners, TLS and the protocols)
+func (o RuntimeOptions) serverRuntimeOption() engine.ServerRuntimeOption {
+	return engine.ServerRuntimeOption{
+		Port:                 o.Port,
+		Host:                 o.Host,
+		Network:              o.Network,
+		SocketPath:           o.SocketPath,
+		Listener:             o.Listener,
+		SocketActivation:     o.SocketActivation,
+		SocketActivationName: o.SocketActivationName,
+		TLSOption:            o.TLSOption,
+		Listeners:            o.Listeners,
+		Protocols:            o.Protocols,
+	}
+}
+
+// Set up the app: inject the providers and register the middlewares and the controllers to the engine.
+//
+// The app is set up only once. (Handler then Run reuses the set up of Handler)
+func (app *GimbapApp) setup(option RuntimeOptions) {
+	if app.isSetUp {
+		return
+	}
+	app.isSetUp = true
+
+	// Runtime option provider function
+	var optionProvider provider.Provider
+	if option.WithProvided != nil {
+		// TODO: Add a check for the input types of provided and see if it is in our provider map.
+		optionProvider = *provider.DefineProvider(provider.ProviderOption{
+			Name:         "RuntimeOptions",
+			Instantiator: option.WithProvided,
+		})
+	} else {
+		optionProvider = *provider.DefineProvider(provider.ProviderOption{
+			Name:         "RuntimeOptions",
+			Instantiator: func() RuntimeOptions { return option },
+		})
+	}
+
+	// Call the dependency manager to inject the providers
+	providers := []*provider.Provider{}
+
+	// Collect all providers from the module
+	providers = append(providers, app.appModule.GetProviderList()...)
+
+	// Collect all microservices
+	for _, m := range app.microservices {
+		providers = append(providers, &m.Provider)
+	}
+
+	// Add the runtime options provider
+	providers = append(providers, &optionProvider)
+
+	// Collect the injectables given as providers (e.g. interceptors, exception filters, middlewares)
+	providers = append(providers, app.collectInjectableProviders(providers)...)
+
+	// Add the functions with injection support
+	if app.functionsWithInjection != nil {
+		providers = append(providers, app.functionsWithInjection...)
+	}
+
+	// Inject the providers
+	app.depManager.ResolveDependencies(app.instanceMap, providers)
+
+	// Load the templates before the routes are served
+	if app.views != nil {
+		if err := app.views.Load(); err != nil {
+			app.logger.Panicf("Failed to load the views. %s", err)
+		}
+	}
+
+	// Build the controller specs from the controller instances.
+	// This will automatically call the GetRouteSpecs function of each controller. (if it is implemented)
+	// The path rewriter of the versioning is set here, before any other handler of the engine.
+	specs := app.buildControllerSpecs()
+
+	// Serve the readiness of the staged shutdown (with the options resolved by WithProvided)
+	if readinessPath := GetProvider(*app, RuntimeOptions{}).Shutdown.ReadinessPath; readinessPath != "" {
+		app.mount(readinessPath, app.ReadinessHandler())
+	}
+
+	// Initialize the engine
+	// Apply the global middlewares and the static paths in the order they were added.
+	for _, setup := range app.engineSetups {
+		setup()
+	}
+
+	// Register the routes of the controllers to the engine.
+	for _, spec := range specs {
+		app.registerController(spec)
+	}
+}
+
+// Use the runtime options given to Run for the app already set up by Handler.
+//
+// The options read by the set up cannot be changed, so they are kept from Handler with a warning.
+func (app *GimbapApp) applyRunOption(option RuntimeOptions) {
+	setUp := GetProvider(*app, RuntimeOptions{})
+
+	if option.WithProvided != nil {
+		app.logger.Warn("The app is already set up by Handler. WithProvided of the runtime options given to Run is ignored.")
+		option = setUp
+	}
+	if option.Shutdown.ReadinessPath != setUp.Shutdown.ReadinessPath {
+		app.logger.Warn("The app is already set up by Handler. The readiness path given to Run is ignored. Give it to Handler instead.")
+	}
+
+	app.runOption = &option
+}
+
 // The internal run function
 //
 // This function will start the engine and call all the onStartListeners.
 func (app *GimbapApp) run() {
-	// Get the runtime options from the instance map
+	// Get the runtime options from the instance map (or the options given to Run after Handler)
 	runtimeOpts := GetProvider(*app, RuntimeOptions{})
-
-	// Initialize the engine
-	// Bind the controller instances to the engine and register the routes.
-	// This will automatically call the GetRouteSpecs function of each controller. (if it is implemented)
-	app.registerControllerInstances()
+	if app.runOption != nil {
+		runtimeOpts = *app.runOption
+	}
 
 	// Register a SIGTEM, SIGINT listener to stop the app gracefully.
 	// This will trigger the engine to stop --> calling an end to the app's lifecycle.
@@ -198,33 +465,45 @@ func (app *GimbapApp) run() {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
+	// The restart signal starts a new process with the listeners, then stops this process once the new process is ready.
+	// (the prefork processes are restarted by their supervisor instead)
+	restartSignal := runtimeOpts.Restart.getSignal()
+	if restartSignal != nil && !isPreforkProcess() {
+		signal.Notify(sigs, restartSignal)
+	}
+
+	// The prefork processes stop with their supervisor
+	if isPreforkProcess() {
+		go app.watchPreforkParent()
+	}
+
 	/*
 		NOTE: There is an issue where the app will not stop if the stop signal is sent before the engine starts.
 	*/
 	go func() {
-		select {
-		case sig := <-sigs:
-			app.logger.Logf("Received signal: %s", sig)
-		case <-app.stopFlag:
-			app.logger.Log("Received graceful stop request")
+		shutdownOption := runtimeOpts.Shutdown
+		for stopping := false; !stopping; {
+			select {
+			case sig := <-sigs:
+				app.logger.Logf("Received signal: %s", sig)
+				if sig != restartSignal {
+					stopping = true
+				} else if stopping = app.restart(runtimeOpts.Restart); stopping {
+					// The new process serves the requests already, so there is no load balancer to wait for.
+					// Wait only for the requests of the connections accepted before the handoff.
+					shutdownOption.PreStopDelay = RestartAcceptGrace
+				}
+			case <-app.stopFlag:
+				app.logger.Log("Received graceful stop request")
+				stopping = true
+			}
 		}
 
 		// Set the flag to abort the start process if it hasn't started yet.
 		app.isAlreadyStopped = true
 
-		// Set a timeout for the onStop and serverEngine.Stop
-		// If the onStop does not finish within the timeout, the app will forcefully stop.
-		success := util.TimeoutJob(func() {
-			// Stop the main engine to break the loop
-			app.serverEngine.Stop()
-
-			// Call the onStop lifecycle
-			app.onStop()
-		}, AppMaxStopTime)
-
-		if !success {
-			app.logger.Warnf("Failed to stop the app gracefully within time %s. Forcing stop.", AppMaxStopTime.String())
-		}
+		// Fail the readiness, wait for the load balancers, then stop the engine and the app
+		app.shutdown(shutdownOption)
 
 		// Send the shutdown signal
 		app.shutdownFlag <- "shutdown"
@@ -242,10 +521,24 @@ func (app *GimbapApp) run() {
 	if !app.isAlreadyStopped {
 		// Start the engine
 		app.logger.Log("App starting")
-		app.serverEngine.Run(engine.ServerRuntimeOption{
-			Port:      runtimeOpts.Port,
-			TLSOption: runtimeOpts.TLSOption,
-		}) // Blocking from here
+		serverOption := runtimeOpts.serverRuntimeOption()
+		serverOption.ReusePort = isPreforkProcess()
+		serverOption.OnReady = func(addr net.Addr) {
+			app.readyOnce.Do(func() {
+				if addr != nil {
+					app.logger.Logf("App is ready on %s", addr)
+				}
+				close(app.readyFlag)
+
+				// Let the parent process stop, if the app was started by a restart
+				engine.NotifyReady()
+			})
+
+			if runtimeOpts.OnReady != nil {
+				runtimeOpts.OnReady(addr)
+			}
+		}
+		app.serverEngine.Run(serverOption) // Blocking from here
 	} else {
 		app.logger.Log("App has been stopped before it started. Exiting.")
 		return
@@ -349,6 +642,7 @@ func (app *GimbapApp) Provide(injectionValue interface{}) {
 // Add middleware to the engine.
 //
 // This will be added as a global middleware to the engine.
+// Either an engine native middleware or a middleware provider can be given.
 func (app *GimbapApp) AddMiddleware(middleware ...interface{}) {
 	// Check if the engine is set.
 	if middleware == nil {
@@ -360,7 +654,11 @@ func (app *GimbapApp) AddMiddleware(middleware ...interface{}) {
 		app.logger.Panic("HttpEngine is not set. Cannot add middleware")
 	}
 
-	app.serverEngine.AddMiddleware(middleware...)
+	// Middleware providers are resolved after the dependency injection, so the registration is deferred until the app runs.
+	app.middlewares = append(app.middlewares, middleware...)
+	app.engineSetups = append(app.engineSetups, func() {
+		app.serverEngine.AddMiddleware(app.resolveMiddlewares(middleware)...)
+	})
 }
 
 // Add a microservice to the app.
@@ -383,10 +681,116 @@ func (app *GimbapApp) AddMicroServices(microservices ...*microservice.MicroServi
 	}
 }
 
+// Add global interceptors to the app.
+//
+// The interceptors are applied to all routes of all controllers, before the controller and route interceptors.
+// Either an interceptor instance or an interceptor provider can be given. Providers will be injected with their dependencies.
+func (app *GimbapApp) AddInterceptors(interceptors ...interface{}) {
+	if interceptors == nil {
+		app.logger.Warn("(AddInterceptors) At least 1 interceptor must be added to use this API. Skipping.")
+		return
+	}
+
+	app.interceptors = append(app.interceptors, interceptors...)
+}
+
+// Add global exception filters to the app.
+//
+// The filters are applied to all routes of all controllers, after the route and controller filters.
+// Errors not handled by any filter are written as RFC 9457 problem details.
+// Either an exception filter instance or an exception filter provider can be given.
+func (app *GimbapApp) AddExceptionFilters(filters ...interface{}) {
+	if filters == nil {
+		app.logger.Warn("(AddExceptionFilters) At least 1 exception filter must be added to use this API. Skipping.")
+		return
+	}
+
+	app.exceptionFilters = append(app.exceptionFilters, filters...)
+}
+
+// Enable the OpenAPI 3.1 document generation.
+//
+// The document is generated from the registered controllers when the app runs.
+// If the option's path is set, the document and the documentation UI are served at the path.
+func (app *GimbapApp) EnableOpenApi(option openapi.Option) {
+	app.openApiOption = &option
+}
+
+// Get the generated OpenAPI document.
+//
+// Returns nil if the OpenAPI generation is not enabled or the app has not run yet.
+func (app *GimbapApp) GetOpenApiDocument() *openapi.Document {
+	return app.openApiDocument
+}
+
+// Enable the API versioning of the controllers and the routes.
+//
+// The versions are given with ControllerOption.Versions and RouteSpec.Versions, and served under the version path (e.g. /api/v1/users).
+// With the header or the media type strategy, the requests are routed to the requested version by the header instead.
+func (app *GimbapApp) EnableVersioning(option versioning.Option) {
+	app.versioningOption = &option
+}
+
+// Enable the server side rendering of the views returned by the handlers. (view.View)
+//
+// The templates are loaded when the app runs, with html/template unless the option has another view engine.
+func (app *GimbapApp) EnableViews(option view.Option) {
+	app.views = view.New(option)
+}
+
+// Get the view engine of the app, to render the views in the native handlers. Returns nil if the views are not enabled.
+func (app *GimbapApp) GetViewEngine() view.IViewEngine {
+	if app.views == nil {
+		return nil
+	}
+
+	return app.views.Engine()
+}
+
+// Get the route table of the app.
+//
+// The routes are registered when the app runs, so the table is empty before that. (use the onStart listeners to read it)
+func (app *GimbapApp) GetRoutes() []route.RouteInfo {
+	return append([]route.RouteInfo{}, app.routes...)
+}
+
+// Print the route table of the app to the writer as a formatted table or JSON.
+func (app *GimbapApp) PrintRoutes(w io.Writer, format route.TableFormat) error {
+	return route.Write(w, app.routes, format)
+}
+
 // Add a static path to the engine.
 func (app *GimbapApp) AddStatic(path, root string, options ...interface{}) {
 	app.logger.Logf("Adding static path: %s --> %s", path, root)
-	app.serverEngine.AddStatic(path, root, options...)
+
+	// Deferred to keep the registration order with the middlewares
+	app.engineSetups = append(app.engineSetups, func() {
+		app.serverEngine.AddStatic(path, root, options...)
+	})
+}
+
+// Mount an http.Handler on the path prefix. (e.g. a legacy net/http mux or a third party handler)
+//
+// The handler serves all methods and sub paths of the prefix, and receives the request path without the prefix. (/legacy/users --> /users)
+// Deferred to keep the registration order with the middlewares.
+func (app *GimbapApp) Mount(prefix string, handler http.Handler) {
+	app.logger.Logf("Mounting handler: %s", prefix)
+
+	app.engineSetups = append(app.engineSetups, func() {
+		app.mount(prefix, handler)
+	})
+}
+
+// Get the address the server listens on. (e.g. to get the port chosen for Port 0)
+//
+// Returns nil until the server listens. Wait for Ready before reading it.
+func (app *GimbapApp) Addr() net.Addr {
+	return app.engineAddr()
+}
+
+// Get a channel closed once the server listens and accepts connections.
+func (app *GimbapApp) Ready() <-chan struct{} {
+	return app.readyFlag
 }
 
 // Stop the app
@@ -415,45 +819,45 @@ func (app *GimbapApp) Run(options ...RuntimeOptions) {
 		option = RuntimeOptions{Port: DefaultPort}
 	}
 
-	// Runtime option provider function
-	var optionProvider provider.Provider
-	if option.WithProvided != nil {
-		// TODO: Add a check for the input types of provided and see if it is in our provider map.
-		optionProvider = *provider.DefineProvider(provider.ProviderOption{
-			Name:         "RuntimeOptions",
-			Instantiator: option.WithProvided,
-		})
-	} else {
-		optionProvider = *provider.DefineProvider(provider.ProviderOption{
-			Name:         "RuntimeOptions",
-			Instantiator: func() RuntimeOptions { return option },
-		})
+	// The app set up by Handler runs with the listeners, the shutdown and the restart options given to Run.
+	// The options used by the set up (the provided options and the readiness path) are kept from Handler.
+	if app.isSetUp && len(options) > 0 {
+		app.applyRunOption(option)
 	}
 
-	// Call the dependency manager to inject the providers
-	providers := []*provider.Provider{}
+	// Supervise the prefork processes instead of serving the app. (the processes run the app with the same options)
+	if option.Prefork.Enabled && !isPreforkProcess() {
+		app.runPrefork(option)
+		return
+	}
 
-	// Collect all providers from the module
-	providers = append(providers, app.appModule.GetProviderList()...)
+	// Inject the providers and register the routes (skipped if already set up by Handler)
+	app.setup(option)
 
-	// Collect all microservices
-	for _, m := range app.microservices {
-		providers = append(providers, &m.Provider)
-	}
+	// Run the app (blocking from here)
+	app.run()
+}
 
-	// Add the runtime options provider
-	providers = append(providers, &optionProvider)
+// Get the app as an http.Handler without running it.
+//
+// The providers are injected and the routes are registered to the engine, but the engine does not listen,
+// and the lifecycle listeners and the microservices are not started. Useful for httptest or serving the app with an existing http.Server.
+// The app is set up with the runtime options provided to the providers. (RuntimeOptions{Port: DefaultPort} if not given)
+func (app *GimbapApp) Handler(options ...RuntimeOptions) http.Handler {
+	defer func() {
+		if r := recover(); r != nil {
+			app.logger.Panicf("Failed to set up the app. %s", r)
+		}
+	}()
 
-	// Add the functions with injection support
-	if app.functionsWithInjection != nil {
-		providers = append(providers, app.functionsWithInjection...)
+	option := RuntimeOptions{Port: DefaultPort}
+	if len(options) > 0 {
+		option = options[0]
 	}
 
-	// Inject the providers
-	app.depManager.ResolveDependencies(app.instanceMap, providers)
+	app.setup(option)
 
-	// Run the app (blocking from here)
-	app.run()
+	return app.engineHandler()
 }
 
 /*
@@ -525,6 +929,9 @@ func CreateApp(option AppOption) *GimbapApp {
 		onStartListeners: []func(){},
 		onStopListeners:  []func(){},
 
+		readyFlag:    make(chan struct{}),
+		readyOnce:    &sync.Once{},
+		draining:     &atomic.Bool{},
 		shutdownFlag: make(chan string),
 		stopFlag:     make(chan bool),
 	}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+)
+
+/*
+
+The optional capabilities of the server engine. (see engine.IServerEngine)
+The engines written for the earlier versions of IServerEngine run without the features of the capabilities they do not have.
+
+*/
+
+type (
+	// Controller of the routes of a spec, registered to the engines without IControllerSpecRegistrar
+	routeSpecController []controller.RouteSpec
+)
+
+func (c routeSpecController) GetRouteSpecs() []controller.RouteSpec { return c }
+
+// Register the controller spec to the engine.
+//
+// The engines without IControllerSpecRegistrar get the routes with the deprecated RegisterController,
+// and the interceptors, the exception filters and the middlewares are not applied.
+func (app *GimbapApp) registerController(spec engine.ControllerSpec) {
+	if e, ok := app.serverEngine.(engine.IControllerSpecRegistrar); ok {
+		e.RegisterControllerSpec(spec)
+		return
+	}
+
+	ignored := len(spec.Interceptors) > 0 || len(spec.ExceptionFilters) > 0 || len(spec.Middlewares) > 0
+	for _, r := range spec.Routes {
+		ignored = ignored || len(r.Interceptors) > 0 || len(r.ExceptionFilters) > 0 || len(r.Middlewares) > 0
+	}
+	if ignored {
+		app.logger.Warnf("%T does not implement IControllerSpecRegistrar. The interceptors, exception filters and middlewares of %s are not applied", app.serverEngine, spec.Name)
+	}
+
+	app.serverEngine.RegisterController(spec.RootPath, routeSpecController(spec.Routes))
+}
+
+// Get the global api prefix of the engine. Empty if the engine does not have one.
+func (app *GimbapApp) globalApiPrefix() string {
+	if e, ok := app.serverEngine.(engine.IGlobalApiPrefixProvider); ok {
+		return e.GetGlobalApiPrefix()
+	}
+
+	return ""
+}
+
+// Set the path rewriter to the engine. Panics if the engine cannot rewrite the paths.
+func (app *GimbapApp) setPathRewriter(rewriter engine.PathRewriter) {
+	e, ok := app.serverEngine.(engine.IPathRewritable)
+	if !ok {
+		app.logger.Panicf("%T does not implement IPathRewritable, which is required by the header and the media type versioning", app.serverEngine)
+	}
+
+	e.SetPathRewriter(rewriter)
+}
+
+// Mount the handler to the engine. Panics if the engine cannot mount handlers.
+func (app *GimbapApp) mount(prefix string, handler http.Handler) {
+	e, ok := app.serverEngine.(engine.IMountable)
+	if !ok {
+		app.logger.Panicf("%T does not implement IMountable. Cannot mount the handler on %s", app.serverEngine, prefix)
+	}
+
+	e.Mount(prefix, handler)
+}
+
+// Get the listening address of the engine. nil if the engine does not report it.
+func (app *GimbapApp) engineAddr() net.Addr {
+	if e, ok := app.serverEngine.(engine.IAddressReporter); ok {
+		return e.Addr()
+	}
+
+	return nil
+}
+
+// Get the engine as an http.Handler. Panics if the engine cannot be served as a handler.
+func (app *GimbapApp) engineHandler() http.Handler {
+	e, ok := app.serverEngine.(engine.IHandlerProvider)
+	if !ok {
+		app.logger.Panicf("%T does not implement IHandlerProvider. Cannot serve the app as an http.Handler", app.serverEngine)
+	}
+
+	return e.Handler()
+}
+
+// Get the request counts of the engine. Zero if the engine does not count the requests.
+func (app *GimbapApp) engineRequests() engine.RequestStats {
+	if e, ok := app.serverEngine.(engine.IRequestReporter); ok {
+		return e.Requests()
+	}
+
+	return engine.RequestStats{}
+}
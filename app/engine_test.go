@@ -0,0 +1,76 @@
+package app_test
+
+import (
+	"time"
+
+	"github.com/jhseong7/gimbap/app"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/module"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type (
+	// Engine implementing only the first version of IServerEngine, without the optional capabilities
+	legacyEngine struct {
+		rootPaths []string
+		routes    []controller.RouteSpec
+
+		running chan struct{}
+		stop    chan struct{}
+	}
+
+	legacyController struct{}
+)
+
+func (e *legacyEngine) RegisterController(rootPath string, c controller.IController) {
+	e.rootPaths = append(e.rootPaths, rootPath)
+	e.routes = append(e.routes, c.GetRouteSpecs()...)
+}
+
+func (e *legacyEngine) Run(option engine.ServerRuntimeOption) {
+	close(e.running)
+	<-e.stop
+}
+
+func (e *legacyEngine) Stop()                                                { close(e.stop) }
+func (e *legacyEngine) AddMiddleware(middleware ...interface{})              {}
+func (e *legacyEngine) AddStatic(prefix, root string, config ...interface{}) {}
+
+func (c *legacyController) GetRouteSpecs() []controller.RouteSpec {
+	return []controller.RouteSpec{
+		{Method: "GET", Path: "items", Handler: controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) { return nil, nil })},
+	}
+}
+
+var _ = Describe("Engine", func() {
+	It("should register the controllers to the engines without the optional capabilities", func() {
+		appModule := module.DefineModule(module.ModuleOption{
+			Name: "LegacyModule",
+			Controllers: []*controller.Controller{controller.DefineController(controller.ControllerOption{
+				Name:         "LegacyController",
+				Instantiator: func() *legacyController { return &legacyController{} },
+				RootPath:     "legacy",
+			})},
+		})
+
+		e := &legacyEngine{running: make(chan struct{}), stop: make(chan struct{})}
+		a := app.CreateApp(app.AppOption{AppName: "Legacy", AppModule: appModule, ServerEngine: e})
+
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			a.Run()
+		}()
+		Eventually(e.running, 10*time.Second).Should(BeClosed())
+
+		Expect(e.rootPaths).To(Equal([]string{"legacy"}))
+		Expect(e.routes).To(HaveLen(1))
+		Expect(e.routes[0].Path).To(Equal("items"))
+		Expect(a.Addr()).To(BeNil())
+
+		a.Stop()
+		Eventually(stopped, 10*time.Second).Should(BeClosed())
+	})
+})
@@ -0,0 +1,55 @@
+package app_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/jhseong7/gimbap/app"
+	"github.com/jhseong7/gimbap/module"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	It("should set up the app with the runtime options given to Handler, then run with the options given to Run", func() {
+		appModule := module.DefineModule(module.ModuleOption{Name: "HandlerModule"})
+		a := app.CreateApp(app.AppOption{AppName: "Handler", AppModule: appModule})
+
+		// The readiness is served on the path resolved by WithProvided
+		handler := a.Handler(app.RuntimeOptions{
+			WithProvided: func() app.RuntimeOptions {
+				return app.RuntimeOptions{Shutdown: app.ShutdownOption{ReadinessPath: "/readyz"}}
+			},
+		})
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", "/readyz", nil))
+		Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+
+		// The listener and the ready callback of Run are used
+		ready := make(chan net.Addr, 1)
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			a.Run(app.RuntimeOptions{
+				Host:    "127.0.0.1",
+				OnReady: func(addr net.Addr) { ready <- addr },
+			})
+		}()
+
+		var addr net.Addr
+		Eventually(ready, 10*time.Second).Should(Receive(&addr))
+		Expect(addr.(*net.TCPAddr).Port).NotTo(Equal(app.DefaultPort))
+
+		r, err := http.Get(fmt.Sprintf("http://%s/readyz", addr))
+		Expect(err).To(BeNil())
+		r.Body.Close()
+		Expect(r.StatusCode).To(Equal(http.StatusOK))
+
+		a.Stop()
+		Eventually(stopped, 10*time.Second).Should(BeClosed())
+	})
+})
@@ -0,0 +1,124 @@
+package app_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/jhseong7/gimbap/app"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/module"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// Environment variables of the helper app: the mode of the app and its port
+	helperEnv     = "GIMBAP_TEST_HELPER"
+	helperPortEnv = "GIMBAP_TEST_HELPER_PORT"
+
+	// Exit the new processes of a restart before they are ready
+	helperFailRestartEnv = "GIMBAP_TEST_HELPER_FAIL_RESTART"
+)
+
+// Controller of the helper app responding with the pid of the process
+type pidController struct{}
+
+func (c *pidController) GetRouteSpecs() []controller.RouteSpec {
+	return []controller.RouteSpec{
+		{Method: "GET", Path: "pid", Handler: controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) {
+			return os.Getpid(), nil
+		})},
+	}
+}
+
+// Run the app of the helper process in the mode of the environment variable (blocking)
+func runHelperApp() {
+	if os.Getenv(helperFailRestartEnv) != "" && os.Getenv("GIMBAP_HANDOFF_KEYS") != "" {
+		os.Exit(3)
+	}
+
+	port, _ := strconv.Atoi(os.Getenv(helperPortEnv))
+	option := app.RuntimeOptions{Host: "127.0.0.1", Port: port}
+
+	switch mode := os.Getenv(helperEnv); mode {
+	case "restart":
+		option.Restart = app.RestartOption{Enabled: true}
+	case "prefork":
+		option.Prefork = app.PreforkOption{Enabled: true, Processes: 2, RestartDelay: 100 * time.Millisecond}
+	default:
+		panic("unknown helper mode " + mode)
+	}
+
+	appModule := module.DefineModule(module.ModuleOption{
+		Name: "HelperModule",
+		Controllers: []*controller.Controller{controller.DefineController(controller.ControllerOption{
+			Name:         "PidController",
+			Instantiator: func() *pidController { return &pidController{} },
+		})},
+	})
+
+	app.CreateApp(app.AppOption{AppName: "Helper", AppModule: appModule}).Run(option)
+}
+
+// Start the helper app in a new process of the test binary. The process is killed when the test ends.
+func startHelperApp(mode string, port int, env ...string) *exec.Cmd {
+	executable, err := os.Executable()
+	Expect(err).To(BeNil())
+
+	// The output is not captured, as the processes started by the helper would keep the pipe open
+	cmd := exec.Command(executable)
+	cmd.Env = append(os.Environ(), append(env, helperEnv+"="+mode, helperPortEnv+"="+strconv.Itoa(port))...)
+	Expect(cmd.Start()).To(Succeed())
+
+	DeferCleanup(func() {
+		if cmd.ProcessState == nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	})
+
+	return cmd
+}
+
+// Get the pid of the process serving a new connection to the helper app
+func getPid(port int) (int, error) {
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+
+	res, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/pid", port))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(string(body))
+}
+
+// Wait for the helper app and get the pid of the process serving it
+func waitForPid(port int) int {
+	var pid int
+	Eventually(func() (err error) {
+		pid, err = getPid(port)
+		return err
+	}, 20*time.Second, 50*time.Millisecond).Should(Succeed())
+
+	return pid
+}
+
+func freePort() int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"reflect"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/interceptor"
+	"github.com/jhseong7/gimbap/provider"
+	"github.com/jhseong7/gimbap/util"
+)
+
+/*
+
+Injectables are values that can be given either as an instance or as a provider. (e.g. interceptors, exception filters, middlewares)
+If a provider is given, the instance is created by the dependency manager and resolved before the registration.
+
+*/
+
+// Get all the injectable entries referenced by the app and the controllers of the app module.
+func (app *GimbapApp) getInjectableEntries() []interface{} {
+	entries := []interface{}{}
+	entries = append(entries, app.interceptors...)
+	entries = append(entries, app.exceptionFilters...)
+	entries = append(entries, app.middlewares...)
+
+	for _, c := range app.getControllers() {
+		entries = append(entries, c.Interceptors...)
+		entries = append(entries, c.ExceptionFilters...)
+		entries = append(entries, c.Middlewares...)
+	}
+
+	return entries
+}
+
+// Get the injectable entries of the routes.
+func getRouteInjectableEntries(routeSpecs []controller.RouteSpec) []interface{} {
+	entries := []interface{}{}
+
+	for _, r := range routeSpecs {
+		entries = append(entries, r.Interceptors...)
+		entries = append(entries, r.ExceptionFilters...)
+		entries = append(entries, r.Middlewares...)
+	}
+
+	return entries
+}
+
+// Collect the providers of the injectables that are not in the given provider list.
+//
+// The same provider can be referenced multiple times (e.g. by several controllers), so the providers are deduplicated by their type.
+func (app *GimbapApp) collectInjectableProviders(providers []*provider.Provider) []*provider.Provider {
+	knownTypes := map[reflect.Type]bool{}
+	for _, p := range providers {
+		if t, ok := util.DeriveTypeFromInstantiator(p.Instantiator); ok {
+			knownTypes[t] = true
+		}
+	}
+
+	injectableProviders := []*provider.Provider{}
+	for _, entry := range app.getInjectableEntries() {
+		p, ok := provider.ExtractProvider(entry)
+		if !ok {
+			continue
+		}
+
+		t, ok := util.DeriveTypeFromInstantiator(p.Instantiator)
+		if !ok || knownTypes[t] {
+			continue
+		}
+
+		knownTypes[t] = true
+		injectableProviders = append(injectableProviders, p)
+	}
+
+	return injectableProviders
+}
+
+// Instantiate the injectables given as providers only to the routes.
+//
+// The route specs are read from the controller instances, so these providers cannot be part of the dependency injection.
+// They are instantiated here with the dependencies from the instance map. (e.g. the providers of the module)
+func (app *GimbapApp) instantiateRouteInjectables(routeSpecs []controller.RouteSpec) {
+	pending := []*provider.Provider{}
+	for _, entry := range getRouteInjectableEntries(routeSpecs) {
+		if p, ok := provider.ExtractProvider(entry); ok {
+			pending = append(pending, p)
+		}
+	}
+
+	// The providers can depend on each other, so instantiate them until none is left
+	for len(pending) > 0 {
+		remaining := []*provider.Provider{}
+		for _, p := range pending {
+			if !app.instantiateInjectable(p) {
+				remaining = append(remaining, p)
+			}
+		}
+
+		if len(remaining) == len(pending) {
+			app.logger.Panicf("Failed to instantiate the route injectable %s. Are its dependencies provided by the module?", remaining[0].Name)
+		}
+
+		pending = remaining
+	}
+}
+
+// Instantiate the injectable provider with the dependencies from the instance map.
+//
+// Returns false if a dependency is not instantiated yet. Providers already instantiated are skipped.
+func (app *GimbapApp) instantiateInjectable(p *provider.Provider) bool {
+	returnTypes, ok := util.DeriveTypeListFromInstantiator(p.Instantiator)
+	if !ok {
+		app.logger.Panicf("Failed to derive type from instantiator: %v", p.Instantiator)
+	}
+
+	if _, ok := app.instanceMap[returnTypes[0]]; ok {
+		return true
+	}
+
+	inputTypes, ok := util.DeriveInputTypesFromInstantiator(p.Instantiator)
+	if !ok {
+		app.logger.Panicf("Failed to derive input types from instantiator: %v", p.Instantiator)
+	}
+
+	inputs := make([]reflect.Value, len(inputTypes))
+	for i, t := range inputTypes {
+		if inputs[i], ok = app.instanceMap[t]; !ok {
+			return false
+		}
+	}
+
+	for i, v := range reflect.ValueOf(p.Instantiator).Call(inputs) {
+		app.instanceMap[returnTypes[i]] = v
+	}
+
+	return true
+}
+
+// Resolve the injectable into its instance.
+//
+// If the injectable is a provider, the instance is fetched from the instance map. Otherwise the value is returned as is.
+func (app *GimbapApp) resolveInjectable(entry interface{}) interface{} {
+	p, ok := provider.ExtractProvider(entry)
+	if !ok {
+		return entry
+	}
+
+	instanceType, ok := util.DeriveTypeFromInstantiator(p.Instantiator)
+	if !ok {
+		app.logger.Panicf("Failed to derive type from instantiator: %v", p.Instantiator)
+	}
+
+	instVal, ok := app.instanceMap[instanceType]
+	if !ok {
+		app.logger.Panicf("Instance of %s not found. Is the provider registered to the app, a controller or a route?", p.Name)
+	}
+
+	return instVal.Interface()
+}
+
+// Resolve the interceptor entries to interceptor instances.
+func (app *GimbapApp) resolveInterceptors(entries []interface{}) []interceptor.IInterceptor {
+	interceptors := make([]interceptor.IInterceptor, 0, len(entries))
+
+	for _, entry := range entries {
+		resolved := app.resolveInjectable(entry)
+
+		casted, ok := resolved.(interceptor.IInterceptor)
+		if !ok {
+			app.logger.Panicf("Interceptor does not implement IInterceptor: %s", reflect.TypeOf(resolved).String())
+		}
+
+		interceptors = append(interceptors, casted)
+	}
+
+	return interceptors
+}
+
+// Resolve the exception filter entries to exception filter instances.
+func (app *GimbapApp) resolveExceptionFilters(entries []interface{}) []exception.IExceptionFilter {
+	filters := make([]exception.IExceptionFilter, 0, len(entries))
+
+	for _, entry := range entries {
+		resolved := app.resolveInjectable(entry)
+
+		casted, ok := resolved.(exception.IExceptionFilter)
+		if !ok {
+			app.logger.Panicf("Exception filter does not implement IExceptionFilter: %s", reflect.TypeOf(resolved).String())
+		}
+
+		filters = append(filters, casted)
+	}
+
+	return filters
+}
+
+// Resolve the middleware entries to engine native middlewares or middleware instances.
+//
+// The middleware instances (IMiddleware) are kept as is, so the engine gets the native middleware from them.
+func (app *GimbapApp) resolveMiddlewares(entries []interface{}) []interface{} {
+	middlewares := make([]interface{}, 0, len(entries))
+
+	for _, entry := range entries {
+		middlewares = append(middlewares, app.resolveInjectable(entry))
+	}
+
+	return middlewares
+}
+
+// Resolve the injectables in the route specs.
+func (app *GimbapApp) resolveRouteSpecs(routeSpecs []controller.RouteSpec) []controller.RouteSpec {
+	resolved := make([]controller.RouteSpec, 0, len(routeSpecs))
+
+	for _, r := range routeSpecs {
+		if len(r.Interceptors) > 0 {
+			interceptors := []interface{}{}
+			for _, i := range app.resolveInterceptors(r.Interceptors) {
+				interceptors = append(interceptors, i)
+			}
+			r.Interceptors = interceptors
+		}
+
+		if len(r.ExceptionFilters) > 0 {
+			filters := []interface{}{}
+			for _, f := range app.resolveExceptionFilters(r.ExceptionFilters) {
+				filters = append(filters, f)
+			}
+			r.ExceptionFilters = filters
+		}
+
+		if len(r.Middlewares) > 0 {
+			r.Middlewares = app.resolveMiddlewares(r.Middlewares)
+		}
+
+		resolved = append(resolved, r)
+	}
+
+	return resolved
+}
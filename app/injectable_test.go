@@ -0,0 +1,106 @@
+package app_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jhseong7/gimbap/app"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/interceptor"
+	"github.com/jhseong7/gimbap/module"
+	"github.com/jhseong7/gimbap/provider"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type (
+	// Provider of the module injected into the route injectables
+	greeting struct {
+		text string
+	}
+
+	// Interceptor provider replacing the result with the greeting
+	greetingInterceptor struct {
+		greeting *greeting
+	}
+
+	// Exception filter provider writing the greeting with 418
+	greetingFilter struct {
+		greeting *greeting
+	}
+
+	routeInjectableController struct{}
+)
+
+func (i *greetingInterceptor) Intercept(ctx controller.ExecutionContext, next interceptor.CallHandler) (interface{}, error) {
+	if _, err := next(); err != nil {
+		return nil, err
+	}
+
+	return i.greeting.text, nil
+}
+
+func (f *greetingFilter) Catch(ctx controller.ExecutionContext, err error) error {
+	return ctx.JSON(http.StatusTeapot, f.greeting.text)
+}
+
+var (
+	greetingInterceptorProvider = interceptor.DefineInterceptor(interceptor.InterceptorOption{
+		Name:         "GreetingInterceptor",
+		Instantiator: func(g *greeting) *greetingInterceptor { return &greetingInterceptor{greeting: g} },
+	})
+
+	greetingFilterProvider = exception.DefineExceptionFilter(exception.ExceptionFilterOption{
+		Name:         "GreetingFilter",
+		Instantiator: func(g *greeting) *greetingFilter { return &greetingFilter{greeting: g} },
+	})
+)
+
+func (c *routeInjectableController) GetRouteSpecs() []controller.RouteSpec {
+	return []controller.RouteSpec{
+		{
+			Method:       "GET",
+			Path:         "intercepted",
+			Handler:      controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) { return "handler", nil }),
+			Interceptors: []interface{}{greetingInterceptorProvider},
+		},
+		{
+			Method:           "GET",
+			Path:             "filtered",
+			Handler:          controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) { return nil, errors.New("failed") }),
+			ExceptionFilters: []interface{}{greetingFilterProvider},
+		},
+	}
+}
+
+var _ = Describe("Injectable", func() {
+	It("should instantiate the providers given only to the routes with the dependencies of the module", func() {
+		appModule := module.DefineModule(module.ModuleOption{
+			Name: "RouteInjectableModule",
+			Providers: []*provider.Provider{provider.DefineProvider(provider.ProviderOption{
+				Name:         "Greeting",
+				Instantiator: func() *greeting { return &greeting{text: "hello"} },
+			})},
+			Controllers: []*controller.Controller{controller.DefineController(controller.ControllerOption{
+				Name:         "RouteInjectableController",
+				Instantiator: func() *routeInjectableController { return &routeInjectableController{} },
+				RootPath:     "injectable",
+			})},
+		})
+
+		handler := app.CreateApp(app.AppOption{AppName: "Injectable", AppModule: appModule}).Handler()
+
+		for path, status := range map[string]int{
+			"/injectable/intercepted": http.StatusOK,
+			"/injectable/filtered":    http.StatusTeapot,
+		} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+			Expect(w.Code).To(Equal(status), path)
+			Expect(w.Body.String()).To(Equal(`"hello"`), path)
+		}
+	})
+})
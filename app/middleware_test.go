@@ -0,0 +1,105 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhseong7/gimbap/app"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/middleware"
+	"github.com/jhseong7/gimbap/module"
+	"github.com/jhseong7/gimbap/provider"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type (
+	// Provider of the module injected into the middleware provider
+	middlewareNames struct {
+		controller string
+		route      string
+	}
+
+	// Middleware provider adding the name given by the module
+	namedMiddleware struct {
+		names *middlewareNames
+	}
+
+	// Middleware provider given only to a route
+	routeNamedMiddleware struct {
+		names *middlewareNames
+	}
+
+	orderController struct{}
+)
+
+// Gin middleware adding the name to the X-Middleware header
+func named(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("X-Middleware", name)
+		c.Next()
+	}
+}
+
+func (m *namedMiddleware) Middleware() interface{} {
+	return named(m.names.controller)
+}
+
+func (m *routeNamedMiddleware) Middleware() interface{} {
+	return named(m.names.route)
+}
+
+var routeMiddlewareProvider = middleware.DefineMiddleware(middleware.MiddlewareOption{
+	Name:         "RouteNamedMiddleware",
+	Instantiator: func(names *middlewareNames) *routeNamedMiddleware { return &routeNamedMiddleware{names: names} },
+})
+
+func (c *orderController) GetRouteSpecs() []controller.RouteSpec {
+	ok := controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) { return "ok", nil })
+
+	return []controller.RouteSpec{
+		{Method: "GET", Path: "route", Handler: ok, Middlewares: []interface{}{named("route")}},
+		{Method: "GET", Path: "provided", Handler: ok, Middlewares: []interface{}{routeMiddlewareProvider}},
+		{Method: "GET", Path: "plain", Handler: ok},
+	}
+}
+
+var _ = Describe("Middleware", func() {
+	It("should run the global, the controller and the route middlewares in order, with the providers resolved from the module", func() {
+		middlewareProvider := middleware.DefineMiddleware(middleware.MiddlewareOption{
+			Name:         "NamedMiddleware",
+			Instantiator: func(names *middlewareNames) *namedMiddleware { return &namedMiddleware{names: names} },
+		})
+
+		appModule := module.DefineModule(module.ModuleOption{
+			Name: "OrderModule",
+			Providers: []*provider.Provider{provider.DefineProvider(provider.ProviderOption{
+				Name:         "MiddlewareNames",
+				Instantiator: func() *middlewareNames { return &middlewareNames{controller: "controller", route: "provided route"} },
+			})},
+			Controllers: []*controller.Controller{controller.DefineController(controller.ControllerOption{
+				Name:         "OrderController",
+				Instantiator: func() *orderController { return &orderController{} },
+				RootPath:     "order",
+				Middlewares:  []interface{}{middlewareProvider},
+			})},
+		})
+
+		a := app.CreateApp(app.AppOption{AppName: "Middleware", AppModule: appModule})
+		a.AddMiddleware(named("global"))
+		handler := a.Handler()
+
+		for path, expected := range map[string][]string{
+			"/order/route":    {"global", "controller", "route"},
+			"/order/provided": {"global", "controller", "provided route"},
+			"/order/plain":    {"global", "controller"},
+		} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Header().Values("X-Middleware")).To(Equal(expected), path)
+		}
+	})
+})
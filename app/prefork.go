@@ -0,0 +1,237 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jhseong7/gimbap/engine"
+)
+
+/*
+
+In the prefork mode, the app process supervises the processes serving the app, instead of serving it:
+
+1. The processes of the executable start with the same arguments, and listen on the same ports with SO_REUSEPORT.
+   The kernel balances the connections between them.
+2. A process that exits is started again after the restart delay.
+3. SIGINT and SIGTERM are sent to all processes, which stop in stages. (see shutdown.go) The processes still running after
+   their stop time are killed.
+
+Each process runs the whole app: the providers, the lifecycle listeners and the microservices.
+
+*/
+
+type (
+	// Option of the prefork mode of the app
+	PreforkOption struct {
+		// Serve the app with several processes sharing the ports. Not supported on Windows.
+		Enabled bool
+
+		// Number of the processes. (runtime.NumCPU() if 0)
+		Processes int
+
+		// Time to wait before starting a process again once it exits. (1 second if 0)
+		RestartDelay time.Duration
+	}
+)
+
+const (
+	// Environment variable of the prefork processes, set to the index of the process
+	preforkEnv = "GIMBAP_PREFORK_PROCESS"
+
+	// Time given to the processes to exit after their stop time, before they are killed
+	preforkStopMargin = time.Second
+)
+
+func (o PreforkOption) getProcesses() int {
+	if o.Processes > 0 {
+		return o.Processes
+	}
+
+	return runtime.NumCPU()
+}
+
+func (o PreforkOption) getRestartDelay() time.Duration {
+	if o.RestartDelay > 0 {
+		return o.RestartDelay
+	}
+
+	return time.Second
+}
+
+// Check if this process is a prefork process started by the app
+func isPreforkProcess() bool {
+	return os.Getenv(preforkEnv) != ""
+}
+
+// Check if the listeners of the option can be shared between the processes. (the ports are bound by each process)
+func checkPreforkListeners(option RuntimeOptions) error {
+	// The processes would fail to listen and be restarted forever
+	if !engine.ReusePortSupported {
+		return errors.New("prefork requires SO_REUSEPORT, which is not supported on this platform")
+	}
+
+	listeners := option.serverRuntimeOption().ListenerOptions()
+
+	for _, l := range listeners {
+		switch {
+		case l.Listener != nil || l.SocketActivation:
+			return errors.New("prefork cannot share a listener created outside the app (Listener or SocketActivation)")
+		case !strings.HasPrefix(l.ListenNetwork(), "tcp"):
+			return errors.New("prefork supports the TCP listeners only")
+		case l.Port == 0:
+			return errors.New("prefork requires a fixed port, as each process would listen on a different ephemeral port")
+		}
+	}
+
+	return nil
+}
+
+// Stop the app if the supervising process exits, so the prefork processes do not outlive it. (e.g. if it is killed)
+func (app *GimbapApp) watchPreforkParent() {
+	parent := os.Getppid()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if os.Getppid() != parent {
+			app.logger.Warn("The prefork supervisor exited. Stopping the app")
+			app.Stop()
+			return
+		}
+	}
+}
+
+// Supervise the prefork processes until the app stops. (blocking)
+func (app *GimbapApp) runPrefork(option RuntimeOptions) {
+	if err := checkPreforkListeners(option); err != nil {
+		app.logger.Fatalf("Failed to start the prefork processes. %s", err)
+	}
+	if option.Restart.Enabled {
+		app.logger.Warn("The graceful restart is not supported with prefork. The restart signal is ignored.")
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	count, delay := option.Prefork.getProcesses(), option.Prefork.getRestartDelay()
+	processes := make([]*engine.Process, count)
+	exited := make(chan int, count)
+	restart := make(chan int, count)
+
+	// The app is ready once a process of each index is ready. (the processes that fail before they are ready are started again)
+	ready := make(chan int, count)
+	readyProcesses := make([]bool, count)
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	start := func(i int) {
+		p, err := engine.StartProcess(preforkEnv + "=" + strconv.Itoa(i+1))
+		if err != nil {
+			app.logger.Warnf("Failed to start the prefork process %d. Retrying in %s. %s", i+1, delay, err)
+			time.AfterFunc(delay, func() { restart <- i })
+			return
+		}
+
+		processes[i] = p
+		go func() {
+			<-p.Done()
+			exited <- i
+		}()
+		go func() {
+			select {
+			case <-p.Ready():
+			case <-p.Done():
+				return
+			}
+
+			select {
+			case ready <- i:
+			case <-stopped:
+			}
+		}()
+	}
+
+	app.logger.Logf("Starting %d prefork processes", count)
+	for i := range processes {
+		start(i)
+	}
+
+	// Supervise the processes until the stop signal
+	var stopSignal os.Signal = syscall.SIGTERM
+	for stopping := false; !stopping; {
+		select {
+		case i := <-exited:
+			app.logger.Warnf("The prefork process %d (pid %d) exited: %v. Restarting in %s", i+1, processes[i].Pid(), processes[i].Err(), delay)
+			processes[i] = nil
+			time.AfterFunc(delay, func() { restart <- i })
+		case i := <-restart:
+			start(i)
+		case i := <-ready:
+			readyProcesses[i] = true
+			if !slices.Contains(readyProcesses, false) {
+				app.readyOnce.Do(func() {
+					app.logger.Logf("All %d prefork processes are ready", count)
+					close(app.readyFlag)
+				})
+			}
+		case sig := <-sigs:
+			app.logger.Logf("Received signal: %s", sig)
+			stopSignal, stopping = sig, true
+		case <-app.stopFlag:
+			app.logger.Log("Received graceful stop request")
+			stopping = true
+		}
+	}
+
+	app.draining.Store(true)
+	app.stopPrefork(processes, stopSignal, option.Shutdown.PreStopDelay+option.Shutdown.getTimeout()+preforkStopMargin)
+	app.logger.Log("App has been shut down")
+}
+
+// Send the stop signal to the processes, and kill the processes still running after the timeout.
+func (app *GimbapApp) stopPrefork(processes []*engine.Process, sig os.Signal, timeout time.Duration) {
+	app.logger.Logf("Stopping the prefork processes (Max %s)", timeout)
+
+	running := []*engine.Process{}
+	for _, p := range processes {
+		if p != nil {
+			p.Signal(sig)
+			running = append(running, p)
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for _, p := range running {
+		select {
+		case <-p.Done():
+			continue
+		case <-timer.C:
+		}
+
+		for _, r := range running {
+			select {
+			case <-r.Done():
+			default:
+				app.logger.Warnf("The prefork process (pid %d) did not stop within %s. Killing it", r.Pid(), timeout)
+				r.Kill()
+			}
+		}
+		break
+	}
+
+	for _, p := range running {
+		<-p.Done()
+	}
+}
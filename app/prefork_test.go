@@ -0,0 +1,79 @@
+//go:build unix
+
+package app_test
+
+import (
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Get the pids of the prefork processes, by sending requests until the given number of processes respond
+func waitForPids(port, count int) map[int]bool {
+	pids := map[int]bool{}
+	Eventually(func() int {
+		if pid, err := getPid(port); err == nil {
+			pids[pid] = true
+		}
+		return len(pids)
+	}, 20*time.Second, 10*time.Millisecond).Should(BeNumerically(">=", count))
+
+	return pids
+}
+
+var _ = Describe("Prefork", func() {
+	It("should start a process again once it crashes", func() {
+		port := freePort()
+		supervisor := startHelperApp("prefork", port)
+
+		pids := waitForPids(port, 2)
+		Expect(pids).NotTo(HaveKey(supervisor.Process.Pid))
+		DeferCleanup(func() {
+			for pid := range pids {
+				syscall.Kill(pid, syscall.SIGKILL)
+			}
+		})
+
+		var crashed int
+		for pid := range pids {
+			crashed = pid
+			break
+		}
+		Expect(syscall.Kill(crashed, syscall.SIGKILL)).To(Succeed())
+
+		// A new process serves the requests with the remaining one
+		Eventually(func() bool {
+			pid, err := getPid(port)
+			if err != nil || pids[pid] {
+				return false
+			}
+
+			pids[pid] = true
+			return true
+		}, 20*time.Second, 10*time.Millisecond).Should(BeTrue())
+
+		// The processes stop with the supervisor
+		Expect(supervisor.Process.Signal(syscall.SIGTERM)).To(Succeed())
+		Expect(supervisor.Wait()).To(Succeed())
+		Expect(getPid(port)).Error().To(HaveOccurred())
+	})
+
+	It("should stop the processes once the supervisor is killed", func() {
+		port := freePort()
+		supervisor := startHelperApp("prefork", port)
+
+		pids := waitForPids(port, 2)
+		DeferCleanup(func() {
+			for pid := range pids {
+				syscall.Kill(pid, syscall.SIGKILL)
+			}
+		})
+
+		Expect(supervisor.Process.Kill()).To(Succeed())
+		supervisor.Wait()
+
+		Eventually(func() error { _, err := getPid(port); return err }, 20*time.Second, 100*time.Millisecond).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	"github.com/jhseong7/gimbap/engine"
+)
+
+/*
+
+The app restarts without dropping connections (e.g. to upgrade the binary) on the restart signal:
+
+1. A new process of the executable starts with the listening sockets of the app. (engine.Restart)
+2. The new process serves the requests on the same sockets, and reports that it is ready.
+3. The app stops accepting the connections, and stops like on SIGTERM, draining its in-flight requests. (with RestartAcceptGrace as the pre-stop delay)
+
+The app keeps running if the new process fails to start or is not ready in time.
+
+*/
+
+type (
+	// Option of the graceful restart of the app
+	RestartOption struct {
+		// Restart the app on the signal. Not supported on Windows.
+		Enabled bool
+
+		// Signal to restart the app. (SIGUSR2 if nil)
+		Signal os.Signal
+
+		// Max time for the new process to be ready. (30 seconds if 0)
+		ReadyTimeout time.Duration
+	}
+)
+
+const (
+	DefaultRestartReadyTimeout = 30 * time.Second
+
+	// Time for the connections accepted before the handoff to send their requests, as net/http closes the connections
+	// without a request once the server shuts down.
+	RestartAcceptGrace = time.Second
+)
+
+// Get the signal to restart the app. (nil if disabled or not supported)
+func (o RestartOption) getSignal() os.Signal {
+	if !o.Enabled {
+		return nil
+	}
+	if o.Signal != nil {
+		return o.Signal
+	}
+
+	return defaultRestartSignal
+}
+
+func (o RestartOption) getReadyTimeout() time.Duration {
+	if o.ReadyTimeout > 0 {
+		return o.ReadyTimeout
+	}
+
+	return DefaultRestartReadyTimeout
+}
+
+// Start the new process of the app with the listeners. Returns true if the new process is ready, so this process must stop.
+func (app *GimbapApp) restart(option RestartOption) bool {
+	app.logger.Log("Restarting the app")
+
+	process, err := engine.Restart(option.getReadyTimeout())
+	if err != nil {
+		app.logger.Warnf("Failed to restart the app, keeping this process running. %s", err)
+		return false
+	}
+
+	app.logger.Logf("The new process %d is ready. Stopping this process", process.Pid())
+	return true
+}
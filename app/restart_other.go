@@ -0,0 +1,8 @@
+//go:build !unix
+
+package app
+
+import "os"
+
+// The sockets cannot be inherited by a new process, so the restart is not supported.
+var defaultRestartSignal os.Signal
@@ -0,0 +1,117 @@
+//go:build unix
+
+package app_test
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Requests sent continuously to the helper app, on new connections
+type requestLoop struct {
+	mutex  sync.Mutex
+	pids   []int
+	errors []error
+
+	// Time of the first response of a process other than the parent
+	handedOver time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startRequestLoop(port, parent int) *requestLoop {
+	l := &requestLoop{stop: make(chan struct{}), done: make(chan struct{})}
+
+	go func() {
+		defer close(l.done)
+
+		for {
+			select {
+			case <-l.stop:
+				return
+			default:
+			}
+
+			pid, err := getPid(port)
+
+			l.mutex.Lock()
+			if err != nil {
+				l.errors = append(l.errors, err)
+			} else {
+				if pid != parent && l.handedOver.IsZero() {
+					l.handedOver = time.Now()
+				}
+				l.pids = append(l.pids, pid)
+			}
+			l.mutex.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	return l
+}
+
+func (l *requestLoop) served() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.pids)
+}
+
+func (l *requestLoop) end() {
+	close(l.stop)
+	<-l.done
+}
+
+var _ = Describe("Restart", func() {
+	It("should hand the listeners over to the new process, and exit once the new process serves the requests", func() {
+		port := freePort()
+		parent := startHelperApp("restart", port)
+		Expect(waitForPid(port)).To(Equal(parent.Process.Pid))
+
+		loop := startRequestLoop(port, parent.Process.Pid)
+		Eventually(loop.served).ShouldNot(BeZero())
+		Expect(parent.Process.Signal(syscall.SIGUSR2)).To(Succeed())
+
+		exited := make(chan time.Time, 1)
+		go func() {
+			parent.Wait()
+			exited <- time.Now()
+		}()
+
+		var exitedAt time.Time
+		Eventually(exited, 20*time.Second).Should(Receive(&exitedAt))
+
+		// The requests sent after the parent exited are served by the new process
+		child := waitForPid(port)
+		DeferCleanup(func() { syscall.Kill(child, syscall.SIGKILL) })
+		Expect(child).NotTo(Equal(parent.Process.Pid))
+
+		loop.end()
+		Expect(loop.errors).To(BeEmpty())
+		Expect(loop.pids).To(ContainElement(child))
+		Expect(loop.handedOver.IsZero()).To(BeFalse())
+		Expect(loop.handedOver.Before(exitedAt)).To(BeTrue())
+		Expect(parent.ProcessState.Success()).To(BeTrue())
+
+		// The new process stops like the parent
+		Expect(syscall.Kill(child, syscall.SIGTERM)).To(Succeed())
+		Eventually(func() error { _, err := getPid(port); return err }, 20*time.Second).ShouldNot(Succeed())
+	})
+
+	It("should keep running if the new process exits before it is ready", func() {
+		port := freePort()
+		parent := startHelperApp("restart", port, helperFailRestartEnv+"=1")
+		Expect(waitForPid(port)).To(Equal(parent.Process.Pid))
+
+		Expect(parent.Process.Signal(syscall.SIGUSR2)).To(Succeed())
+
+		Consistently(func() (int, error) { return getPid(port) }, 2*time.Second, 100*time.Millisecond).Should(Equal(parent.Process.Pid))
+	})
+})
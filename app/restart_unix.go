@@ -0,0 +1,8 @@
+//go:build unix
+
+package app
+
+import "syscall"
+
+// SIGUSR2 restarts the app by default. (as nginx and other servers upgrading the binary)
+var defaultRestartSignal = syscall.SIGUSR2
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jhseong7/gimbap/util"
+)
+
+/*
+
+The app stops in stages, so a load balancer (e.g. a Kubernetes service) stops sending requests before the server stops:
+
+1. The readiness fails. (IsReady, ReadinessHandler)
+2. The app waits for the pre-stop delay, while the load balancers deregister the app.
+3. The server engine stops accepting connections and drains the in-flight requests. (up to the shutdown timeout of the engine)
+4. The lifecycle listeners and the microservices stop.
+
+*/
+
+type (
+	// Option of the staged shutdown of the app
+	ShutdownOption struct {
+		// Path of the readiness endpoint. (e.g. /readyz) Not served if empty.
+		// The endpoint responds 200 once the server listens, and 503 from the start of the shutdown.
+		ReadinessPath string
+
+		// Time to wait after failing the readiness before the server stops accepting connections. (no delay if 0)
+		// Set it a bit longer than the time the load balancers take to notice the readiness. (e.g. the period of the readiness probe)
+		PreStopDelay time.Duration
+
+		// Max time to stop the server and the app after the pre-stop delay. (AppMaxStopTime if 0)
+		Timeout time.Duration
+
+		// Called with the report of the requests once the server engine stops. (e.g. to export metrics)
+		OnDrained func(report ShutdownReport)
+	}
+
+	// Requests of the server engine at its stop
+	ShutdownReport struct {
+		// Requests in flight when the server stopped accepting connections
+		InFlight int64
+
+		// Requests completed while the server stopped
+		Drained int64
+
+		// Requests still in flight after the shutdown timeout of the engine. (their connections are closed)
+		CutOff int64
+
+		// Time taken by the server engine to stop
+		Duration time.Duration
+	}
+)
+
+// Get the max time to stop the server and the app after the pre-stop delay
+func (o ShutdownOption) getTimeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+
+	return AppMaxStopTime
+}
+
+// Stop the app in stages. (see the comment at the top of the file)
+func (app *GimbapApp) shutdown(option ShutdownOption) {
+	// Check the readiness before failing it, as there is no load balancer to wait for if the server never listened
+	wasReady := app.IsReady()
+	app.draining.Store(true)
+	app.logger.Log("Failing the readiness of the app")
+
+	if wasReady && option.PreStopDelay > 0 {
+		app.logger.Logf("Waiting %s before stopping the server", option.PreStopDelay)
+		time.Sleep(option.PreStopDelay)
+	}
+
+	// Set a timeout for the onStop and serverEngine.Stop
+	// If the onStop does not finish within the timeout, the app will forcefully stop.
+	timeout := option.getTimeout()
+	success := util.TimeoutJob(func() {
+		// Stop the main engine to break the loop
+		report := app.stopServerEngine()
+		if option.OnDrained != nil {
+			option.OnDrained(report)
+		}
+
+		// Call the onStop lifecycle
+		app.onStop()
+	}, timeout)
+
+	if !success {
+		app.logger.Warnf("Failed to stop the app gracefully within time %s. Forcing stop.", timeout.String())
+	}
+}
+
+// Stop the server engine and report the requests drained or cut off by the stop.
+func (app *GimbapApp) stopServerEngine() ShutdownReport {
+	before := app.engineRequests()
+	if before.InFlight > 0 {
+		app.logger.Logf("Stopping the server with %d requests in flight", before.InFlight)
+	}
+
+	begin := time.Now()
+	app.serverEngine.Stop()
+
+	after := app.engineRequests()
+	report := ShutdownReport{
+		InFlight: before.InFlight,
+		Drained:  after.Completed - before.Completed,
+		CutOff:   after.InFlight,
+		Duration: time.Since(begin),
+	}
+
+	if report.CutOff > 0 {
+		app.logger.Warnf("Server stopped in %s. %d requests drained, %d requests cut off", report.Duration, report.Drained, report.CutOff)
+	} else {
+		app.logger.Logf("Server stopped in %s. %d requests drained", report.Duration, report.Drained)
+	}
+
+	return report
+}
+
+// Check if the app is ready to serve the requests. (the server listens, and the app is not shutting down)
+func (app *GimbapApp) IsReady() bool {
+	if app.draining.Load() {
+		return false
+	}
+
+	select {
+	case <-app.readyFlag:
+		return true
+	default:
+		return false
+	}
+}
+
+// Get the handler of the readiness endpoint. Responds 200 if the app is ready, 503 otherwise.
+//
+// Served on ShutdownOption.ReadinessPath, or can be mounted by the user. (e.g. on a separate port of the probes)
+func (app *GimbapApp) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		if app.IsReady() {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("ready"))
+			return
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not ready"))
+	})
+}
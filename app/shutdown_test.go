@@ -0,0 +1,105 @@
+package app_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jhseong7/gimbap/app"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/module"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Controller with a request blocking until it is released
+type slowController struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c *slowController) GetRouteSpecs() []controller.RouteSpec {
+	return []controller.RouteSpec{
+		{Method: "GET", Path: "slow", Handler: controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) {
+			close(c.entered)
+			<-c.release
+			return "done", nil
+		})},
+	}
+}
+
+var _ = Describe("Shutdown", func() {
+	It("should fail the readiness, wait for the pre-stop delay, then drain the requests in flight", func() {
+		slow := &slowController{entered: make(chan struct{}), release: make(chan struct{})}
+		appModule := module.DefineModule(module.ModuleOption{
+			Name: "ShutdownModule",
+			Controllers: []*controller.Controller{controller.DefineController(controller.ControllerOption{
+				Name:         "SlowController",
+				Instantiator: func() *slowController { return slow },
+			})},
+		})
+
+		const preStopDelay = 500 * time.Millisecond
+		reports := make(chan app.ShutdownReport, 1)
+
+		a := app.CreateApp(app.AppOption{AppName: "Shutdown", AppModule: appModule})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			a.Run(app.RuntimeOptions{
+				Host: "127.0.0.1",
+				Shutdown: app.ShutdownOption{
+					ReadinessPath: "/readyz",
+					PreStopDelay:  preStopDelay,
+					OnDrained:     func(report app.ShutdownReport) { reports <- report },
+				},
+			})
+		}()
+		Eventually(a.Ready(), 10*time.Second).Should(BeClosed())
+
+		client := &http.Client{Timeout: 10 * time.Second}
+		url := func(path string) string { return fmt.Sprintf("http://%s%s", a.Addr(), path) }
+		get := func(path string) (int, string) {
+			res, err := client.Get(url(path))
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+			return res.StatusCode, string(body)
+		}
+
+		status, _ := get("/readyz")
+		Expect(status).To(Equal(http.StatusOK))
+
+		slowStatus := make(chan int, 1)
+		go func() {
+			defer GinkgoRecover()
+
+			status, _ := get("/slow")
+			slowStatus <- status
+		}()
+		Eventually(slow.entered, 10*time.Second).Should(BeClosed())
+
+		stopping := time.Now()
+		go a.Stop()
+
+		// The server still accepts the requests during the pre-stop delay, with the readiness failing
+		Eventually(func() int { status, _ := get("/readyz"); return status }).Should(Equal(http.StatusServiceUnavailable))
+		Expect(reports).NotTo(Receive())
+
+		// The slow request is released once the server stops, and is drained
+		time.Sleep(time.Until(stopping.Add(preStopDelay + 200*time.Millisecond)))
+		Expect(slowStatus).NotTo(Receive())
+		close(slow.release)
+		Eventually(slowStatus, 10*time.Second).Should(Receive(Equal(http.StatusOK)))
+
+		var report app.ShutdownReport
+		Eventually(reports, 10*time.Second).Should(Receive(&report))
+		Expect(report.InFlight).To(Equal(int64(1)))
+		Expect(report.Drained).To(Equal(int64(1)))
+		Expect(report.CutOff).To(BeZero())
+
+		Eventually(stopped, 10*time.Second).Should(BeClosed())
+	})
+})
@@ -0,0 +1,24 @@
+package app_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The test binary runs the helper app instead of the tests when it is started by a test (or by the helper app itself)
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) != "" {
+		runHelperApp()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestApp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "App Suite")
+}
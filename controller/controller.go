@@ -19,12 +19,53 @@ type (
 	Controller struct {
 		provider.Provider
 		RootPath string
+
+		// Interceptors applied to all routes of the controller.
+		Interceptors []interface{}
+
+		// Exception filters applied to all routes of the controller.
+		ExceptionFilters []interface{}
+
+		// Middlewares applied to all routes of the controller.
+		Middlewares []interface{}
+
+		// API versions of the controller.
+		Versions []string
+
+		// Metadata of all routes of the controller.
+		Metadata map[string]interface{}
 	}
 
 	RouteSpec struct {
 		Path    string // Route path to the handler. The full path will be RootPath + Path.
 		Method  string // HTTP method (GET, POST, PUT, DELETE, etc.)
 		Handler interface{}
+
+		// Documentation of the route. (used for the OpenAPI document)
+		Summary     string
+		Description string
+
+		// Interceptors applied only to this route. (runs after the global and controller interceptors)
+		// Either an interceptor instance or an interceptor provider can be given.
+		// The providers are instantiated once the route specs are read, with the dependencies provided by the module.
+		Interceptors []interface{}
+
+		// Exception filters applied only to this route. (runs before the controller and global filters)
+		// Either an exception filter instance or an exception filter provider can be given.
+		// The providers are instantiated once the route specs are read, with the dependencies provided by the module.
+		ExceptionFilters []interface{}
+
+		// Engine native middlewares applied only to this route. (runs after the global and controller middlewares)
+		// Either a native middleware or a middleware provider can be given.
+		// The providers are instantiated once the route specs are read, with the dependencies provided by the module.
+		Middlewares []interface{}
+
+		// API versions of the route. Overrides the versions of the controller.
+		Versions []string
+
+		// Metadata of the route (e.g. required roles, public flag). Merged over the metadata of the controller.
+		// Read at request time with the reflector.
+		Metadata map[string]interface{}
 	}
 
 	// Redefine ProviderOption as ControllerOption.
@@ -37,6 +78,25 @@ type (
 
 		// Root path of the controller. The full path of each route will be RootPath + Path.
 		RootPath string
+
+		// Interceptors applied to all routes of the controller.
+		// Either an interceptor instance or an interceptor provider can be given.
+		Interceptors []interface{}
+
+		// Exception filters applied to all routes of the controller.
+		// Either an exception filter instance or an exception filter provider can be given.
+		ExceptionFilters []interface{}
+
+		// Engine native middlewares applied to all routes of the controller. The controller is registered as a route group of the engine.
+		// Either a native middleware or a middleware provider can be given.
+		Middlewares []interface{}
+
+		// API versions served by the controller. (e.g. []string{"1", "2"}) Only used if the versioning of the app is enabled.
+		// The routes can override the versions with RouteSpec.Versions.
+		Versions []string
+
+		// Metadata applied to all routes of the controller. The routes can override the keys with RouteSpec.Metadata.
+		Metadata map[string]interface{}
 	}
 )
 
@@ -71,6 +131,11 @@ func DefineController(option ControllerOption) *Controller {
 			Instantiator: option.Instantiator,
 			Handler:      HandlerName,
 		},
-		RootPath: option.RootPath,
+		RootPath:         option.RootPath,
+		Interceptors:     option.Interceptors,
+		ExceptionFilters: option.ExceptionFilters,
+		Middlewares:      option.Middlewares,
+		Versions:         option.Versions,
+		Metadata:         option.Metadata,
 	}
 }
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"crypto/tls"
+	"io"
+)
+
+type (
+	// Engine neutral handler. Accepted by all engines in addition to the native handlers.
+	HandlerFunc func(ctx ExecutionContext) (interface{}, error)
+
+	// Engine neutral view of a request handled by a controller route.
+	//
+	// Each server engine implements this interface on top of its native context,
+	// so request-time components (e.g. interceptors) can work regardless of the engine.
+	ExecutionContext interface {
+		// The native context of the engine. (e.g. *gin.Context, echo.Context, *fiber.Ctx)
+		Native() interface{}
+
+		// Name of the controller that owns the route.
+		ControllerName() string
+
+		// The route spec of the matched route.
+		Route() RouteSpec
+
+		// The full path the route was registered with. (e.g. /api/users/{id})
+		RoutePath() string
+
+		// Get a path parameter of the request by the name in the route path.
+		Param(name string) string
+
+		// Metadata of the route. (controller metadata merged with the route metadata)
+		Metadata() map[string]interface{}
+
+		// HTTP method of the request.
+		Method() string
+
+		// The actual path of the request.
+		Path() string
+
+		// Get a request header value.
+		Header(key string) string
+
+		// TLS connection state of the request. nil if the request is not served with TLS.
+		// The client verified by mutual TLS is in the verified chains. (see engine.PeerIdentityOf)
+		TLS() *tls.ConnectionState
+
+		// Set a response header value.
+		SetHeader(key, value string)
+
+		// Bind the request (body, query, path parameters) to the given pointer with the native binding of the engine.
+		Bind(v interface{}) error
+
+		// Write the value as a JSON response with the given status.
+		JSON(status int, value interface{}) error
+
+		// Write the raw data as the response with the given status and content type.
+		Blob(status int, contentType string, data []byte) error
+
+		// Stream the response with the given status and content type. (e.g. server-sent events)
+		//
+		// The headers are sent before the stream function writes the body. Some engines (fiber) run the stream function
+		// after the handler returns, so the function must not use the execution context.
+		Stream(status int, contentType string, stream func(w StreamWriter)) error
+
+		// Check if the response has already been written.
+		Written() bool
+	}
+
+	// Writer of a streamed response
+	StreamWriter interface {
+		io.Writer
+
+		// Send the written data to the client. Fails once the client is disconnected.
+		Flush() error
+
+		// Closed once the client disconnects or the server shuts down.
+		// Some engines (fiber) detect the disconnection only when a flush fails.
+		Done() <-chan struct{}
+	}
+
+	// Result value that writes the response itself, instead of being written as JSON. (e.g. sse.Stream)
+	Responder interface {
+		Respond(ctx ExecutionContext) error
+	}
+)
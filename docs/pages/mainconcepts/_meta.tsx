@@ -6,4 +6,7 @@ export default {
   microservices: "Microservices",
   moduleprovider: "Modules and Providers",
   controller: "Controller",
+  middleware: "Middleware",
+  interceptor: "Interceptor",
+  exception: "Exception Filter",
 };
@@ -1,3 +1,8 @@
 export default {
   https: "HTTPS/TLS support",
+  listeners: "Listeners",
+  openapi: "OpenAPI Document",
+  versioning: "API Versioning",
+  views: "Views",
+  sse: "Server-Sent Events",
 };
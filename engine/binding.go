@@ -0,0 +1,162 @@
+// File: binding.go
+//
+// This file defines the engine neutral binding of the request parameters.
+// All engines bind the request structs of the typed handlers with the same tags, so the handlers work on every engine.
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jhseong7/gimbap/exception"
+)
+
+type (
+	// Values of the request to bind, read from the native request of the engine.
+	BindSource struct {
+		Param  func(name string) string   // Path parameter. (empty if not found)
+		Query  func(name string) []string // Values of the query parameter. (nil if not found)
+		Header func(name string) []string // Values of the request header. (nil if not found)
+	}
+)
+
+const (
+	PathTag   = "path"   // Tag of the fields bound from the path parameters
+	QueryTag  = "query"  // Tag of the fields bound from the query parameters
+	HeaderTag = "header" // Tag of the fields bound from the request headers
+)
+
+// Bind the path parameters, the query and the headers to the struct fields by their tags.
+//
+//   - "path": path parameters
+//   - "query": query parameters
+//   - "header": request headers
+//
+// The other fields are the body, bound by the engine before the parameters ("json" tag for JSON, "form" tag for forms),
+// so the body fields cannot overwrite the parameters. Values that are not a pointer to a struct are left as they are.
+func BindParameters(v interface{}, source BindSource) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("bind target must be a non-nil pointer: %T", v)
+	}
+
+	if rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	return BindFields(rv.Elem(), func(f reflect.StructField) ([]string, bool) {
+		if name := TagName(f, PathTag); name != "" {
+			value := source.Param(name)
+			return []string{value}, value != ""
+		}
+		if name := TagName(f, QueryTag); name != "" {
+			values := source.Query(name)
+			return values, values != nil
+		}
+		if name := TagName(f, HeaderTag); name != "" {
+			values := source.Header(name)
+			return values, values != nil
+		}
+
+		return nil, false
+	})
+}
+
+// Get the name of the field in the first tag found. Returns an empty string if the field has none of the tags.
+func TagName(f reflect.StructField, tags ...string) string {
+	for _, tag := range tags {
+		if name := strings.Split(f.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// Set the fields with the values given by the lookup. Embedded structs are bound as a part of the struct.
+func BindFields(v reflect.Value, lookup func(f reflect.StructField) ([]string, bool)) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := BindFields(v.Field(i), lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		values, ok := lookup(f)
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		if err := setValue(v.Field(i), values); err != nil {
+			return exception.BadRequest(fmt.Sprintf("Invalid value of %s", f.Name)).WithCause(err)
+		}
+	}
+
+	return nil
+}
+
+// Set the string values to the field by its kind
+func setValue(field reflect.Value, values []string) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setValue(field.Elem(), values)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	value := values[0]
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return errors.New("unsupported field type " + field.Type().String())
+	}
+
+	return nil
+}
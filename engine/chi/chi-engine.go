@@ -0,0 +1,188 @@
+package chi_engine
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/internal/nethttp"
+	"github.com/jhseong7/gimbap/exception"
+)
+
+type (
+	// Server engine built on the chi router.
+	//
+	// The server, the global middlewares and the static files are handled by nethttp.Engine.
+	ChiHttpEngine struct {
+		*nethttp.Engine
+
+		// The underlying router
+		engine *chi.Mux
+
+		// Routing of the router after its middlewares, and the global middlewares chained around it (see useMiddlewares)
+		routed    http.Handler
+		routing   http.Handler
+		chainOnce sync.Once
+	}
+
+	ChiHttpEngineOption struct {
+		engine.ServerEngineOption
+
+		// Validator of the requests bound with ExecutionContext.Bind (optional)
+		Validator Validator
+	}
+
+	// Validator of the requests bound with ExecutionContext.Bind (same as the echo.Validator interface)
+	Validator = nethttp.Validator
+)
+
+// Path syntax of chi. Chi does not support optional parameters and parameters mixed with static text in this syntax.
+var pathSyntax = engine.PathSyntax{
+	Engine:   "chi",
+	Param:    func(name string) string { return "{" + name + "}" },
+	CatchAll: func(name string) string { return "*" },
+}
+
+// Register the routes of the controller. (see engine.NewControllerSpec)
+//
+// Deprecated: the app registers the controllers with RegisterControllerSpec.
+func (e *ChiHttpEngine) RegisterController(rootPath string, c controller.IController) {
+	e.RegisterControllerSpec(engine.NewControllerSpec(rootPath, c))
+}
+
+func (e *ChiHttpEngine) RegisterControllerSpec(spec engine.ControllerSpec) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.Logger.Panicf("Failed to register controller to path: %s. %v", spec.RootPath, r)
+		}
+	}()
+
+	// Register the controller as an inline group with the controller middlewares.
+	// NOTE: chi.Route is not used, as it mounts a sub router that cannot be shared by the controllers with the same root path.
+	if _, err := engine.TranslateGroupPath(pathSyntax, e.GetGlobalApiPrefix(), spec.RootPath); err != nil {
+		e.Logger.Panic(err.Error())
+	}
+
+	e.engine.Group(func(group chi.Router) {
+		for _, m := range e.CastMiddlewares(spec.Middlewares) {
+			group.Use(m)
+		}
+
+		for _, routeSpec := range spec.Routes {
+			engine.CheckMethodValidity(routeSpec.Method)
+			fullPath := engine.MergeRestPath(e.GetGlobalApiPrefix(), spec.RootPath, routeSpec.Path)
+
+			// Translate the path to the chi syntax. (optional parameters are registered as 2 routes)
+			paths, err := engine.TranslateRoutePath(pathSyntax, "", fullPath)
+			if err != nil {
+				e.Logger.Panic(err.Error())
+			}
+
+			// Register the route with the route middlewares
+			route := group.With(e.CastMiddlewares(routeSpec.Middlewares)...)
+			handler := e.RouteHandler(spec, routeSpec, fullPath)
+			for _, path := range paths {
+				route.Method(routeSpec.Method, path, handler)
+				e.Metadata.Set(routeSpec.Method, path, spec.RouteMetadata(routeSpec))
+			}
+
+			// Get the name of the Handler function
+			handlerName := engine.RuntimeFuncName(routeSpec.Handler)
+
+			e.Logger.Logf("Registered route: %-8s %-20s --> %s", routeSpec.Method, fullPath, handlerName)
+		}
+	})
+}
+
+// The StaticOption is served when no route matches. (see nethttp.Engine.AddStaticFiles)
+func (e *ChiHttpEngine) AddStatic(prefix, root string, config ...interface{}) {
+	if e.AddStaticFiles(prefix, root, config) {
+		return
+	}
+
+	// NOTE: chi does not support other configs for static file serving
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	e.engine.Handle(prefix+"/*", http.StripPrefix(prefix, http.FileServer(http.Dir(root))))
+}
+
+// Mount the handler on the prefix for all methods with chi.Mux.Mount.
+func (e *ChiHttpEngine) Mount(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	e.engine.Mount(engine.MergeRestPath(prefix), engine.MountHandler(prefix, handler))
+}
+
+// Get the metadata of the route matching the request
+func (e *ChiHttpEngine) metadataOf(r *http.Request) map[string]interface{} {
+	pattern := e.engine.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	return e.Metadata.Get(r.Method, pattern)
+}
+
+// Route the requests with the router. The global middlewares are chained inside the router once the handler is built.
+func (e *ChiHttpEngine) router() (http.Handler, bool) {
+	// chi does not run its middlewares until a route is registered, so the global middlewares are chained outside
+	if e.routed == nil {
+		return e.engine, false
+	}
+
+	e.chainOnce.Do(func() { e.routing = nethttp.Chain(e.routed, e.Middlewares) })
+	return e.engine, true
+}
+
+// Middleware of the router running the global middlewares.
+//
+// NOTE: chi does not accept middlewares after the routes, but the static paths and the mounts may be added before the middlewares.
+// So the middleware is registered once when the engine is created, and runs the global middlewares chained when the handler is built. (see router)
+func (e *ChiHttpEngine) useMiddlewares(next http.Handler) http.Handler {
+	e.routed = next
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e.routing == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		e.routing.ServeHTTP(w, r)
+	})
+}
+
+// Internal function to create a new chi router
+func createChiHttpEngine() (e *chi.Mux) {
+	e = chi.NewRouter()
+
+	// Unmatched methods are written in the same format as the exception filters
+	e.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		nethttp.HandleError(w, r, exception.MethodNotAllowed())
+	})
+
+	return
+}
+
+// Create a new chi engine
+func NewChiHttpEngine(options ...ChiHttpEngineOption) *ChiHttpEngine {
+	// Get the options
+	var option ChiHttpEngineOption
+	if len(options) > 0 {
+		option = options[0]
+	}
+
+	e := &ChiHttpEngine{
+		Engine: nethttp.NewEngine("ChiHttpEngine", option.ServerEngineOption, option.Validator),
+		engine: createChiHttpEngine(),
+	}
+	e.CatchAllKey = "*"
+	e.Router = e.router
+	e.MetadataOf = e.metadataOf
+	e.engine.Use(e.useMiddlewares)
+
+	// Unmatched routes are served by the static files of the StaticOption, or written in the same format as the exception filters
+	e.engine.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		if !e.Statics.Serve(w, r) {
+			nethttp.HandleError(w, r, exception.NotFound())
+		}
+	})
+
+	return e
+}
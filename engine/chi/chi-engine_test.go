@@ -0,0 +1,101 @@
+package chi_engine_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	chi_engine "github.com/jhseong7/gimbap/engine/chi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The routes, the bindings, the middleware order and the server are covered by the conformance suite. (see conformance_test.go)
+
+// Native middleware adding the name to the X-Middleware header
+func named(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Response writer adding the route pattern of chi to the headers
+type patternWriter struct {
+	http.ResponseWriter
+	request *http.Request
+}
+
+func (w *patternWriter) WriteHeader(status int) {
+	if rctx := chi.RouteContext(w.request.Context()); rctx != nil {
+		w.Header().Set("X-Route-Pattern", rctx.RoutePattern())
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware writing the route pattern of chi. (the pattern is set once the request is routed)
+func pattern(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&patternWriter{ResponseWriter: w, request: r}, r)
+	})
+}
+
+func serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+
+	return w
+}
+
+var _ = Describe("ChiHttpEngine", func() {
+	var e *chi_engine.ChiHttpEngine
+
+	BeforeEach(func() {
+		e = chi_engine.NewChiHttpEngine()
+		e.RegisterControllerSpec(engine.ControllerSpec{
+			Name:     "ItemController",
+			RootPath: "items",
+			Routes: []controller.RouteSpec{
+				{
+					Method: "GET",
+					Path:   "{id}",
+					Handler: controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) {
+						return map[string]string{"id": ctx.Param("id")}, nil
+					}),
+				},
+			},
+		})
+	})
+
+	It("Runs the global middlewares inside the router, with the route context of chi", func() {
+		// The global middlewares may be added after the routes, unlike chi.Mux.Use
+		e.AddMiddleware(named("global"), pattern)
+
+		res := serve(e.Handler(), "GET", "/items/1")
+
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Header().Values("X-Middleware")).To(Equal([]string{"global"}))
+		Expect(res.Header().Get("X-Route-Pattern")).To(Equal("/items/{id}"))
+	})
+
+	It("Runs the global middlewares of an engine without routes", func() {
+		e = chi_engine.NewChiHttpEngine()
+		e.AddMiddleware(named("global"))
+
+		res := serve(e.Handler(), "GET", "/unknown")
+
+		Expect(res.Code).To(Equal(http.StatusNotFound))
+		Expect(res.Header().Values("X-Middleware")).To(Equal([]string{"global"}))
+	})
+
+	It("Panics if a middleware is added after the handler is built", func() {
+		h := e.Handler()
+		serve(h, "GET", "/items/1")
+
+		Expect(func() { e.AddMiddleware(named("late")) }).To(Panic())
+	})
+})
@@ -0,0 +1,27 @@
+package chi_engine_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhseong7/gimbap/engine"
+	chi_engine "github.com/jhseong7/gimbap/engine/chi"
+	"github.com/jhseong7/gimbap/engine/enginetest"
+)
+
+func TestConformance(t *testing.T) {
+	enginetest.Run(t, enginetest.Option{
+		New: func() engine.IServerEngine { return chi_engine.NewChiHttpEngine() },
+		NewWithOption: func(option engine.ServerEngineOption) engine.IServerEngine {
+			return chi_engine.NewChiHttpEngine(chi_engine.ChiHttpEngineOption{ServerEngineOption: option})
+		},
+		Middleware: func(name string) interface{} {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Add(enginetest.MiddlewareHeader, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		},
+	})
+}
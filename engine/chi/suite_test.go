@@ -0,0 +1,13 @@
+package chi_engine_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestChiHttpEngine(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ChiHttpEngine Suite")
+}
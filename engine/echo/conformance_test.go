@@ -0,0 +1,27 @@
+package echo_engine_test
+
+import (
+	"testing"
+
+	"github.com/jhseong7/gimbap/engine"
+	echo_engine "github.com/jhseong7/gimbap/engine/echo"
+	"github.com/jhseong7/gimbap/engine/enginetest"
+	"github.com/labstack/echo/v4"
+)
+
+func TestConformance(t *testing.T) {
+	enginetest.Run(t, enginetest.Option{
+		New: func() engine.IServerEngine { return echo_engine.NewEchoHttpEngine() },
+		NewWithOption: func(option engine.ServerEngineOption) engine.IServerEngine {
+			return echo_engine.NewEchoHttpEngine(option)
+		},
+		Middleware: func(name string) interface{} {
+			return echo.MiddlewareFunc(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Response().Header().Add(enginetest.MiddlewareHeader, name)
+					return next(c)
+				}
+			})
+		},
+	})
+}
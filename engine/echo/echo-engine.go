@@ -1,16 +1,19 @@
 package echo_engine
 
 import (
-	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"reflect"
-	"time"
+	"strings"
 
 	"github.com/jhseong7/ecl"
 	"github.com/jhseong7/gimbap/controller"
 	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/internal/nethttp"
+	"github.com/jhseong7/gimbap/exception"
+	gimbap_middleware "github.com/jhseong7/gimbap/middleware"
 
 	echo "github.com/labstack/echo/v4"
 
@@ -24,9 +27,21 @@ type (
 		// The underlying http engine
 		engine          *echo.Echo
 		globalApiPrefix string
+		metadata        engine.RouteMetadataTable
 
+		// Server of Run, with the limits and the timeouts of the option
+		option engine.ServerEngineOption
 		server *http.Server
 
+		// Address of the listener (set once the server listens)
+		address engine.ServerAddress
+
+		// Requests served by the server of Run
+		requests engine.RequestCounter
+
+		// Static files of the StaticOption, served when no route matches
+		statics engine.Statics
+
 		logger ecl.Logger
 
 		// server stop flag
@@ -34,52 +49,127 @@ type (
 	}
 )
 
-// Check if the handler is valid and cast it to gin.HandlerFunc.
+func (e *EchoHttpEngine) checkAndCastToEchoMiddlewareHandler(handler interface{}) echo.MiddlewareFunc {
+	// Get the native middleware from the middleware provider instances
+	handler = gimbap_middleware.ToNative(handler)
+
+	// Both the named type and the plain function type are accepted
+	switch h := handler.(type) {
+	case echo.MiddlewareFunc:
+		return h
+	case func(echo.HandlerFunc) echo.HandlerFunc:
+		return h
+	}
+
+	e.logger.Panicf("Middleware Handler must be func(echo.HandlerFunc) echo.HandlerFunc: got %s", reflect.TypeOf(handler).String())
+	return nil
+}
+
+// Cast the middlewares to echo.MiddlewareFunc
+func (e *EchoHttpEngine) castMiddlewares(middlewares []interface{}) []echo.MiddlewareFunc {
+	casted := make([]echo.MiddlewareFunc, 0, len(middlewares))
+	for _, m := range middlewares {
+		casted = append(casted, e.checkAndCastToEchoMiddlewareHandler(m))
+	}
+
+	return casted
+}
+
+// Convert the echo native errors to http exceptions, so they are handled the same way as other engines.
 //
-// This is a helper function to check if the handler is valid and cast it to gin.HandlerFunc before registering it to the engine.
-func (e *EchoHttpEngine) checkAndCastToEchoHandler(handler interface{}) echo.HandlerFunc {
-	// Check if the input 0 is echo.Context
-	handlerType := reflect.TypeOf(handler)
+// The http exceptions are kept as they are, even if their cause is an echo error.
+func convertEchoError(err error) error {
+	var httpException *exception.HttpException
+	if errors.As(err, &httpException) {
+		return err
+	}
 
-	if handlerType.NumIn() == 0 || handlerType.In(0) != reflect.TypeOf((*echo.Context)(nil)).Elem() {
-		e.logger.Panicf("Handler's first parameter must be echo.Context: got %s %s", handlerType.String())
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return exception.New(he.Code, fmt.Sprint(he.Message)).WithCause(he.Internal)
 	}
 
-	// Cast the handler value's interface to func(echo.Context) error
-	return handler.(func(echo.Context) error)
+	return err
 }
 
-func (e *EchoHttpEngine) checkAndCastToEchoMiddlewareHandler(handler interface{}) echo.MiddlewareFunc {
-	// Check if the input 0 is echo.Context
-	handlerType := reflect.TypeOf(handler)
+// Path syntax of echo. Echo does not support optional parameters and parameters mixed with static text.
+var pathSyntax = engine.PathSyntax{
+	Engine:   "echo",
+	Param:    func(name string) string { return ":" + name },
+	CatchAll: func(name string) string { return "*" },
+}
 
-	if handlerType.NumIn() == 0 || handlerType.In(0) != reflect.TypeOf((echo.HandlerFunc)(nil)) {
-		e.logger.Panicf("Middleware Handler's first parameter must be echo.HandlerFunc:  got %s", handlerType.String())
+// Create the echo handler of the route.
+//
+// The handler is wrapped to run through the interceptors, and the result value is written as the response.
+// Errors and panics are handled by the exception filters.
+func (e *EchoHttpEngine) createRouteHandler(spec engine.ControllerSpec, route controller.RouteSpec, fullPath string) echo.HandlerFunc {
+	interceptors := spec.RouteInterceptors(route)
+	filters := spec.RouteExceptionFilters(route)
+	path, _ := engine.ParsePath(fullPath) // Validated by the translation
+	metadata := spec.RouteMetadata(route)
+
+	nativeHandler, isNative := route.Handler.(func(echo.Context) error)
+	valueHandler, isValue := engine.CastToValueHandler(route.Handler, reflect.TypeOf((*echo.Context)(nil)).Elem())
+
+	if !isNative && !isValue {
+		e.logger.Panicf("Handler must be func(echo.Context) error, func(echo.Context[, Req]) (T, error) or controller.HandlerFunc: %s", reflect.TypeOf(route.Handler).String())
 	}
 
-	// Also check if the output is echo.HandlerFunc which is a type func(echo.Context) error
-	if handlerType.NumOut() == 0 || handlerType.Out(0) != reflect.TypeOf((echo.HandlerFunc)(nil)) {
-		e.logger.Panicf("Middleware Handler's return type must be echo.HandlerFunc:  got %s", handlerType.String())
+	return func(c echo.Context) error {
+		ctx := &echoExecutionContext{ctx: c, controllerName: spec.Name, route: route, routePath: fullPath, path: path, metadata: metadata}
+
+		engine.ExecuteHandler(ctx, path, interceptors, filters, func() (interface{}, error) {
+			if isNative {
+				return nil, convertEchoError(nativeHandler(c))
+			}
+
+			v, err := valueHandler(ctx)
+			return v, convertEchoError(err)
+		})
+
+		return nil
 	}
+}
 
-	// Cast the handler value's interface to gin.HandlerFunc
-	return handler.(func(echo.HandlerFunc) echo.HandlerFunc)
+// Register the routes of the controller. (see engine.NewControllerSpec)
+//
+// Deprecated: the app registers the controllers with RegisterControllerSpec.
+func (e *EchoHttpEngine) RegisterController(rootPath string, c controller.IController) {
+	e.RegisterControllerSpec(engine.NewControllerSpec(rootPath, c))
 }
 
-func (e *EchoHttpEngine) RegisterController(rootPath string, instance controller.IController) {
+func (e *EchoHttpEngine) RegisterControllerSpec(spec engine.ControllerSpec) {
 	defer func() {
 		if r := recover(); r != nil {
-			e.logger.Panicf("Failed to register controller to path: %s", rootPath)
+			e.logger.Panicf("Failed to register controller to path: %s. %v", spec.RootPath, r)
 		}
 	}()
 
-	routeSpecs := instance.GetRouteSpecs()
+	// Register the controller as a route group with the controller middlewares
+	groupPath, err := engine.TranslateGroupPath(pathSyntax, e.globalApiPrefix, spec.RootPath)
+	if err != nil {
+		e.logger.Panic(err.Error())
+	}
+	group := e.engine.Group(groupPath, e.castMiddlewares(spec.Middlewares)...)
 
-	for _, routeSpec := range routeSpecs {
+	for _, routeSpec := range spec.Routes {
 		engine.CheckMethodValidity(routeSpec.Method)
-		fullPath := engine.MergeRestPath(e.globalApiPrefix, rootPath, routeSpec.Path)
+		fullPath := engine.MergeRestPath(e.globalApiPrefix, spec.RootPath, routeSpec.Path)
 
-		e.engine.Add(routeSpec.Method, fullPath, e.checkAndCastToEchoHandler(routeSpec.Handler))
+		// Translate the path to the echo syntax. (optional parameters are registered as 2 routes)
+		relPaths, err := engine.TranslateRoutePath(pathSyntax, groupPath, fullPath)
+		if err != nil {
+			e.logger.Panic(err.Error())
+		}
+
+		// Register the route with the route middlewares
+		handler := e.createRouteHandler(spec, routeSpec, fullPath)
+		routeMiddlewares := e.castMiddlewares(routeSpec.Middlewares)
+		for _, relPath := range relPaths {
+			group.Add(routeSpec.Method, relPath, handler, routeMiddlewares...)
+			e.metadata.Set(routeSpec.Method, groupPath+relPath, spec.RouteMetadata(routeSpec))
+		}
 
 		// Get the name of the Handler function
 		handlerName := engine.RuntimeFuncName(routeSpec.Handler)
@@ -89,8 +179,8 @@ func (e *EchoHttpEngine) RegisterController(rootPath string, instance controller
 }
 
 // Add middleware to the engine
-func (e *EchoHttpEngine) AddMiddleware(middleware ...interface{}) {
-	for _, m := range middleware {
+func (e *EchoHttpEngine) AddMiddleware(middlewares ...interface{}) {
+	for _, m := range middlewares {
 		casted := e.checkAndCastToEchoMiddlewareHandler(m)
 		e.engine.Use(casted)
 	}
@@ -100,100 +190,119 @@ func (e *EchoHttpEngine) Run(option engine.ServerRuntimeOption) {
 	// Send the stop flag (if the server stops)
 	defer func() { e.stopFlag <- "stopped" }()
 
-	port := option.Port
+	// Create an http server with the limits of the engine option
+	e.server = nethttp.NewServer(e.option, e.requests.Handler(e.engine))
 
-	if port == 0 {
-		e.logger.Warn("Port is not set. Defaulting to 8080")
-		port = 8080
+	// Create the listeners (host and port, unix socket, given listener or systemd socket, with TLS and the redirects to https)
+	listeners, err := engine.OpenListeners(option)
+	if err != nil {
+		e.logger.Fatalf("Failed to create the listeners: %s", err)
 	}
+	listeners.Log(e.logger.Logf)
 
-	// Create an http server
-	e.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: e.engine,
+	// The connections are accepted from here (queued until the server starts)
+	e.address.Ready(option, listeners.Addr())
+	defer e.address.Set(nil)
+
+	// Start the server (blocking)
+	if err := listeners.ServeHTTP(e.server, e.option.GetShutdownTimeout()); err != nil && err != http.ErrServerClosed {
+		e.logger.Fatalf("Failed to start the http engine: %s", err)
 	}
 
-	// Split the case for TLS and non-TLS
-	if option.TLSOption != nil {
-		e.logger.Logf("Starting the http engine with TLS on port %d", port)
+}
 
-		// If the config is given directly, use it, else load the cert/key files
-		var config *tls.Config
-		if option.TLSOption.Config != nil {
-			// Use the given tls config directly
-			config = option.TLSOption.Config
-		} else {
-			config = &tls.Config{
-				MinVersion: tls.VersionTLS12,
-			}
-		}
+func (e *EchoHttpEngine) Stop() {
+	timeout := e.option.GetShutdownTimeout()
+	e.logger.Logf("Stopping the http engine (Max %s)", timeout)
 
-		// Only load the cert/key files if the config does not have a certificate
-		if option.TLSOption.CertFile != "" && option.TLSOption.KeyFile != "" && config.Certificates == nil {
-			var err error
-			cert, err := tls.LoadX509KeyPair(option.TLSOption.CertFile, option.TLSOption.KeyFile)
-			if err != nil {
-				e.logger.Fatalf("Failed to load TLS config: %s", err)
-			}
+	// NOTE: shutdown through the server, not the engine as it was started with the server
+	if err := nethttp.Shutdown(e.server, timeout, e.stopFlag); err != nil {
+		e.logger.Warnf("Server failed to shutdown gracefully within %s, the remaining connections are closed: %s", timeout, err)
+		return
+	}
 
-			config = &tls.Config{
-				MinVersion:   tls.VersionTLS12,
-				Certificates: []tls.Certificate{cert},
-			}
-		}
+	e.logger.Log("Server stopped gracefully")
+}
 
-		// If the certificates are not loaded, panic
-		if config.Certificates == nil {
-			e.logger.Fatalf(
-				"Failed to load TLS config: At least one of tls.Config.Certificates or 'CertFile and KeyFile' are required",
-			)
-		}
+// Get the address the server listens on. (nil if not running)
+func (e *EchoHttpEngine) Addr() net.Addr {
+	return e.address.Get()
+}
 
-		// Create a listener with the tls config
-		tlsListener, err := tls.Listen("tcp", e.server.Addr, config)
-		if err != nil {
-			e.logger.Fatalf("Failed to create a tls listener: %s", err)
+// Get the counts of the requests served by Run
+func (e *EchoHttpEngine) Requests() engine.RequestStats {
+	return e.requests.Stats()
+}
+
+func (e *EchoHttpEngine) GetGlobalApiPrefix() string {
+	return e.globalApiPrefix
+}
+
+// Attach the metadata of the matched route to the context, so the middlewares can read it with the reflector.
+func (e *EchoHttpEngine) attachMetadata(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if metadata := e.metadata.Get(c.Request().Method, c.Path()); metadata != nil {
+			c.Set(engine.MetadataContextKey, metadata)
 		}
 
-		// Run the server with the tls listener
-		if err := e.server.Serve(tlsListener); err != nil && err != http.ErrServerClosed {
-			e.logger.Fatalf("Failed to start the http engine: %s", err)
+		return next(c)
+	}
+}
+
+// Set the path rewriter as a pre middleware of echo. (runs before the routing)
+func (e *EchoHttpEngine) SetPathRewriter(rewriter engine.PathRewriter) {
+	e.engine.Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			if path := rewriter(r.Method, r.URL.Path, r.Header.Get); path != r.URL.Path {
+				r.URL.Path = path
+				r.URL.RawPath = ""
+			}
+
+			return next(c)
 		}
+	})
+}
 
+// The StaticOption is served when no route matches the paths of the prefix.
+func (e *EchoHttpEngine) AddStatic(prefix, root string, config ...interface{}) {
+	option, ok := engine.StaticOptionOf(config)
+	if !ok {
+		// NOTE: Echo does not support other configs for static file serving
+		e.engine.Static(prefix, root)
 		return
 	}
 
-	e.logger.Logf("Starting the http engine on port %d", port)
-
-	// Start the server. Http mode with no TLS
-	if err := e.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		e.logger.Fatalf("Failed to start the http engine: %s", err)
+	files, err := engine.NewStaticFiles(prefix, root, option)
+	if err != nil {
+		e.logger.Panicf("Failed to add static files to the engine: %s", err)
 	}
+	e.statics = e.statics.Add(files)
 
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	e.engine.RouteNotFound(prefix+"/*", func(c echo.Context) error {
+		if e.statics.Serve(c.Response(), c.Request()) {
+			return nil
+		}
+
+		return echo.ErrNotFound
+	})
 }
 
-func (e *EchoHttpEngine) Stop() {
-	e.logger.Log("Stopping the http engine (Max 5 seconds)")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// Mount the handler on the prefix for all methods. The prefix and the sub paths are registered as 2 routes.
+func (e *EchoHttpEngine) Mount(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	h := echo.WrapHandler(engine.MountHandler(prefix, handler))
 
-	// NOTE: shutdown through the server, not the engine as it was started with the server
-	if err := e.server.Shutdown(ctx); err != nil {
-		e.logger.Fatalf("Failed to shutdown the http engine: %v", err)
+	if prefix != "" {
+		e.engine.Any(prefix, h)
 	}
-
-	select {
-	case <-ctx.Done():
-		e.logger.Warn("Server failed to shutdown gracefully with in 5 seconds")
-	case <-e.stopFlag:
-		e.logger.Log("Server stopped gracefully")
-	}
-
+	e.engine.Any(prefix+"/*", h)
 }
 
-func (e *EchoHttpEngine) AddStatic(prefix, root string, config ...interface{}) {
-	// NOTE: Echo does not support config for static file serving
-	e.engine.Static(prefix, root)
+// Get the engine as an http.Handler. (same as the handler of the server started by Run)
+func (e *EchoHttpEngine) Handler() http.Handler {
+	return e.engine
 }
 
 // Internal function to create a new echo engine with the logger middleware
@@ -204,6 +313,11 @@ func createEchoHttpEngine(logger ecl.Logger) (e *echo.Echo) {
 	// Add the recover  middleware to the engine
 	e.Use(middleware.Recover())
 
+	// Errors outside the routes (e.g. middlewares, unmatched routes) are written in the same format as the exception filters
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		exception.Handle(&echoExecutionContext{ctx: c}, nil, convertEchoError(err))
+	}
+
 	// Don't show the banner of echo and the port number info (it's redundant)
 	e.HideBanner = true
 	e.HidePort = true
@@ -241,10 +355,17 @@ func NewEchoHttpEngine(options ...engine.ServerEngineOption) *EchoHttpEngine {
 	// Create gin engine with the logger
 	e := createEchoHttpEngine(l)
 
-	return &EchoHttpEngine{
+	ee := &EchoHttpEngine{
 		engine:          e,
 		logger:          l,
 		globalApiPrefix: option.GlobalApiPrefix,
+		option:          option,
+		metadata:        engine.RouteMetadataTable{},
 		stopFlag:        make(chan string),
 	}
+
+	// Runs before the middlewares added by the user. (echo finds the route before running the middlewares)
+	e.Use(ee.attachMetadata)
+
+	return ee
 }
@@ -0,0 +1,71 @@
+package echo_engine
+
+import (
+	"crypto/tls"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/internal/nethttp"
+	echo "github.com/labstack/echo/v4"
+)
+
+type (
+	// Echo implementation of the controller.ExecutionContext
+	echoExecutionContext struct {
+		controller.ExecutionContext
+
+		ctx            echo.Context
+		controllerName string
+		route          controller.RouteSpec
+		routePath      string
+		path           *engine.RoutePath // Parsed route path (nil outside of the routes)
+		metadata       map[string]interface{}
+	}
+)
+
+func (c *echoExecutionContext) Native() interface{}              { return c.ctx }
+func (c *echoExecutionContext) ControllerName() string           { return c.controllerName }
+func (c *echoExecutionContext) Route() controller.RouteSpec      { return c.route }
+func (c *echoExecutionContext) Metadata() map[string]interface{} { return c.metadata }
+func (c *echoExecutionContext) RoutePath() string                { return c.routePath }
+func (c *echoExecutionContext) Method() string                   { return c.ctx.Request().Method }
+func (c *echoExecutionContext) Path() string                     { return c.ctx.Request().URL.Path }
+func (c *echoExecutionContext) Header(key string) string         { return c.ctx.Request().Header.Get(key) }
+func (c *echoExecutionContext) TLS() *tls.ConnectionState        { return c.ctx.Request().TLS }
+func (c *echoExecutionContext) SetHeader(key, value string) {
+	c.ctx.Response().Header().Set(key, value)
+}
+func (c *echoExecutionContext) Written() bool { return c.ctx.Response().Committed }
+func (c *echoExecutionContext) JSON(status int, value interface{}) error {
+	return c.ctx.JSON(status, value)
+}
+
+func (c *echoExecutionContext) Blob(status int, contentType string, data []byte) error {
+	return c.ctx.Blob(status, contentType, data)
+}
+
+func (c *echoExecutionContext) Stream(status int, contentType string, stream func(w controller.StreamWriter)) error {
+	return nethttp.Stream(c.ctx.Response(), c.ctx.Request(), status, contentType, stream)
+}
+
+// Get the path parameter. The catch-all parameter is registered as "*" on echo.
+func (c *echoExecutionContext) Param(name string) string {
+	if name != "" && name == c.path.CatchAll() {
+		name = "*"
+	}
+
+	return c.ctx.Param(name)
+}
+
+// Bind the request with the engine neutral tags (see nethttp.Bind). Validates the value if a validator is registered to echo.
+func (c *echoExecutionContext) Bind(v interface{}) error {
+	if err := nethttp.Bind(c.ctx.Request(), v, c.Param); err != nil {
+		return err
+	}
+
+	if c.ctx.Echo().Validator != nil {
+		return c.ctx.Validate(v)
+	}
+
+	return nil
+}
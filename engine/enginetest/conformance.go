@@ -0,0 +1,1667 @@
+// File: conformance.go
+//
+// Conformance suite of the server engines. Any IServerEngine (including third party engines) can run the suite
+// to check that it behaves the same as the engines of gimbap.
+//
+//	func TestConformance(t *testing.T) {
+//		enginetest.Run(t, enginetest.Option{
+//			New:        func() engine.IServerEngine { return my_engine.New() },
+//			Middleware: func(name string) interface{} { ... },
+//		})
+//	}
+package enginetest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/interceptor"
+	"github.com/jhseong7/gimbap/sse"
+	"github.com/jhseong7/gimbap/view"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+)
+
+type (
+	Option struct {
+		// Create a new engine. Each test runs on a new engine.
+		New func() engine.IServerEngine
+
+		// Create a new engine with the limits and the timeouts of the option. The tests of the limits are skipped if not given.
+		NewWithOption func(option engine.ServerEngineOption) engine.IServerEngine
+
+		// Create a native middleware of the engine that adds the name to the MiddlewareHeader response header, then calls the next handler.
+		Middleware func(name string) interface{}
+
+		// Tests to skip by the name. (e.g. "static" for engines without static file serving, "h2c" and "http3" for engines not based on net/http)
+		Skip []string
+	}
+
+	// Engine with the optional capabilities checked by the suite. (the engines of gimbap implement all of them)
+	Engine interface {
+		engine.IServerEngine
+		engine.IControllerSpecRegistrar
+		engine.IMountable
+		engine.IAddressReporter
+		engine.IHandlerProvider
+		engine.IRequestReporter
+	}
+
+	// Engine running on a port for a test
+	server struct {
+		t      *testing.T
+		engine engine.IServerEngine
+		port   int
+		client *http.Client
+		scheme string
+		host   string
+		done   chan struct{}
+
+		stopOnce sync.Once
+	}
+)
+
+const (
+	// Response header of the middlewares created by Option.Middleware
+	MiddlewareHeader = "X-Middleware"
+
+	// Max time to wait for the server to start and stop
+	waitTimeout = 10 * time.Second
+)
+
+// Run the conformance suite on the engine.
+func Run(t *testing.T, option Option) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, option Option)
+	}{
+		{"routes", testRoutes},
+		{"binding", testBinding},
+		{"middleware-order", testMiddlewareOrder},
+		{"errors", testErrors},
+		{"views", testViews},
+		{"sse", testSSE},
+		{"static", testStatic},
+		{"static-option", testStaticOption},
+		{"mount", testMount},
+		{"mount-root", testMountRoot},
+		{"handler", testHandler},
+		{"tls-config", testTLSConfig},
+		{"tls-files", testTLSFiles},
+		{"tls-reload", testTLSReload},
+		{"mtls", testMutualTLS},
+		{"graceful-stop", testGracefulStop},
+		{"port", testPort},
+		{"listener", testListener},
+		{"ephemeral-port", testEphemeralPort},
+		{"multiple-listeners", testMultipleListeners},
+		{"unix-socket", testUnixSocket},
+		{"h2c", testH2C},
+		{"http3", testHTTP3},
+		{"body-limit", testBodyLimit},
+		{"header-timeout", testHeaderTimeout},
+		{"shutdown-timeout", testShutdownTimeout},
+		{"requests", testRequests},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, skip := range option.Skip {
+				if skip == test.name {
+					t.Skip("skipped by the option")
+				}
+			}
+
+			test.run(t, option)
+		})
+	}
+}
+
+// Get a free TCP port of the local host.
+func FreePort(t *testing.T) int {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to get a free port: %s", err)
+	}
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// Wait until the address accepts connections. Returns false on timeout.
+func waitForAddress(network, address string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if conn, err := net.DialTimeout(network, address, 100*time.Millisecond); err == nil {
+			conn.Close()
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return false
+}
+
+// Run the engine on a free port and wait until it accepts connections. The engine is stopped when the test ends.
+func start(t *testing.T, e engine.IServerEngine, tlsOption *engine.TLSOption, client *http.Client) *server {
+	t.Helper()
+
+	port := FreePort(t)
+	s := startWith(t, e, engine.ServerRuntimeOption{Port: port, TLSOption: tlsOption}, client, fmt.Sprintf("localhost:%d", port))
+	s.port = port
+
+	return s
+}
+
+// Run the engine with the runtime option and wait until it accepts connections. The requests are sent to the host.
+func startWith(t *testing.T, e engine.IServerEngine, option engine.ServerRuntimeOption, client *http.Client, host string) *server {
+	t.Helper()
+
+	s := &server{t: t, engine: e, client: client, scheme: "http", host: host, done: make(chan struct{})}
+	if option.TLSOption != nil {
+		s.scheme = "https"
+	}
+	if s.client == nil {
+		s.client = &http.Client{Timeout: waitTimeout}
+	}
+
+	go func() {
+		defer close(s.done)
+		e.Run(option)
+	}()
+
+	listener := option.ListenerOptions()[0]
+	network, address := listener.ListenNetwork(), listener.ListenAddress()
+	if listener.Listener != nil {
+		network, address = listener.Listener.Addr().Network(), listener.Listener.Addr().String()
+	}
+	if !waitForAddress(network, address, waitTimeout) {
+		t.Fatalf("the engine did not start on %s", address)
+	}
+
+	t.Cleanup(s.stop)
+	return s
+}
+
+// Stop the engine and wait until Run returns.
+func (s *server) stop() {
+	s.stopOnce.Do(func() {
+		s.engine.Stop()
+
+		select {
+		case <-s.done:
+		case <-time.After(waitTimeout):
+			s.t.Errorf("Run did not return after Stop")
+		}
+	})
+}
+
+func (s *server) url(path string) string {
+	return fmt.Sprintf("%s://%s%s", s.scheme, s.host, path)
+}
+
+// Send a request and read the response body.
+func (s *server) do(method, path string, body string) (*http.Response, string) {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, s.url(path), reader)
+	if err != nil {
+		s.t.Fatalf("failed to create the request: %s", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s failed: %s", method, path, err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		s.t.Fatalf("failed to read the response of %s %s: %s", method, path, err)
+	}
+
+	return res, string(data)
+}
+
+// Send a request and check the status. Returns the body.
+func (s *server) expect(method, path, body string, status int) string {
+	s.t.Helper()
+
+	res, data := s.do(method, path, body)
+	if res.StatusCode != status {
+		s.t.Errorf("%s %s: expected status %d, got %d (%s)", method, path, status, res.StatusCode, data)
+	}
+
+	return data
+}
+
+// Check that the body is the JSON of the expected value
+func expectJSON(t *testing.T, body string, expected interface{}) {
+	t.Helper()
+
+	var actual, want interface{}
+	if err := json.Unmarshal([]byte(body), &actual); err != nil {
+		t.Errorf("expected a JSON body, got %q", body)
+		return
+	}
+
+	data, _ := json.Marshal(expected)
+	_ = json.Unmarshal(data, &want)
+
+	if fmt.Sprint(actual) != fmt.Sprint(want) {
+		t.Errorf("expected the body %v, got %v", want, actual)
+	}
+}
+
+// Create a route of an engine neutral handler
+func route(method, path string, handler controller.HandlerFunc, middlewares ...interface{}) controller.RouteSpec {
+	return controller.RouteSpec{Method: method, Path: path, Handler: handler, Middlewares: middlewares}
+}
+
+func testRoutes(t *testing.T, option Option) {
+	e := newEngine(t, option)
+
+	param := func(name string) controller.HandlerFunc {
+		return func(ctx controller.ExecutionContext) (interface{}, error) {
+			return map[string]string{name: ctx.Param(name), "route": ctx.RoutePath()}, nil
+		}
+	}
+
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "ItemController",
+		RootPath: "items",
+		Routes: []controller.RouteSpec{
+			route("GET", "", func(ctx controller.ExecutionContext) (interface{}, error) { return []string{"a", "b"}, nil }),
+			route("GET", "{id:int}", param("id")),
+			route("GET", "page/{page?}", param("page")),
+			route("GET", "files/{path...}", param("path")),
+			route("POST", "", func(ctx controller.ExecutionContext) (interface{}, error) {
+				var body struct {
+					Name string `json:"name"`
+				}
+				if err := ctx.Bind(&body); err != nil {
+					return nil, err
+				}
+				return map[string]string{"name": body.Name, "method": ctx.Method()}, nil
+			}),
+			route("DELETE", "{id:int}", func(ctx controller.ExecutionContext) (interface{}, error) {
+				ctx.SetHeader("X-Deleted", ctx.Param("id"))
+				return nil, ctx.Blob(http.StatusNoContent, "text/plain", nil)
+			}),
+		},
+	})
+
+	// Controllers sharing the root path
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "ItemStatsController",
+		RootPath: "items",
+		Routes:   []controller.RouteSpec{route("GET", "stats/count", param("count"))},
+	})
+
+	s := start(t, e, nil, nil)
+
+	expectJSON(t, s.expect("GET", "/items", "", http.StatusOK), []string{"a", "b"})
+	expectJSON(t, s.expect("GET", "/items/12", "", http.StatusOK), map[string]string{"id": "12", "route": "/items/{id:int}"})
+	expectJSON(t, s.expect("GET", "/items/page/3", "", http.StatusOK), map[string]string{"page": "3", "route": "/items/page/{page?}"})
+	expectJSON(t, s.expect("GET", "/items/page", "", http.StatusOK), map[string]string{"page": "", "route": "/items/page/{page?}"})
+	expectJSON(t, s.expect("GET", "/items/files/a/b.txt", "", http.StatusOK), map[string]string{"path": "a/b.txt", "route": "/items/files/{path...}"})
+	expectJSON(t, s.expect("POST", "/items", `{"name":"gimbap"}`, http.StatusOK), map[string]string{"name": "gimbap", "method": "POST"})
+	expectJSON(t, s.expect("GET", "/items/stats/count", "", http.StatusOK), map[string]string{"count": "", "route": "/items/stats/count"})
+
+	res, _ := s.do("DELETE", "/items/7", "")
+	if res.StatusCode != http.StatusNoContent || res.Header.Get("X-Deleted") != "7" {
+		t.Errorf("DELETE /items/7: expected 204 with the header, got %d (%q)", res.StatusCode, res.Header.Get("X-Deleted"))
+	}
+
+	// Parameters not matching the constraint are not found
+	s.expect("GET", "/items/abc", "", http.StatusNotFound)
+}
+
+// Request bound by the typed handler of the binding test
+type bindingRequest struct {
+	ID    int      `path:"id"`
+	Tags  []string `query:"tag"`
+	Token string   `header:"X-Token"`
+	Name  string   `json:"name" form:"name"`
+}
+
+// Check that the request structs are bound with the same tags on all engines
+func testBinding(t *testing.T, option Option) {
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "BindingController",
+		RootPath: "bind",
+		Routes: []controller.RouteSpec{
+			{Method: "POST", Path: "{id:int}", Handler: func(ctx controller.ExecutionContext, req bindingRequest) (bindingRequest, error) {
+				return req, nil
+			}},
+		},
+	})
+
+	s := start(t, e, nil, nil)
+
+	for contentType, body := range map[string]string{
+		// The body cannot overwrite the path parameter ("ID" is the JSON name of the field)
+		"application/json":                  `{"name":"gimbap","ID":99}`,
+		"application/x-www-form-urlencoded": "name=gimbap",
+	} {
+		req, err := http.NewRequest("POST", s.url("/bind/7?tag=a&tag=b"), strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Token", "secret")
+
+		res, err := s.client.Do(req)
+		if err != nil {
+			t.Fatalf("POST /bind/7 failed: %s", err)
+		}
+		data, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("POST /bind/7 (%s): expected status %d, got %d (%s)", contentType, http.StatusOK, res.StatusCode, data)
+			continue
+		}
+
+		var bound bindingRequest
+		if err := json.Unmarshal(data, &bound); err != nil {
+			t.Fatalf("expected a JSON body, got %q", data)
+		}
+
+		expected := bindingRequest{ID: 7, Tags: []string{"a", "b"}, Token: "secret", Name: "gimbap"}
+		if fmt.Sprint(bound) != fmt.Sprint(expected) {
+			t.Errorf("POST /bind/7 (%s): expected the request %+v, got %+v", contentType, expected, bound)
+		}
+	}
+
+	// Invalid parameters are bad requests
+	s.expect("POST", "/bind/7?tag=a", `{"name":1}`, http.StatusBadRequest)
+}
+
+func testMiddlewareOrder(t *testing.T, option Option) {
+	if option.Middleware == nil {
+		t.Skip("Option.Middleware is not given")
+	}
+
+	e := newEngine(t, option)
+	e.AddMiddleware(option.Middleware("global"))
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:        "OrderController",
+		RootPath:    "order",
+		Middlewares: []interface{}{option.Middleware("controller")},
+		Routes: []controller.RouteSpec{
+			route("GET", "route", func(ctx controller.ExecutionContext) (interface{}, error) { return "ok", nil }, option.Middleware("route")),
+			route("GET", "plain", func(ctx controller.ExecutionContext) (interface{}, error) { return "ok", nil }),
+		},
+	})
+
+	// Controller middlewares must not run for the other controllers
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "OtherController",
+		RootPath: "order",
+		Routes:   []controller.RouteSpec{route("GET", "other", func(ctx controller.ExecutionContext) (interface{}, error) { return "ok", nil })},
+	})
+
+	s := start(t, e, nil, nil)
+
+	for path, expected := range map[string]string{
+		"/order/route": "global,controller,route",
+		"/order/plain": "global,controller",
+		"/order/other": "global",
+	} {
+		res, _ := s.do("GET", path, "")
+		if actual := middlewareOrder(res.Header); actual != expected {
+			t.Errorf("GET %s: expected the middlewares %q, got %q", path, expected, actual)
+		}
+	}
+}
+
+// Get the names added by the middlewares in order
+func middlewareOrder(header http.Header) string {
+	names := []string{}
+	for _, value := range header.Values(MiddlewareHeader) {
+		for _, name := range strings.Split(value, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+func testErrors(t *testing.T, option Option) {
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "ErrorController",
+		RootPath: "errors",
+		Routes: []controller.RouteSpec{
+			route("GET", "exception", func(ctx controller.ExecutionContext) (interface{}, error) {
+				return nil, exception.Conflict("Already exists")
+			}),
+			route("GET", "error", func(ctx controller.ExecutionContext) (interface{}, error) {
+				return nil, fmt.Errorf("internal detail")
+			}),
+			route("GET", "panic", func(ctx controller.ExecutionContext) (interface{}, error) {
+				panic("boom")
+			}),
+		},
+	})
+
+	s := start(t, e, nil, nil)
+
+	for _, c := range []struct {
+		path   string
+		status int
+		detail string
+	}{
+		{"/errors/exception", http.StatusConflict, "Already exists"},
+		{"/errors/error", http.StatusInternalServerError, ""},
+		{"/errors/panic", http.StatusInternalServerError, ""},
+		{"/errors/unknown", http.StatusNotFound, ""},
+	} {
+		res, body := s.do("GET", c.path, "")
+		if res.StatusCode != c.status {
+			t.Errorf("GET %s: expected status %d, got %d", c.path, c.status, res.StatusCode)
+		}
+		if contentType := res.Header.Get("Content-Type"); !strings.HasPrefix(contentType, exception.ProblemJSONContentType) {
+			t.Errorf("GET %s: expected the problem details, got %q (%s)", c.path, contentType, body)
+			continue
+		}
+
+		var problem exception.ProblemDetails
+		if err := json.Unmarshal([]byte(body), &problem); err != nil || problem.Status != c.status {
+			t.Errorf("GET %s: invalid problem details %s", c.path, body)
+		}
+		if c.detail != "" && problem.Detail != c.detail {
+			t.Errorf("GET %s: expected the detail %q, got %q", c.path, c.detail, problem.Detail)
+		}
+		if strings.Contains(body, "internal detail") || strings.Contains(body, "boom") {
+			t.Errorf("GET %s: internal errors must not be exposed: %s", c.path, body)
+		}
+	}
+}
+
+// The views returned by the handlers are rendered the same by all engines. (interceptor of the view package)
+func testViews(t *testing.T, option Option) {
+	renderer := view.New(view.Option{
+		FS: fstest.MapFS{
+			"layouts/main.html": {Data: []byte(`<title>{{ block "title" . }}App{{ end }}</title><main>{{ yield }}</main>`)},
+			"users/detail.html": {Data: []byte(`{{ define "title" }}User{{ end }}<p>{{ .Name }}</p>`)},
+		},
+		Layout: "layouts/main",
+	})
+	if err := renderer.Load(); err != nil {
+		t.Fatalf("failed to load the views: %s", err)
+	}
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:         "ViewController",
+		RootPath:     "views",
+		Interceptors: []interceptor.IInterceptor{renderer},
+		Routes: []controller.RouteSpec{
+			route("GET", "users/{name}", func(ctx controller.ExecutionContext) (interface{}, error) {
+				return view.View{Name: "users/detail", Data: map[string]string{"Name": ctx.Param("name")}}, nil
+			}),
+			route("GET", "created", func(ctx controller.ExecutionContext) (interface{}, error) {
+				return &view.View{Name: "users/detail", Data: map[string]string{"Name": "new"}, Layout: view.NoLayout, Status: http.StatusCreated}, nil
+			}),
+			route("GET", "missing", func(ctx controller.ExecutionContext) (interface{}, error) {
+				return view.View{Name: "users/missing"}, nil
+			}),
+		},
+	})
+
+	s := start(t, e, nil, nil)
+
+	res, body := s.do("GET", "/views/users/kim&lee", "")
+	if res.StatusCode != http.StatusOK || body != "<title>User</title><main><p>kim&amp;lee</p></main>" {
+		t.Errorf("expected the view in the layout, got %d %q", res.StatusCode, body)
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+		t.Errorf("expected the content type of the views, got %q", contentType)
+	}
+
+	if body := s.expect("GET", "/views/created", "", http.StatusCreated); body != "<p>new</p>" {
+		t.Errorf("expected the view without the layout, got %q", body)
+	}
+	s.expect("GET", "/views/missing", "", http.StatusInternalServerError)
+}
+
+// The server-sent events are streamed the same by all engines, until the events end, the client disconnects or the engine stops.
+func testSSE(t *testing.T, option Option) {
+	disconnected := make(chan string, 2)
+	live := func(name string) controller.HandlerFunc {
+		return func(ctx controller.ExecutionContext) (interface{}, error) {
+			return sse.Stream{
+				Events:       make(chan sse.Event),
+				KeepAlive:    20 * time.Millisecond,
+				OnDisconnect: func() { disconnected <- name },
+			}, nil
+		}
+	}
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "EventController",
+		RootPath: "events",
+		Routes: []controller.RouteSpec{
+			route("GET", "numbers", func(ctx controller.ExecutionContext) (interface{}, error) {
+				events := make(chan sse.Event, 2)
+				events <- sse.Event{ID: "1", Data: "one\ntwo"}
+				events <- sse.Event{ID: "2", Event: "update", Data: map[string]int{"n": 2}}
+				close(events)
+
+				return sse.Stream{Events: events, Retry: 2 * time.Second}, nil
+			}),
+			route("GET", "resume", func(ctx controller.ExecutionContext) (interface{}, error) {
+				last, _ := strconv.Atoi(sse.LastEventID(ctx))
+
+				return &sse.Stream{Iterator: func(yield func(sse.Event) bool) {
+					for i := last + 1; i <= 3; i++ {
+						if !yield(sse.Event{ID: strconv.Itoa(i), Data: strconv.Itoa(i)}) {
+							return
+						}
+					}
+				}}, nil
+			}),
+			route("GET", "live", live("live")),
+			route("GET", "stopped", live("stopped")),
+		},
+	})
+
+	s := start(t, e, nil, nil)
+
+	res, body := s.do("GET", "/events/numbers", "")
+	if expected := "retry: 2000\n\nid: 1\ndata: one\ndata: two\n\nid: 2\nevent: update\ndata: {\"n\":2}\n\n"; body != expected {
+		t.Errorf("expected the events %q, got %q", expected, body)
+	}
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), sse.ContentType) || res.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("expected the headers of the event stream, got %q %q", res.Header.Get("Content-Type"), res.Header.Get("Cache-Control"))
+	}
+
+	req, _ := http.NewRequest("GET", s.url("/events/resume"), nil)
+	req.Header.Set(sse.LastEventIDHeader, "1")
+	if res, err := s.client.Do(req); err != nil {
+		t.Errorf("failed to resume the events: %s", err)
+	} else {
+		data, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if string(data) != "id: 2\ndata: 2\n\nid: 3\ndata: 3\n\n" {
+			t.Errorf("expected the events after the last event ID, got %q", data)
+		}
+	}
+
+	// Open a stream until the first keep-alive comment
+	open := func(path string) io.Closer {
+		t.Helper()
+
+		res, err := (&http.Client{}).Get(s.url(path))
+		if err != nil {
+			t.Fatalf("failed to open the stream %s: %s", path, err)
+		}
+
+		reader := bufio.NewReader(res.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("expected a keep-alive comment on %s: %s", path, err)
+			}
+			if line == ": ping\n" {
+				return res.Body
+			}
+		}
+	}
+
+	waitDisconnected := func(name string) {
+		t.Helper()
+
+		select {
+		case got := <-disconnected:
+			if got != name {
+				t.Errorf("expected the stream %s to end, got %s", name, got)
+			}
+		case <-time.After(waitTimeout):
+			t.Errorf("the stream %s did not end", name)
+		}
+	}
+
+	open("/events/live").Close()
+	waitDisconnected("live")
+
+	// The open streams end when the engine stops, instead of holding the shutdown
+	defer open("/events/stopped").Close()
+
+	started := time.Now()
+	s.stop()
+	waitDisconnected("stopped")
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Errorf("expected the engine to stop without waiting for the streams, took %s", elapsed)
+	}
+}
+
+func testStatic(t *testing.T, option Option) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello gimbap"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "nested.txt"), []byte("nested"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEngine(t, option)
+	e.AddStatic("/static", dir)
+
+	s := start(t, e, nil, nil)
+
+	if body := s.expect("GET", "/static/hello.txt", "", http.StatusOK); body != "hello gimbap" {
+		t.Errorf("expected the file content, got %q", body)
+	}
+	if body := s.expect("GET", "/static/sub/nested.txt", "", http.StatusOK); body != "nested" {
+		t.Errorf("expected the nested file content, got %q", body)
+	}
+	s.expect("GET", "/static/missing.txt", "", http.StatusNotFound)
+}
+
+// The StaticOption is served the same by all engines. (after the routes, from an fs.FS)
+func testStaticOption(t *testing.T, option Option) {
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	writer.Write([]byte("console.log(1)"))
+	writer.Close()
+
+	files := fstest.MapFS{
+		"dist/index.html":       {Data: []byte("<html>app</html>")},
+		"dist/assets/app.js":    {Data: []byte("console.log(1)")},
+		"dist/assets/app.js.gz": {Data: gzipped.Bytes()},
+		"dist/assets/app.js.br": {Data: []byte("brotli")},
+		"dist/docs/readme.txt":  {Data: []byte("readme")},
+		"dist/docs/guide/a.txt": {Data: []byte("a")},
+	}
+
+	e := newEngine(t, option)
+	e.AddStatic("/", "dist", engine.StaticOption{
+		FS:                files,
+		Fallback:          "index.html",
+		CacheControl:      "public, max-age=60",
+		IndexCacheControl: "no-cache",
+		ETag:              true,
+		Precompressed:     true,
+	})
+	e.AddStatic("/files", "dist/docs", engine.StaticOption{FS: files, Browse: true})
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "ApiController",
+		RootPath: "api",
+		Routes:   []controller.RouteSpec{route("GET", "users", func(ctx controller.ExecutionContext) (interface{}, error) { return "users", nil })},
+	})
+
+	s := start(t, e, nil, nil)
+
+	get := func(path string, header ...string) (*http.Response, string) {
+		t.Helper()
+
+		req, _ := http.NewRequest("GET", s.url(path), nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+
+		res, err := s.client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s failed: %s", path, err)
+		}
+		defer res.Body.Close()
+
+		data, _ := io.ReadAll(res.Body)
+		return res, string(data)
+	}
+
+	// The routes first
+	expectJSON(t, s.expect("GET", "/api/users", "", http.StatusOK), "users")
+
+	res, body := get("/assets/app.js", "Accept-Encoding", "identity")
+	if res.StatusCode != http.StatusOK || body != "console.log(1)" {
+		t.Errorf("expected the asset, got %d %q", res.StatusCode, body)
+	}
+	if !strings.Contains(res.Header.Get("Content-Type"), "javascript") {
+		t.Errorf("expected the content type of the asset, got %q", res.Header.Get("Content-Type"))
+	}
+	if res.Header.Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("expected the cache control of the files, got %q", res.Header.Get("Cache-Control"))
+	}
+
+	etag := res.Header.Get("ETag")
+	if etag == "" {
+		t.Error("expected an ETag")
+	} else if res, _ := get("/assets/app.js", "Accept-Encoding", "identity", "If-None-Match", etag); res.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for the ETag, got %d", res.StatusCode)
+	}
+
+	// Precompressed assets (the transport does not decompress the responses of an explicit Accept-Encoding)
+	if res, body := get("/assets/app.js", "Accept-Encoding", "br, gzip"); res.Header.Get("Content-Encoding") != "br" || body != "brotli" {
+		t.Errorf("expected the brotli asset, got %q %q", res.Header.Get("Content-Encoding"), body)
+	}
+	if res, body := get("/assets/app.js", "Accept-Encoding", "gzip"); res.Header.Get("Content-Encoding") != "gzip" || body != gzipped.String() {
+		t.Errorf("expected the gzip asset, got %q", res.Header.Get("Content-Encoding"))
+	}
+
+	// SPA fallback for the pages requested by the browsers
+	res, body = get("/users/1/profile", "Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	if res.StatusCode != http.StatusOK || body != "<html>app</html>" {
+		t.Errorf("expected the fallback, got %d %q", res.StatusCode, body)
+	}
+	if res.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("expected the cache control of the index, got %q", res.Header.Get("Cache-Control"))
+	}
+
+	// The unmatched API paths and the missing assets are not found, instead of the fallback
+	for _, request := range [][]string{
+		{"/api/unknown"},
+		{"/api/unknown", "Accept", "application/json"},
+		{"/assets/missing.js", "Accept", "text/html,*/*"},
+	} {
+		res, body := get(request[0], request[1:]...)
+		if res.StatusCode != http.StatusNotFound || !strings.HasPrefix(res.Header.Get("Content-Type"), exception.ProblemJSONContentType) {
+			t.Errorf("GET %s %v: expected the not found problem, got %d %q (%s)", request[0], request[1:], res.StatusCode, res.Header.Get("Content-Type"), body)
+		}
+	}
+
+	// Directory listing of the longer prefix (redirected to the trailing slash)
+	if body := s.expect("GET", "/files", "", http.StatusOK); !strings.Contains(body, `href="readme.txt"`) || !strings.Contains(body, `href="guide/"`) {
+		t.Errorf("expected the listing of the directory, got %q", body)
+	}
+	if body := s.expect("GET", "/files/guide/a.txt", "", http.StatusOK); body != "a" {
+		t.Errorf("expected the file of the listed directory, got %q", body)
+	}
+	s.expect("GET", "/files/missing.txt", "", http.StatusNotFound)
+	if res, body := get("/files/missing", "Accept", "text/html"); res.StatusCode != http.StatusOK || body != "<html>app</html>" {
+		t.Errorf("expected the page to fall back to the index of /, got %d %q", res.StatusCode, body)
+	}
+
+	// Only GET and HEAD
+	if res, _ := s.do("POST", "/assets/app.js", ""); res.StatusCode == http.StatusOK {
+		t.Errorf("expected POST not to be served, got %d", res.StatusCode)
+	}
+}
+
+func testMount(t *testing.T, option Option) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.Path)
+	})
+
+	e := newEngine(t, option)
+	if option.Middleware != nil {
+		e.AddMiddleware(option.Middleware("global"))
+	}
+	e.Mount("/legacy", mux)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "NewController",
+		Routes: []controller.RouteSpec{route("GET", "users", func(ctx controller.ExecutionContext) (interface{}, error) { return "new", nil })},
+	})
+
+	s := start(t, e, nil, nil)
+
+	// The mounted handler receives the path without the prefix, for all methods
+	for _, c := range []struct{ method, path, body string }{
+		{"GET", "/legacy/users", "GET /users"},
+		{"POST", "/legacy/users/1", "POST /users/1"},
+		{"DELETE", "/legacy/a/b/c", "DELETE /a/b/c"},
+		{"GET", "/legacy", "GET /"},
+	} {
+		res, body := s.do(c.method, c.path, "")
+		if res.StatusCode != http.StatusOK || body != c.body {
+			t.Errorf("%s %s: expected %q, got %d (%s)", c.method, c.path, c.body, res.StatusCode, body)
+		}
+		if option.Middleware != nil && middlewareOrder(res.Header) != "global" {
+			t.Errorf("%s %s: expected the global middleware, got %q", c.method, c.path, middlewareOrder(res.Header))
+		}
+	}
+
+	// The routes of the engine are not shadowed by the mount
+	expectJSON(t, s.expect("GET", "/users", "", http.StatusOK), "new")
+	s.expect("GET", "/legacyusers", "", http.StatusNotFound)
+}
+
+// Check that a handler mounted on the root serves the paths the routes do not match
+func testMountRoot(t *testing.T, option Option) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.Path)
+	})
+
+	e := newEngine(t, option)
+	e.Mount("/", mux)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "NewController",
+		Routes: []controller.RouteSpec{route("GET", "users", func(ctx controller.ExecutionContext) (interface{}, error) { return "new", nil })},
+	})
+
+	s := start(t, e, nil, nil)
+
+	for _, c := range []struct{ method, path, body string }{
+		{"GET", "/", "GET /"},
+		{"GET", "/legacy/users", "GET /legacy/users"},
+		{"POST", "/users/1", "POST /users/1"},
+	} {
+		res, body := s.do(c.method, c.path, "")
+		if res.StatusCode != http.StatusOK || body != c.body {
+			t.Errorf("%s %s: expected %q, got %d (%s)", c.method, c.path, c.body, res.StatusCode, body)
+		}
+	}
+
+	// The routes of the engine are not shadowed by the mount
+	expectJSON(t, s.expect("GET", "/users", "", http.StatusOK), "new")
+}
+
+// Check that the handler of the engine serves the routes without Run
+func testHandler(t *testing.T, option Option) {
+	e := newEngine(t, option)
+	if option.Middleware != nil {
+		e.AddMiddleware(option.Middleware("global"))
+	}
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:     "HandlerController",
+		RootPath: "items",
+		Routes: []controller.RouteSpec{route("GET", "{id}", func(ctx controller.ExecutionContext) (interface{}, error) {
+			return map[string]string{"id": ctx.Param("id")}, nil
+		})},
+	})
+
+	ts := httptest.NewServer(e.Handler())
+	defer ts.Close()
+
+	res, err := ts.Client().Get(ts.URL + "/items/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d (%s)", res.StatusCode, body)
+	}
+	expectJSON(t, string(body), map[string]string{"id": "3"})
+	if option.Middleware != nil && middlewareOrder(res.Header) != "global" {
+		t.Errorf("expected the global middleware, got %q", middlewareOrder(res.Header))
+	}
+
+	res, err = ts.Client().Get(ts.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /missing: expected status 404, got %d", res.StatusCode)
+	}
+}
+
+// Check that the engine serves TLS with the option
+func testTLS(t *testing.T, option Option, cert *Certificate, tlsOption *engine.TLSOption) {
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "TLSController",
+		Routes: []controller.RouteSpec{route("GET", "secure", func(ctx controller.ExecutionContext) (interface{}, error) { return "secure", nil })},
+	})
+
+	client := &http.Client{
+		Timeout:   waitTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: cert.Pool}},
+	}
+	s := start(t, e, tlsOption, client)
+
+	res, body := s.do("GET", "/secure", "")
+	if res.StatusCode != http.StatusOK || res.TLS == nil {
+		t.Errorf("expected a TLS response, got %d (%s)", res.StatusCode, body)
+	}
+
+	// Plain http requests must not be served
+	plain := &http.Client{Timeout: waitTimeout}
+	if res, err := plain.Get(fmt.Sprintf("http://localhost:%d/secure", s.port)); err == nil {
+		if res.StatusCode == http.StatusOK {
+			t.Errorf("plain http request was served on the TLS port")
+		}
+		res.Body.Close()
+	}
+}
+
+func testTLSConfig(t *testing.T, option Option) {
+	cert, err := GenerateCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testTLS(t, option, cert, &engine.TLSOption{Config: &tls.Config{Certificates: []tls.Certificate{cert.TLS}}})
+}
+
+func testTLSFiles(t *testing.T, option Option) {
+	cert, err := GenerateCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := writeCertificate(t, t.TempDir(), cert)
+
+	testTLS(t, option, cert, &engine.TLSOption{CertFile: certFile, KeyFile: keyFile})
+}
+
+func testGracefulStop(t *testing.T, option Option) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name: "SlowController",
+		Routes: []controller.RouteSpec{route("GET", "slow", func(ctx controller.ExecutionContext) (interface{}, error) {
+			close(entered)
+			<-release
+			return "done", nil
+		})},
+	})
+
+	s := start(t, e, nil, nil)
+
+	// Start a request and stop the engine while it is in flight
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		res, err := s.client.Get(s.url("/slow"))
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		results <- result{status: res.StatusCode, body: string(body)}
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(waitTimeout):
+		t.Fatal("the request did not reach the handler")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.stop()
+		close(stopped)
+	}()
+
+	// The engine must not stop before the in-flight request is done
+	select {
+	case <-stopped:
+		t.Error("Stop returned before the in-flight request was done")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+
+	r := <-results
+	if r.err != nil || r.status != http.StatusOK {
+		t.Errorf("the in-flight request failed: %d %v", r.status, r.err)
+	}
+	expectJSON(t, r.body, "done")
+
+	select {
+	case <-stopped:
+	case <-time.After(waitTimeout):
+		t.Fatal("Stop did not return")
+	}
+
+	// New connections are refused after the stop
+	if conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", s.port), time.Second); err == nil {
+		conn.Close()
+		t.Error("the engine accepts connections after Stop")
+	}
+}
+
+func testPort(t *testing.T, option Option) {
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "PingController",
+		Routes: []controller.RouteSpec{route("GET", "ping", func(ctx controller.ExecutionContext) (interface{}, error) { return "pong", nil })},
+	})
+
+	s := start(t, e, nil, nil)
+	expectJSON(t, s.expect("GET", "/ping", "", http.StatusOK), "pong")
+
+	// The port is released after the stop
+	s.stop()
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		t.Fatalf("the port %d is not released after Stop: %s", s.port, err)
+	}
+	ln.Close()
+}
+
+// Check that the engine serves on the listener given by the option
+func testListener(t *testing.T, option Option) {
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "PingController",
+		Routes: []controller.RouteSpec{route("GET", "ping", func(ctx controller.ExecutionContext) (interface{}, error) { return "pong", nil })},
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := startWith(t, e, engine.ServerRuntimeOption{Listener: ln}, nil, ln.Addr().String())
+	expectJSON(t, s.expect("GET", "/ping", "", http.StatusOK), "pong")
+}
+
+func testUnixSocket(t *testing.T, option Option) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain sockets are not tested on windows")
+	}
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "PingController",
+		Routes: []controller.RouteSpec{route("GET", "ping", func(ctx controller.ExecutionContext) (interface{}, error) { return "pong", nil })},
+	})
+
+	// Short path, as the socket path is limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "gimbap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "http.sock")
+
+	client := &http.Client{
+		Timeout: waitTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	s := startWith(t, e, engine.ServerRuntimeOption{SocketPath: socket}, client, "gimbap")
+	expectJSON(t, s.expect("GET", "/ping", "", http.StatusOK), "pong")
+}
+
+// Check that port 0 listens on an ephemeral port, reported by OnReady and Addr
+func testEphemeralPort(t *testing.T, option Option) {
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "PingController",
+		Routes: []controller.RouteSpec{route("GET", "ping", func(ctx controller.ExecutionContext) (interface{}, error) { return "pong", nil })},
+	})
+
+	if e.Addr() != nil {
+		t.Errorf("expected no address before Run, got %s", e.Addr())
+	}
+
+	ready := make(chan net.Addr, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(engine.ServerRuntimeOption{Host: "127.0.0.1", OnReady: func(addr net.Addr) { ready <- addr }})
+	}()
+
+	var addr net.Addr
+	select {
+	case addr = <-ready:
+	case <-time.After(waitTimeout):
+		t.Fatal("OnReady was not called")
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || tcpAddr.Port == 0 {
+		t.Fatalf("expected a TCP address with a port, got %v", addr)
+	}
+	if e.Addr() == nil || e.Addr().String() != addr.String() {
+		t.Errorf("expected Addr to be %s, got %v", addr, e.Addr())
+	}
+
+	s := &server{t: t, engine: e, client: &http.Client{Timeout: waitTimeout}, scheme: "http", host: addr.String(), done: done}
+	t.Cleanup(s.stop)
+
+	expectJSON(t, s.expect("GET", "/ping", "", http.StatusOK), "pong")
+}
+
+// Check that the engine serves plain http and https at once, with a listener redirecting to https
+func testMultipleListeners(t *testing.T, option Option) {
+	cert, err := GenerateCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "PingController",
+		Routes: []controller.RouteSpec{route("GET", "ping", func(ctx controller.ExecutionContext) (interface{}, error) { return "pong", nil })},
+	})
+
+	plainPort, tlsPort, redirectPort := FreePort(t), FreePort(t), FreePort(t)
+	client := &http.Client{
+		Timeout:   waitTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: cert.Pool}},
+		// Check the redirect response itself
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	s := startWith(t, e, engine.ServerRuntimeOption{
+		Listeners: []engine.ListenerOption{
+			{Port: plainPort},
+			{Port: tlsPort, TLSOption: &engine.TLSOption{Config: &tls.Config{Certificates: []tls.Certificate{cert.TLS}}}},
+			{Port: redirectPort, RedirectToHTTPS: true},
+		},
+	}, client, fmt.Sprintf("localhost:%d", plainPort))
+
+	if !waitForAddress("tcp", fmt.Sprintf("127.0.0.1:%d", redirectPort), waitTimeout) {
+		t.Fatal("the redirect listener did not start")
+	}
+
+	expectJSON(t, s.expect("GET", "/ping", "", http.StatusOK), "pong")
+
+	res, err := client.Get(fmt.Sprintf("https://localhost:%d/ping", tlsPort))
+	if err != nil {
+		t.Fatalf("https request failed: %s", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.TLS == nil {
+		t.Errorf("expected a TLS response, got %d (%s)", res.StatusCode, body)
+	}
+	expectJSON(t, string(body), "pong")
+
+	res, err = client.Get(fmt.Sprintf("http://localhost:%d/ping?a=1", redirectPort))
+	if err != nil {
+		t.Fatalf("redirect request failed: %s", err)
+	}
+	res.Body.Close()
+	expected := fmt.Sprintf("https://localhost:%d/ping?a=1", tlsPort)
+	if res.StatusCode != http.StatusPermanentRedirect || res.Header.Get("Location") != expected {
+		t.Errorf("expected a redirect to %s, got %d (%s)", expected, res.StatusCode, res.Header.Get("Location"))
+	}
+
+	// All listeners are closed by Stop
+	s.stop()
+	for _, port := range []int{plainPort, tlsPort, redirectPort} {
+		if conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second); err == nil {
+			conn.Close()
+			t.Errorf("the port %d accepts connections after Stop", port)
+		}
+	}
+}
+
+// Write the certificate and the key files to the directory
+func writeCertificate(t *testing.T, dir string, cert *Certificate) (certFile, keyFile string) {
+	t.Helper()
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, cert.CertPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, cert.KeyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+// Check that the rotated certificate files are served without a restart
+func testTLSReload(t *testing.T, option Option) {
+	first, err := GenerateCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GenerateCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, first)
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "PingController",
+		Routes: []controller.RouteSpec{route("GET", "ping", func(ctx controller.ExecutionContext) (interface{}, error) { return "pong", nil })},
+	})
+
+	// Trust both certificates, and check which one is served
+	pool := first.Pool.Clone()
+	pool.AppendCertsFromPEM(second.CertPEM)
+	client := &http.Client{Timeout: waitTimeout, Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}, DisableKeepAlives: true}}
+
+	s := start(t, e, &engine.TLSOption{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Millisecond}, client)
+
+	served := func() []byte {
+		res, _ := s.do("GET", "/ping", "")
+		if res.TLS == nil || len(res.TLS.PeerCertificates) == 0 {
+			t.Fatal("expected a TLS response")
+		}
+		return res.TLS.PeerCertificates[0].Raw
+	}
+
+	if string(served()) != string(first.TLS.Certificate[0]) {
+		t.Error("expected the first certificate")
+	}
+
+	// Rotate the files (with a later modification time)
+	writeCertificate(t, dir, second)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	time.Sleep(10 * time.Millisecond)
+
+	if string(served()) != string(second.TLS.Certificate[0]) {
+		t.Error("expected the reloaded certificate")
+	}
+}
+
+// Check that the client certificates are verified, and the identity is given to the handlers
+func testMutualTLS(t *testing.T, option Option) {
+	cert, err := GenerateCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := GenerateClientCertificate("gimbap-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name: "IdentityController",
+		Routes: []controller.RouteSpec{route("GET", "whoami", func(ctx controller.ExecutionContext) (interface{}, error) {
+			identity := engine.PeerIdentityOf(ctx.TLS())
+			if identity == nil {
+				return "anonymous", nil
+			}
+			return identity.CommonName, nil
+		})},
+	})
+
+	client := &http.Client{
+		Timeout: waitTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      cert.Pool,
+			Certificates: []tls.Certificate{clientCert.TLS},
+		}},
+	}
+
+	s := start(t, e, &engine.TLSOption{
+		Config:    &tls.Config{Certificates: []tls.Certificate{cert.TLS}},
+		ClientCAs: clientCert.Pool,
+	}, client)
+
+	expectJSON(t, s.expect("GET", "/whoami", "", http.StatusOK), "gimbap-client")
+
+	// Clients without a certificate are rejected by the handshake
+	anonymous := &http.Client{Timeout: waitTimeout, Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: cert.Pool}}}
+	if res, err := anonymous.Get(s.url("/whoami")); err == nil {
+		res.Body.Close()
+		t.Errorf("expected the handshake to fail without a client certificate, got %d", res.StatusCode)
+	}
+}
+
+// Check that the engine serves HTTP/2 without TLS with the h2c protocol option
+func testH2C(t *testing.T, option Option) {
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "ProtoController",
+		Routes: []controller.RouteSpec{route("GET", "proto", func(ctx controller.ExecutionContext) (interface{}, error) { return "h2c", nil })},
+	})
+
+	// HTTP/2 with prior knowledge on a plain connection
+	client := &http.Client{
+		Timeout: waitTimeout,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	port := FreePort(t)
+	s := startWith(t, e, engine.ServerRuntimeOption{Port: port, Protocols: engine.ProtocolOption{H2C: true}}, client, fmt.Sprintf("localhost:%d", port))
+
+	res, body := s.do("GET", "/proto", "")
+	if res.StatusCode != http.StatusOK || res.ProtoMajor != 2 {
+		t.Errorf("expected an HTTP/2 response, got %s %d (%s)", res.Proto, res.StatusCode, body)
+	}
+
+	// HTTP/1.1 is still served
+	res, err := http.Get(s.url("/proto"))
+	if err != nil {
+		t.Fatalf("HTTP/1.1 request failed: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.ProtoMajor != 1 {
+		t.Errorf("expected an HTTP/1.1 response, got %s %d", res.Proto, res.StatusCode)
+	}
+}
+
+// Check that the engine serves HTTP/3 on the port of the TLS listener, and advertises it on the TLS responses
+func testHTTP3(t *testing.T, option Option) {
+	cert, err := GenerateCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEngine(t, option)
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "ProtoController",
+		Routes: []controller.RouteSpec{route("GET", "proto", func(ctx controller.ExecutionContext) (interface{}, error) { return "h3", nil })},
+	})
+
+	client := &http.Client{
+		Timeout:   waitTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: cert.Pool}},
+	}
+
+	port := FreePort(t)
+	s := startWith(t, e, engine.ServerRuntimeOption{
+		Port:      port,
+		TLSOption: &engine.TLSOption{Config: &tls.Config{Certificates: []tls.Certificate{cert.TLS}}},
+		Protocols: engine.ProtocolOption{HTTP3: true},
+	}, client, fmt.Sprintf("localhost:%d", port))
+
+	res, _ := s.do("GET", "/proto", "")
+	expected := fmt.Sprintf(`h3=":%d"`, port)
+	if !strings.HasPrefix(res.Header.Get("Alt-Svc"), expected) {
+		t.Errorf("expected the Alt-Svc header %s, got %q", expected, res.Header.Get("Alt-Svc"))
+	}
+
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: cert.Pool}}
+	defer transport.Close()
+
+	h3 := &http.Client{Timeout: waitTimeout, Transport: transport}
+	res, err = h3.Get(s.url("/proto"))
+	if err != nil {
+		t.Fatalf("HTTP/3 request failed: %s", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.ProtoMajor != 3 {
+		t.Errorf("expected an HTTP/3 response, got %s %d (%s)", res.Proto, res.StatusCode, body)
+	}
+	expectJSON(t, string(body), "h3")
+}
+
+// Create an engine with the limits, or skip the test if Option.NewWithOption is not given
+func newWithOption(t *testing.T, option Option, engineOption engine.ServerEngineOption) Engine {
+	t.Helper()
+
+	if option.NewWithOption == nil {
+		t.Skip("Option.NewWithOption is not given")
+	}
+
+	return asEngine(t, option.NewWithOption(engineOption))
+}
+
+// Create a new engine for a test
+func newEngine(t *testing.T, option Option) Engine {
+	t.Helper()
+
+	return asEngine(t, option.New())
+}
+
+// Get the engine with the capabilities checked by the suite. Fails the test if the engine does not have them.
+func asEngine(t *testing.T, e engine.IServerEngine) Engine {
+	t.Helper()
+
+	casted, ok := e.(Engine)
+	if !ok {
+		t.Fatalf("%T does not implement the capabilities checked by the suite (see Engine)", e)
+	}
+
+	return casted
+}
+
+// Check that the requests with a body larger than MaxBodyBytes are rejected
+func testBodyLimit(t *testing.T, option Option) {
+	e := newWithOption(t, option, engine.ServerEngineOption{MaxBodyBytes: 64})
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name: "UploadController",
+		Routes: []controller.RouteSpec{route("POST", "upload", func(ctx controller.ExecutionContext) (interface{}, error) {
+			var body struct {
+				Name string `json:"name"`
+			}
+			if err := ctx.Bind(&body); err != nil {
+				return nil, err
+			}
+			return body.Name, nil
+		}),
+			// The request of the typed handlers is bound before the handler is called
+			{Method: "POST", Path: "typed", Handler: func(ctx controller.ExecutionContext, body struct {
+				Name string `json:"name"`
+			}) (interface{}, error) {
+				return body.Name, nil
+			}},
+		},
+	})
+
+	s := start(t, e, nil, nil)
+
+	large := fmt.Sprintf(`{"name":"%s"}`, strings.Repeat("x", 128))
+	s.expect("POST", "/upload", `{"name":"gimbap"}`, http.StatusOK)
+	s.expect("POST", "/upload", large, http.StatusRequestEntityTooLarge)
+	s.expect("POST", "/typed", `{"name":"gimbap"}`, http.StatusOK)
+	s.expect("POST", "/typed", large, http.StatusRequestEntityTooLarge)
+
+	// Chunked body without a Content-Length, so the limit is only reached while reading the body
+	req, err := http.NewRequest("POST", s.url("/typed"), io.MultiReader(strings.NewReader(large)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		t.Fatalf("POST /typed failed: %s", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST /typed (chunked): expected status %d, got %d (%s)", http.StatusRequestEntityTooLarge, res.StatusCode, body)
+	}
+}
+
+// Check that the connections sending the headers slowly are closed by the ReadHeaderTimeout (slowloris)
+func testHeaderTimeout(t *testing.T, option Option) {
+	e := newWithOption(t, option, engine.ServerEngineOption{ReadHeaderTimeout: 200 * time.Millisecond})
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name:   "PingController",
+		Routes: []controller.RouteSpec{route("GET", "ping", func(ctx controller.ExecutionContext) (interface{}, error) { return "pong", nil })},
+	})
+
+	s := start(t, e, nil, nil)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", s.port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The headers are never finished
+	if _, err := conn.Write([]byte("GET /ping HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	begin := time.Now()
+	conn.SetReadDeadline(time.Now().Add(waitTimeout))
+	if _, err := io.Copy(io.Discard, conn); err != nil && time.Since(begin) >= waitTimeout {
+		t.Fatalf("the connection was not closed: %s", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("the connection was closed after %s, expected about 200ms", elapsed)
+	}
+}
+
+// Check that Stop does not wait for the in-flight requests longer than the ShutdownTimeout
+func testShutdownTimeout(t *testing.T, option Option) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	e := newWithOption(t, option, engine.ServerEngineOption{ShutdownTimeout: 300 * time.Millisecond})
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name: "SlowController",
+		Routes: []controller.RouteSpec{route("GET", "slow", func(ctx controller.ExecutionContext) (interface{}, error) {
+			close(entered)
+			<-release
+			return "done", nil
+		})},
+	})
+
+	s := start(t, e, nil, nil)
+
+	go func() {
+		if res, err := s.client.Get(s.url("/slow")); err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(waitTimeout):
+		t.Fatal("the request did not reach the handler")
+	}
+
+	begin := time.Now()
+	s.stop()
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("Stop returned after %s, expected about 300ms", elapsed)
+	}
+}
+
+// The requests are counted while they are handled, and the requests still in flight after the shutdown timeout are reported.
+func testRequests(t *testing.T, option Option) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	streamRelease := make(chan struct{})
+
+	e := newWithOption(t, option, engine.ServerEngineOption{ShutdownTimeout: 300 * time.Millisecond})
+	e.RegisterControllerSpec(engine.ControllerSpec{
+		Name: "RequestsController",
+		Routes: []controller.RouteSpec{
+			route("GET", "fast", func(ctx controller.ExecutionContext) (interface{}, error) {
+				return "done", nil
+			}),
+			route("GET", "slow", func(ctx controller.ExecutionContext) (interface{}, error) {
+				close(entered)
+				<-release
+				return "done", nil
+			}),
+			route("GET", "stream", func(ctx controller.ExecutionContext) (interface{}, error) {
+				return nil, ctx.Stream(http.StatusOK, "text/plain", func(w controller.StreamWriter) {
+					io.WriteString(w, "open\n")
+					w.Flush()
+
+					select {
+					case <-streamRelease:
+					case <-w.Done():
+					}
+				})
+			}),
+		},
+	})
+
+	s := start(t, e, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		res, err := s.client.Get(s.url("/fast"))
+		if err != nil {
+			t.Fatalf("failed to get /fast: %s", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	if stats := e.Requests(); stats.Completed != 3 || stats.InFlight != 0 {
+		t.Errorf("expected 3 completed requests and none in flight, got %+v", stats)
+	}
+
+	// The streamed responses are in flight until the stream ends
+	res, err := s.client.Get(s.url("/stream"))
+	if err != nil {
+		t.Fatalf("failed to get /stream: %s", err)
+	}
+	if line, err := bufio.NewReader(res.Body).ReadString('\n'); err != nil || line != "open\n" {
+		t.Fatalf("expected the first line of the stream, got %q (%v)", line, err)
+	}
+	if stats := e.Requests(); stats.InFlight != 1 {
+		t.Errorf("expected the stream in flight, got %+v", stats)
+	}
+
+	close(streamRelease)
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	deadline := time.Now().Add(waitTimeout)
+	for stats := e.Requests(); stats.Completed != 4 || stats.InFlight != 0; stats = e.Requests() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the stream to be completed, got %+v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	go func() {
+		if res, err := s.client.Get(s.url("/slow")); err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(waitTimeout):
+		t.Fatal("the request did not reach the handler")
+	}
+
+	if stats := e.Requests(); stats.InFlight != 1 {
+		t.Errorf("expected 1 request in flight, got %+v", stats)
+	}
+
+	// The slow request is cut off by the shutdown timeout
+	s.stop()
+	if stats := e.Requests(); stats.Completed != 4 || stats.InFlight != 1 {
+		t.Errorf("expected the slow request to be cut off, got %+v", stats)
+	}
+}
@@ -0,0 +1,28 @@
+package fiber_engine_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/enginetest"
+	fiber_engine "github.com/jhseong7/gimbap/engine/fiber"
+)
+
+func TestConformance(t *testing.T) {
+	enginetest.Run(t, enginetest.Option{
+		New: func() engine.IServerEngine { return fiber_engine.NewFiberHttpEngine() },
+		NewWithOption: func(option engine.ServerEngineOption) engine.IServerEngine {
+			return fiber_engine.NewFiberHttpEngine(fiber_engine.FiberHttpEngineOption{ServerEngineOption: option})
+		},
+		Middleware: func(name string) interface{} {
+			return func(c *fiber.Ctx) error {
+				c.Append(enginetest.MiddlewareHeader, name)
+				return c.Next()
+			}
+		},
+
+		// fasthttp serves HTTP/1.1 only
+		Skip: []string{"h2c", "http3"},
+	})
+}
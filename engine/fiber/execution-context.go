@@ -0,0 +1,164 @@
+package fiber_engine
+
+import (
+	"bufio"
+	"crypto/tls"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+)
+
+type (
+	// Writer of the streamed responses. fasthttp does not report the disconnections, so they are detected by the failed flushes.
+	fiberStreamWriter struct {
+		*bufio.Writer
+
+		done      chan struct{}
+		closeOnce sync.Once
+	}
+
+	// Request counted by the engine, ended by the stream writer if the response is streamed
+	streamedRequest struct {
+		end      func()
+		streamed bool
+	}
+
+	// Key of the streamedRequest in the locals of the context
+	streamedRequestKey struct{}
+
+	// Fiber implementation of the controller.ExecutionContext
+	fiberExecutionContext struct {
+		controller.ExecutionContext
+
+		ctx            *fiber.Ctx
+		controllerName string
+		route          controller.RouteSpec
+		routePath      string
+		path           *engine.RoutePath // Parsed route path (nil outside of the routes)
+		metadata       map[string]interface{}
+	}
+)
+
+func (c *fiberExecutionContext) Native() interface{}              { return c.ctx }
+func (c *fiberExecutionContext) ControllerName() string           { return c.controllerName }
+func (c *fiberExecutionContext) Route() controller.RouteSpec      { return c.route }
+func (c *fiberExecutionContext) Metadata() map[string]interface{} { return c.metadata }
+func (c *fiberExecutionContext) RoutePath() string                { return c.routePath }
+func (c *fiberExecutionContext) Method() string                   { return c.ctx.Method() }
+func (c *fiberExecutionContext) Path() string                     { return c.ctx.Path() }
+func (c *fiberExecutionContext) Header(key string) string         { return c.ctx.Get(key) }
+func (c *fiberExecutionContext) TLS() *tls.ConnectionState {
+	return c.ctx.Context().TLSConnectionState()
+}
+func (c *fiberExecutionContext) SetHeader(key, value string) { c.ctx.Set(key, value) }
+
+// Fiber does not track if the response is written. Check the body instead.
+func (c *fiberExecutionContext) Written() bool {
+	return len(c.ctx.Response().Body()) > 0 || c.ctx.Response().IsBodyStream()
+}
+
+func (c *fiberExecutionContext) JSON(status int, value interface{}) error {
+	return c.ctx.Status(status).JSON(value)
+}
+
+func (c *fiberExecutionContext) Blob(status int, contentType string, data []byte) error {
+	c.ctx.Set(fiber.HeaderContentType, contentType)
+	return c.ctx.Status(status).Send(data)
+}
+
+// Stream the response with the body stream writer of fasthttp, which runs after the handler returns.
+func (c *fiberExecutionContext) Stream(status int, contentType string, stream func(w controller.StreamWriter)) error {
+	c.ctx.Set(fiber.HeaderContentType, contentType)
+	c.ctx.Status(status)
+
+	// The context is released once the handler returns, so the done channel of the server and the request are taken here
+	shutdown := c.ctx.Context().Done()
+	var end func()
+	if request, ok := c.ctx.Locals(streamedRequestKey{}).(*streamedRequest); ok && !request.streamed {
+		request.streamed = true
+		end = request.end
+	}
+
+	c.ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if end != nil {
+			defer end()
+		}
+
+		writer := &fiberStreamWriter{Writer: w, done: make(chan struct{})}
+
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-shutdown:
+				writer.close()
+			case <-finished:
+			}
+		}()
+
+		if writer.Flush() == nil {
+			stream(writer)
+		}
+	})
+
+	return nil
+}
+
+func (w *fiberStreamWriter) Flush() error {
+	err := w.Writer.Flush()
+	if err != nil {
+		w.close()
+	}
+
+	return err
+}
+
+func (w *fiberStreamWriter) Done() <-chan struct{} {
+	return w.done
+}
+
+func (w *fiberStreamWriter) close() {
+	w.closeOnce.Do(func() { close(w.done) })
+}
+
+// Get the path parameter. The catch-all parameter is registered as "*" on fiber.
+func (c *fiberExecutionContext) Param(name string) string {
+	if name != "" && name == c.path.CatchAll() {
+		name = "*"
+	}
+
+	return c.ctx.Params(name)
+}
+
+// Bind the body ("json" or "form" tag by the content type) with the fiber body parser,
+// then the path parameters, the query and the headers with the engine neutral tags. (see engine.BindParameters)
+func (c *fiberExecutionContext) Bind(v interface{}) error {
+	// Body first, so the parameters are not overwritten by the body fields
+	if len(c.ctx.Body()) > 0 {
+		if err := c.ctx.BodyParser(v); err != nil {
+			return err
+		}
+	}
+
+	return engine.BindParameters(v, engine.BindSource{
+		Param:  c.Param,
+		Query:  func(name string) []string { return byteStrings(c.ctx.Context().QueryArgs().PeekMulti(name)) },
+		Header: func(name string) []string { return byteStrings(c.ctx.Request().Header.PeekAll(name)) },
+	})
+}
+
+// Copy the byte values of fasthttp to strings, as the bytes are reused after the request. nil if there is no value.
+func byteStrings(values [][]byte) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = string(value)
+	}
+
+	return strs
+}
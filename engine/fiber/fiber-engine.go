@@ -1,17 +1,23 @@
 package fiber_engine
 
 import (
-	"crypto/tls"
-	"fmt"
+	"errors"
+	"net"
+	"net/http"
 	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	r "github.com/gofiber/fiber/v2/middleware/recover"
 
 	"github.com/jhseong7/ecl"
 	"github.com/jhseong7/gimbap/controller"
 	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/middleware"
 )
 
 type (
@@ -21,63 +27,184 @@ type (
 		// The underlying http engine
 		engine          *fiber.App
 		globalApiPrefix string
+		routeMetadata   []fiberRouteMetadata
+
+		// Shutdown timeout of the option (the limits are applied to the fiber config)
+		shutdownTimeout time.Duration
+
+		// Address of the listener (set once the server listens)
+		address engine.ServerAddress
+
+		// Requests served by the engine
+		requests engine.RequestCounter
+
+		// Static files of the StaticOption, served when no route matches
+		statics engine.Statics
 
 		logger ecl.Logger
 	}
 
+	// Metadata of a registered route. Fiber does not expose the matched route to the middlewares, so the paths are matched by the patterns.
+	fiberRouteMetadata struct {
+		method   string
+		pattern  *regexp.Regexp
+		metadata map[string]interface{}
+	}
+
 	FiberHttpEngineOption struct {
 		engine.ServerEngineOption
+
+		// Config of fiber. The values set here take precedence over the limits of the ServerEngineOption.
 		FiberConfig fiber.Config
 	}
 )
 
-// Check if the handler is valid and cast it to gin.HandlerFunc.
+// Check if the handler is valid and cast it to fiber.Handler.
 //
-// This is a helper function to check if the handler is valid and cast it to gin.HandlerFunc before registering it to the engine.
+// This is a helper function to check if the handler is valid and cast it to fiber.Handler before registering it to the engine.
 func (e *FiberHttpEngine) checkAndCastToFiberHandler(handler interface{}) fiber.Handler {
-	// Check if the input 0 is *gin.Context
-	handlerType := reflect.TypeOf(handler)
-	if handlerType.NumIn() == 0 || handlerType.In(0) != reflect.TypeOf(&fiber.Ctx{}) {
-		e.logger.Panicf("Handler's first parameter must be *fiber.Context --> got %s", handlerType.String())
+	// Get the native middleware from the middleware provider instances
+	handler = middleware.ToNative(handler)
+
+	// NOTE: fiber.Handler is an alias of func(*fiber.Ctx) error
+	if h, ok := handler.(fiber.Handler); ok {
+		return h
+	}
+
+	e.logger.Panicf("Handler's first parameter must be *fiber.Context --> got %s", reflect.TypeOf(handler).String())
+	return nil
+}
+
+// Cast the middlewares to fiber.Handler
+func (e *FiberHttpEngine) castMiddlewares(middlewares []interface{}) []fiber.Handler {
+	casted := make([]fiber.Handler, 0, len(middlewares))
+	for _, m := range middlewares {
+		casted = append(casted, e.checkAndCastToFiberHandler(m))
+	}
+
+	return casted
+}
+
+// Convert the fiber native errors to http exceptions, so they are handled the same way as other engines.
+func convertFiberError(err error) error {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return exception.New(fe.Code, fe.Message)
 	}
 
-	// Cast the handler value's interface to Fiber's Handler type (func(*fiber.Ctx) error)
-	return handler.(func(*fiber.Ctx) error)
+	return err
+}
+
+// Path syntax of fiber. Text after a parameter must start with a delimiter of fiber's router.
+//
+// The type constraints are given to fiber's router, as fiber matches the routes in the registration order.
+// (e.g. /items/{id:int} must not match /items/page, so a later route can)
+var pathSyntax = engine.PathSyntax{
+	Engine:          "fiber",
+	Param:           func(name string) string { return ":" + name },
+	CatchAll:        func(name string) string { return "*" },
+	Optional:        func(name string) string { return ":" + name + "?" },
+	Constraint:      func(name, constraint string) string { return name + fiberConstraints[constraint] },
+	MixedSegments:   true,
+	ParamDelimiters: "-.",
+}
+
+// Native constraints of fiber by the gimbap constraints
+var fiberConstraints = map[string]string{
+	"int":   "<int>",
+	"uint":  "<regex(^[0-9]+$)>",
+	"float": "<float>",
+	"bool":  "<bool>",
+	"alpha": "<alpha>",
+	"alnum": "<regex(^[A-Za-z0-9]+$)>",
+	"uuid":  "<guid>",
+}
+
+// Create the fiber handler of the route.
+//
+// The handler is wrapped to run through the interceptors, and the result value is written as the response.
+// Errors and panics are handled by the exception filters.
+func (e *FiberHttpEngine) createRouteHandler(spec engine.ControllerSpec, route controller.RouteSpec, fullPath string) fiber.Handler {
+	interceptors := spec.RouteInterceptors(route)
+	filters := spec.RouteExceptionFilters(route)
+	path, _ := engine.ParsePath(fullPath) // Validated by the translation
+	metadata := spec.RouteMetadata(route)
+
+	nativeHandler, isNative := route.Handler.(func(*fiber.Ctx) error)
+	valueHandler, isValue := engine.CastToValueHandler(route.Handler, reflect.TypeOf(&fiber.Ctx{}))
+
+	if !isNative && !isValue {
+		e.logger.Panicf("Handler must be func(*fiber.Ctx) error, func(*fiber.Ctx[, Req]) (T, error) or controller.HandlerFunc: %s", reflect.TypeOf(route.Handler).String())
+	}
+
+	return func(c *fiber.Ctx) error {
+		ctx := &fiberExecutionContext{ctx: c, controllerName: spec.Name, route: route, routePath: fullPath, path: path, metadata: metadata}
+
+		engine.ExecuteHandler(ctx, path, interceptors, filters, func() (interface{}, error) {
+			if isNative {
+				return nil, convertFiberError(nativeHandler(c))
+			}
+
+			v, err := valueHandler(ctx)
+			return v, convertFiberError(err)
+		})
+
+		return nil
+	}
 }
 
-func (e *FiberHttpEngine) RegisterController(rootPath string, instance controller.IController) {
+// Register the routes of the controller. (see engine.NewControllerSpec)
+//
+// Deprecated: the app registers the controllers with RegisterControllerSpec.
+func (e *FiberHttpEngine) RegisterController(rootPath string, c controller.IController) {
+	e.RegisterControllerSpec(engine.NewControllerSpec(rootPath, c))
+}
+
+func (e *FiberHttpEngine) RegisterControllerSpec(spec engine.ControllerSpec) {
 	defer func() {
 		if r := recover(); r != nil {
-			e.logger.Panicf("Failed to register controller to path: %s", rootPath)
+			e.logger.Panicf("Failed to register controller to path: %s. %v", spec.RootPath, r)
 		}
 	}()
 
-	routeSpecs := instance.GetRouteSpecs()
+	// Register the controller as a route group.
+	// NOTE: The controller middlewares are not given to the group, as fiber applies group handlers to every request under the prefix
+	// (including other controllers with the same root path, and unmatched routes). They are attached to each route instead.
+	groupPath, err := engine.TranslateGroupPath(pathSyntax, e.globalApiPrefix, spec.RootPath)
+	if err != nil {
+		e.logger.Panic(err.Error())
+	}
+	group := e.engine.Group(groupPath)
+	controllerMiddlewares := e.castMiddlewares(spec.Middlewares)
 
-	for _, routeSpec := range routeSpecs {
+	for _, routeSpec := range spec.Routes {
 		engine.CheckMethodValidity(routeSpec.Method)
-		fullPath := engine.MergeRestPath(e.globalApiPrefix, rootPath, routeSpec.Path)
+		fullPath := engine.MergeRestPath(e.globalApiPrefix, spec.RootPath, routeSpec.Path)
+
+		// Translate the path to the fiber syntax
+		relPaths, err := engine.TranslateRoutePath(pathSyntax, groupPath, fullPath)
+		if err != nil {
+			e.logger.Panic(err.Error())
+		}
+
+		// Metadata of the route for the middlewares
+		metadata := spec.RouteMetadata(routeSpec)
+		if path, err := engine.ParsePath(fullPath); err == nil && metadata != nil {
+			e.routeMetadata = append(e.routeMetadata, fiberRouteMetadata{method: routeSpec.Method, pattern: path.Regexp(), metadata: metadata})
+		}
+
+		// The metadata of the matched route (which may differ from the first pattern match), then the middlewares (controller --> route) and the handler
+		handlers := []fiber.Handler{func(c *fiber.Ctx) error {
+			c.Locals(engine.MetadataContextKey, metadata)
+			return c.Next()
+		}}
+		handlers = append(handlers, controllerMiddlewares...)
+		handlers = append(handlers, e.castMiddlewares(routeSpec.Middlewares)...)
+		handlers = append(handlers, e.createRouteHandler(spec, routeSpec, fullPath))
 
 		// Register the route
-		// Unlike gin, fiber does not have a method to register a route with a handler function.
-		// so use a switch statement to register the route based on the method.
-		switch routeSpec.Method {
-		case "GET":
-			e.engine.Get(fullPath, e.checkAndCastToFiberHandler(routeSpec.Handler))
-		case "POST":
-			e.engine.Post(fullPath, e.checkAndCastToFiberHandler(routeSpec.Handler))
-		case "PUT":
-			e.engine.Put(fullPath, e.checkAndCastToFiberHandler(routeSpec.Handler))
-		case "DELETE":
-			e.engine.Delete(fullPath, e.checkAndCastToFiberHandler(routeSpec.Handler))
-		case "PATCH":
-			e.engine.Patch(fullPath, e.checkAndCastToFiberHandler(routeSpec.Handler))
-		case "OPTIONS":
-			e.engine.Options(fullPath, e.checkAndCastToFiberHandler(routeSpec.Handler))
-		case "HEAD":
-			e.engine.Head(fullPath, e.checkAndCastToFiberHandler(routeSpec.Handler))
-		default:
-			e.logger.Panicf("Invalid HTTP method: %s. Must be one of (GET, POST, PUT, DELETE, PATCH, OPTIONS, HEAD)", routeSpec.Method)
+		for _, relPath := range relPaths {
+			group.Add(routeSpec.Method, relPath, handlers...)
 		}
 
 		// Get the name of the Handler function
@@ -88,87 +215,96 @@ func (e *FiberHttpEngine) RegisterController(rootPath string, instance controlle
 }
 
 // Add middleware to the engine
-func (e *FiberHttpEngine) AddMiddleware(middleware ...interface{}) {
-	for _, m := range middleware {
+func (e *FiberHttpEngine) AddMiddleware(middlewares ...interface{}) {
+	for _, m := range middlewares {
 		casted := e.checkAndCastToFiberHandler(m)
 		e.engine.Use(casted)
 	}
 }
 
 func (e *FiberHttpEngine) Run(option engine.ServerRuntimeOption) {
-	port := option.Port
-
-	if port == 0 {
-		e.logger.Warn("Port is not set. Defaulting to 8080")
-		port = 8080
-	}
-
-	// Load the tls config if it is given
-	// Split the case for TLS and non-TLS
-	if option.TLSOption != nil {
-		e.logger.Logf("Starting the http engine with TLS on port %d", port)
-
-		// If the config is given directly, use it, else load the cert/key files
-		var config *tls.Config
-		if option.TLSOption.Config != nil {
-			// Use the given tls config directly
-			config = option.TLSOption.Config
-		} else {
-			config = &tls.Config{
-				MinVersion: tls.VersionTLS12,
-			}
-		}
+	// fasthttp serves HTTP/1.1 only
+	if err := option.Protocols.Unsupported("fiber"); err != nil {
+		e.logger.Fatalf("Failed to start the http engine: %s", err)
+	}
 
-		// Only load the cert/key files if the config does not have a certificate
-		if option.TLSOption.CertFile != "" && option.TLSOption.KeyFile != "" && config.Certificates == nil {
-			var err error
-			cert, err := tls.LoadX509KeyPair(option.TLSOption.CertFile, option.TLSOption.KeyFile)
-			if err != nil {
-				e.logger.Fatalf("Failed to load TLS config: %s", err)
-			}
+	// Create the listeners (host and port, unix socket, given listener or systemd socket, with TLS and the redirects to https)
+	listeners, err := engine.OpenListeners(option)
+	if err != nil {
+		e.logger.Fatalf("Failed to create the listeners: %s", err)
+	}
+	listeners.Log(e.logger.Logf)
 
-			config = &tls.Config{
-				MinVersion:   tls.VersionTLS12,
-				Certificates: []tls.Certificate{cert},
-			}
-		}
+	// The connections are accepted from here (queued until the server starts)
+	e.address.Ready(option, listeners.Addr())
+	defer e.address.Set(nil)
 
-		// If the certificates are not loaded, panic
-		if config.Certificates == nil {
-			e.logger.Fatalf(
-				"Failed to load TLS config: At least one of tls.Config.Certificates or 'CertFile and KeyFile' are required",
-			)
-		}
+	// Start the server on all listeners (blocking)
+	if err := listeners.Serve(e.engine.Listener); err != nil {
+		e.logger.Fatalf("Failed to start the http engine: %v", err)
+	}
+}
 
-		// Create a listener with the tls config
-		ln, err := tls.Listen("tcp", fmt.Sprintf(":%d", port), config)
-		if err != nil {
-			e.logger.Fatalf("Failed to create a tls listener: %s", err)
-		}
+func (e *FiberHttpEngine) Stop() {
+	e.logger.Logf("Stopping the http engine (Max %s)", e.shutdownTimeout)
 
-		if err := e.engine.Listener(ln); err != nil {
-			e.logger.Fatalf("Failed to start the http engine: %v", err)
-		}
+	if err := e.engine.ShutdownWithTimeout(e.shutdownTimeout); err != nil {
+		e.logger.Warnf("Server failed to shutdown gracefully within %s: %v", e.shutdownTimeout, err)
 		return
 	}
 
-	e.logger.Logf("Starting the http engine on port %d", port)
+	e.logger.Log("Server stopped gracefully")
+}
 
-	// TODO: add a way to set cert and key for https
-	if err := e.engine.Listen(fmt.Sprintf(":%d", port)); err != nil {
-		e.logger.Fatalf("Failed to start the http engine: %v", err)
-	}
+// Get the address the server listens on. (nil if not running)
+func (e *FiberHttpEngine) Addr() net.Addr {
+	return e.address.Get()
 }
 
-func (e *FiberHttpEngine) Stop() {
-	e.logger.Log("Stopping the http engine (Max 5 seconds)")
+// Get the counts of the requests served by the engine
+func (e *FiberHttpEngine) Requests() engine.RequestStats {
+	return e.requests.Stats()
+}
 
-	if err := e.engine.ShutdownWithTimeout(5 * time.Second); err != nil {
-		e.logger.Fatalf("Failed to shutdown the http engine: %v", err)
+func (e *FiberHttpEngine) GetGlobalApiPrefix() string {
+	return e.globalApiPrefix
+}
+
+// Set the path rewriter as the first handler of fiber.
+//
+// NOTE: Fiber matches the routes while running the handlers, so the overridden path is used for the routes after the rewriter.
+// The rewriter must be set before any other handler is added.
+func (e *FiberHttpEngine) SetPathRewriter(rewriter engine.PathRewriter) {
+	e.engine.Use(func(c *fiber.Ctx) error {
+		header := func(key string) string { return c.Get(key) }
+		if path := rewriter(c.Method(), c.Path(), header); path != c.Path() {
+			c.Path(path)
+			e.attachMetadata(c)
+		}
+
+		return c.Next()
+	})
+}
+
+// Attach the metadata of the route matching the path to the context, so the global middlewares can read it with the reflector.
+func (e *FiberHttpEngine) attachMetadata(c *fiber.Ctx) {
+	for _, r := range e.routeMetadata {
+		if r.method == c.Method() && r.pattern.MatchString(c.Path()) {
+			c.Locals(engine.MetadataContextKey, r.metadata)
+			return
+		}
 	}
+
+	c.Locals(engine.MetadataContextKey, nil)
 }
 
+// The StaticOption is served when no route matches (the files are converted with the adaptor middleware), or fiber.Static by fiber.
 func (e *FiberHttpEngine) AddStatic(prefix, root string, config ...interface{}) {
+	if option, ok := engine.StaticOptionOf(config); ok {
+		e.addStaticFiles(prefix, root, option)
+		return
+	}
+
 	// Try and cast the config to fiber.Static
 	var fiberStaticConfig fiber.Static
 	if len(config) > 0 {
@@ -183,11 +319,89 @@ func (e *FiberHttpEngine) AddStatic(prefix, root string, config ...interface{})
 	e.engine.Static(prefix, root, fiberStaticConfig)
 }
 
+// Serve the static files for the requests not matching any route. (the next handlers end with 404)
+//
+// The middleware is added by the first static files, and serves all static files of the engine.
+func (e *FiberHttpEngine) addStaticFiles(prefix, root string, option engine.StaticOption) {
+	files, err := engine.NewStaticFiles(prefix, root, option)
+	if err != nil {
+		e.logger.Panicf("Failed to add static files to the engine: %s", err)
+	}
+
+	e.statics = e.statics.Add(files)
+	if len(e.statics) > 1 {
+		return
+	}
+
+	served := adaptor.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.statics.Serve(w, r)
+	}))
+	e.engine.Use(func(c *fiber.Ctx) error {
+		if !e.statics.Has(c.Method(), c.Path(), c.Get(fiber.HeaderAccept)) {
+			return c.Next()
+		}
+
+		err := c.Next()
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusNotFound {
+			return err
+		}
+
+		return served(c)
+	})
+}
+
+// Mount the handler on the prefix for all methods. The handler is converted to a fiber handler with the adaptor middleware.
+//
+// A handler mounted on the root serves the requests no route matches, so it does not shadow the routes registered after it.
+//
+// NOTE: The mounted handler does not call the next handlers, so the middlewares added after the mount are not applied to it.
+func (e *FiberHttpEngine) Mount(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	mounted := adaptor.HTTPHandler(engine.MountHandler(prefix, handler))
+
+	if prefix == "" {
+		e.engine.Use(func(c *fiber.Ctx) error {
+			err := c.Next()
+			var fiberErr *fiber.Error
+			if !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusNotFound {
+				return err
+			}
+
+			return mounted(c)
+		})
+		return
+	}
+
+	e.engine.Use(engine.MergeRestPath(prefix), func(c *fiber.Ctx) error {
+		// Fiber matches the prefix without the path boundary (e.g. /legacy matches /legacyusers)
+		if rest := strings.TrimPrefix(c.Path(), prefix); rest != "" && rest[0] != '/' {
+			return c.Next()
+		}
+
+		return mounted(c)
+	})
+}
+
+// Get the engine as an http.Handler. The requests are converted to fasthttp requests with the adaptor middleware.
+func (e *FiberHttpEngine) Handler() http.Handler {
+	return adaptor.FiberApp(e.engine)
+}
+
 // Internal function to create a new fiber engine
 func createFiberHttpEngine(fiberConfig fiber.Config) (e *fiber.App) {
 	// Inject the custom logger to the fiber logger
 	// Initialize the custom logger
 
+	// Errors outside the routes (e.g. middlewares, unmatched routes) are written in the same format as the exception filters
+	// unless a custom error handler is given.
+	if fiberConfig.ErrorHandler == nil {
+		fiberConfig.ErrorHandler = func(c *fiber.Ctx, err error) error {
+			exception.Handle(&fiberExecutionContext{ctx: c}, nil, convertFiberError(err))
+			return nil
+		}
+	}
+
 	e = fiber.New(fiberConfig)
 
 	// Add the recover middleware to the engine
@@ -198,6 +412,32 @@ func createFiberHttpEngine(fiberConfig fiber.Config) (e *fiber.App) {
 	return
 }
 
+// Apply the limits of the engine option to the fiber config, if the config does not set them.
+//
+// fasthttp does not have a timeout of the headers only, so ReadHeaderTimeout is the ReadTimeout if it is not set.
+func applyServerLimits(config *fiber.Config, option engine.ServerEngineOption) {
+	if config.ReadTimeout == 0 {
+		config.ReadTimeout = option.ReadTimeout
+		if config.ReadTimeout == 0 {
+			config.ReadTimeout = option.ReadHeaderTimeout
+		}
+	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = option.WriteTimeout
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = option.IdleTimeout
+	}
+
+	// The headers must fit in the read buffer of fasthttp
+	if config.ReadBufferSize == 0 {
+		config.ReadBufferSize = option.MaxHeaderBytes
+	}
+	if config.BodyLimit == 0 {
+		config.BodyLimit = int(option.MaxBodyBytes)
+	}
+}
+
 // Create a new http engine (for now, gin is the only supported engine)
 func NewFiberHttpEngine(options ...FiberHttpEngineOption) *FiberHttpEngine {
 	// Get the options
@@ -212,11 +452,33 @@ func NewFiberHttpEngine(options ...FiberHttpEngineOption) *FiberHttpEngine {
 	})
 
 	// Create gin engine with the logger
-	e := createFiberHttpEngine(option.FiberConfig)
+	config := option.FiberConfig
+	applyServerLimits(&config, option.ServerEngineOption)
+	e := createFiberHttpEngine(config)
 
-	return &FiberHttpEngine{
+	fe := &FiberHttpEngine{
 		engine:          e,
 		logger:          l,
 		globalApiPrefix: option.GlobalApiPrefix,
+		shutdownTimeout: option.GetShutdownTimeout(),
 	}
+
+	// Runs before the middlewares added by the user
+	e.Use(func(c *fiber.Ctx) error {
+		fe.requests.Begin()
+
+		// The streamed responses are written after the handler returns, so the request ends with the stream instead (see Stream)
+		request := &streamedRequest{end: fe.requests.End}
+		c.Locals(streamedRequestKey{}, request)
+		defer func() {
+			if !request.streamed {
+				request.end()
+			}
+		}()
+
+		fe.attachMetadata(c)
+		return c.Next()
+	})
+
+	return fe
 }
@@ -0,0 +1,25 @@
+package gin_engine_test
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/enginetest"
+	gin_engine "github.com/jhseong7/gimbap/engine/gin"
+)
+
+func TestConformance(t *testing.T) {
+	enginetest.Run(t, enginetest.Option{
+		New: func() engine.IServerEngine { return gin_engine.NewGinHttpEngine() },
+		NewWithOption: func(option engine.ServerEngineOption) engine.IServerEngine {
+			return gin_engine.NewGinHttpEngine(option)
+		},
+		Middleware: func(name string) interface{} {
+			return gin.HandlerFunc(func(c *gin.Context) {
+				c.Writer.Header().Add(enginetest.MiddlewareHeader, name)
+				c.Next()
+			})
+		},
+	})
+}
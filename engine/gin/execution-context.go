@@ -0,0 +1,73 @@
+package gin_engine
+
+import (
+	"crypto/tls"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/internal/nethttp"
+)
+
+type (
+	// Gin implementation of the controller.ExecutionContext
+	ginExecutionContext struct {
+		controller.ExecutionContext
+
+		ctx            *gin.Context
+		controllerName string
+		route          controller.RouteSpec
+		routePath      string
+		path           *engine.RoutePath // Parsed route path (nil outside of the routes)
+		metadata       map[string]interface{}
+	}
+)
+
+func (c *ginExecutionContext) Native() interface{}              { return c.ctx }
+func (c *ginExecutionContext) ControllerName() string           { return c.controllerName }
+func (c *ginExecutionContext) Route() controller.RouteSpec      { return c.route }
+func (c *ginExecutionContext) Metadata() map[string]interface{} { return c.metadata }
+func (c *ginExecutionContext) RoutePath() string                { return c.routePath }
+func (c *ginExecutionContext) Method() string                   { return c.ctx.Request.Method }
+func (c *ginExecutionContext) Path() string                     { return c.ctx.Request.URL.Path }
+func (c *ginExecutionContext) Header(key string) string         { return c.ctx.GetHeader(key) }
+func (c *ginExecutionContext) TLS() *tls.ConnectionState        { return c.ctx.Request.TLS }
+func (c *ginExecutionContext) SetHeader(key, value string)      { c.ctx.Header(key, value) }
+func (c *ginExecutionContext) Written() bool                    { return c.ctx.Writer.Written() }
+func (c *ginExecutionContext) JSON(status int, value interface{}) error {
+	c.ctx.JSON(status, value)
+	return nil
+}
+
+func (c *ginExecutionContext) Blob(status int, contentType string, data []byte) error {
+	c.ctx.Data(status, contentType, data)
+	return nil
+}
+
+func (c *ginExecutionContext) Stream(status int, contentType string, stream func(w controller.StreamWriter)) error {
+	return nethttp.Stream(c.ctx.Writer, c.ctx.Request, status, contentType, stream)
+}
+
+// Get the path parameter. Gin's catch-all values start with a slash, which is trimmed.
+func (c *ginExecutionContext) Param(name string) string {
+	if name == c.path.CatchAll() {
+		return strings.TrimPrefix(c.ctx.Param(name), "/")
+	}
+
+	return c.ctx.Param(name)
+}
+
+// Bind the request with the engine neutral tags (see nethttp.Bind), then validate it with the validator of gin. ("binding" tag)
+func (c *ginExecutionContext) Bind(v interface{}) error {
+	if err := nethttp.Bind(c.ctx.Request, v, c.Param); err != nil {
+		return err
+	}
+
+	if binding.Validator != nil {
+		return binding.Validator.ValidateStruct(v)
+	}
+
+	return nil
+}
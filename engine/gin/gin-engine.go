@@ -2,18 +2,19 @@ package gin_engine
 
 import (
 	"bytes"
-	"context"
-	"crypto/tls"
-	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"reflect"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jhseong7/ecl"
 	"github.com/jhseong7/gimbap/controller"
 	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/internal/nethttp"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/middleware"
 )
 
 type (
@@ -23,9 +24,25 @@ type (
 		// The underlying http engine
 		engine          *gin.Engine
 		globalApiPrefix string
+		pathRewriter    engine.PathRewriter
+		metadata        engine.RouteMetadataTable
 
+		// Server of Run, with the limits and the timeouts of the option
+		option engine.ServerEngineOption
 		server *http.Server
 
+		// Address of the listener (set once the server listens)
+		address engine.ServerAddress
+
+		// Requests served by the server of Run
+		requests engine.RequestCounter
+
+		// Static files of the StaticOption, served when no route matches
+		statics engine.Statics
+
+		// Handler mounted on the root, served when no route matches (see Mount)
+		rootMount http.Handler
+
 		logger ecl.Logger
 
 		// server stop flag
@@ -50,32 +67,113 @@ func (g *GinLogger) Write(p []byte) (n int, err error) {
 //
 // This is a helper function to check if the handler is valid and cast it to gin.HandlerFunc before registering it to the engine.
 func (e *GinHttpEngine) checkAndCastToGinHandler(handler interface{}) gin.HandlerFunc {
-	// Check if the input 0 is *gin.Context
-	handlerType := reflect.TypeOf(handler)
-	if handlerType.NumIn() == 0 || handlerType.In(0) != reflect.TypeOf(&gin.Context{}) {
-		e.logger.Panicf("Handler's first parameter must be *gin.Context: %s", handlerType.String())
+	// Get the native middleware from the middleware provider instances
+	handler = middleware.ToNative(handler)
+
+	// Both the named type and the plain function type are accepted
+	switch h := handler.(type) {
+	case gin.HandlerFunc:
+		return h
+	case func(*gin.Context):
+		return h
+	}
+
+	e.logger.Panicf("Handler's first parameter must be *gin.Context: %s", reflect.TypeOf(handler).String())
+	return nil
+}
+
+// Cast the middlewares to gin.HandlerFunc
+func (e *GinHttpEngine) castMiddlewares(middlewares []interface{}) []gin.HandlerFunc {
+	casted := make([]gin.HandlerFunc, 0, len(middlewares))
+	for _, m := range middlewares {
+		casted = append(casted, e.checkAndCastToGinHandler(m))
 	}
 
-	// Cast the handler value's interface to gin.HandlerFunc
-	return handler.(func(*gin.Context))
+	return casted
+}
+
+// Path syntax of gin. Gin does not support optional parameters and parameters mixed with static text.
+var pathSyntax = engine.PathSyntax{
+	Engine:   "gin",
+	Param:    func(name string) string { return ":" + name },
+	CatchAll: func(name string) string { return "*" + name },
 }
 
-func (e *GinHttpEngine) RegisterController(rootPath string, instance controller.IController) {
+// Create the gin handler of the route.
+//
+// The handler is wrapped to run through the interceptors, and the result value is written as the response.
+// Errors (including the ones added with c.Error) and panics are handled by the exception filters.
+func (e *GinHttpEngine) createRouteHandler(spec engine.ControllerSpec, route controller.RouteSpec, fullPath string) gin.HandlerFunc {
+	interceptors := spec.RouteInterceptors(route)
+	filters := spec.RouteExceptionFilters(route)
+	path, _ := engine.ParsePath(fullPath) // Validated by the translation
+	metadata := spec.RouteMetadata(route)
+
+	nativeHandler, isNative := route.Handler.(func(*gin.Context))
+	valueHandler, isValue := engine.CastToValueHandler(route.Handler, reflect.TypeOf(&gin.Context{}))
+
+	if !isNative && !isValue {
+		e.logger.Panicf("Handler must be func(*gin.Context), func(*gin.Context[, Req]) (T, error) or controller.HandlerFunc: %s", reflect.TypeOf(route.Handler).String())
+	}
+
+	return func(c *gin.Context) {
+		ctx := &ginExecutionContext{ctx: c, controllerName: spec.Name, route: route, routePath: fullPath, path: path, metadata: metadata}
+
+		engine.ExecuteHandler(ctx, path, interceptors, filters, func() (interface{}, error) {
+			if isNative {
+				nativeHandler(c)
+
+				// Errors added by the native handler without a response are handled by the filters
+				if len(c.Errors) > 0 && !c.Writer.Written() {
+					return nil, c.Errors.Last().Err
+				}
+
+				return nil, nil
+			}
+
+			return valueHandler(ctx)
+		})
+	}
+}
+
+// Register the routes of the controller. (see engine.NewControllerSpec)
+//
+// Deprecated: the app registers the controllers with RegisterControllerSpec.
+func (e *GinHttpEngine) RegisterController(rootPath string, c controller.IController) {
+	e.RegisterControllerSpec(engine.NewControllerSpec(rootPath, c))
+}
+
+func (e *GinHttpEngine) RegisterControllerSpec(spec engine.ControllerSpec) {
 	defer func() {
 		if r := recover(); r != nil {
-			e.logger.Panicf("Failed to register controller to path: %s", rootPath)
+			e.logger.Panicf("Failed to register controller to path: %s. %v", spec.RootPath, r)
 		}
 	}()
 
-	routeSpecs := instance.GetRouteSpecs()
+	// Register the controller as a route group with the controller middlewares
+	groupPath, err := engine.TranslateGroupPath(pathSyntax, e.globalApiPrefix, spec.RootPath)
+	if err != nil {
+		e.logger.Panic(err.Error())
+	}
+	group := e.engine.Group(groupPath, e.castMiddlewares(spec.Middlewares)...)
 
-	for _, routeSpec := range routeSpecs {
+	for _, routeSpec := range spec.Routes {
 		engine.CheckMethodValidity(routeSpec.Method)
-		fullPath := engine.MergeRestPath(e.globalApiPrefix, rootPath, routeSpec.Path)
+		fullPath := engine.MergeRestPath(e.globalApiPrefix, spec.RootPath, routeSpec.Path)
+
+		// Translate the path to the gin syntax. (optional parameters are registered as 2 routes)
+		relPaths, err := engine.TranslateRoutePath(pathSyntax, groupPath, fullPath)
+		if err != nil {
+			e.logger.Panic(err.Error())
+		}
 
-		// Register the route
-		// Check if the handler is compatible with gin.HandlerFunc. else, panic so the user can fix it.
-		e.engine.Handle(routeSpec.Method, fullPath, e.checkAndCastToGinHandler(routeSpec.Handler))
+		// Register the route with the route middlewares
+		// Check if the handler is compatible with gin. else, panic so the user can fix it.
+		handlers := append(e.castMiddlewares(routeSpec.Middlewares), e.createRouteHandler(spec, routeSpec, fullPath))
+		for _, relPath := range relPaths {
+			group.Handle(routeSpec.Method, relPath, handlers...)
+			e.metadata.Set(routeSpec.Method, groupPath+relPath, spec.RouteMetadata(routeSpec))
+		}
 
 		// Get the name of the Handler function
 		handlerName := engine.RuntimeFuncName(routeSpec.Handler)
@@ -85,8 +183,8 @@ func (e *GinHttpEngine) RegisterController(rootPath string, instance controller.
 }
 
 // Add middleware to the engine
-func (e *GinHttpEngine) AddMiddleware(middleware ...interface{}) {
-	for _, m := range middleware {
+func (e *GinHttpEngine) AddMiddleware(middlewares ...interface{}) {
+	for _, m := range middlewares {
 		casted := e.checkAndCastToGinHandler(m)
 		e.engine.Use(casted)
 	}
@@ -96,97 +194,134 @@ func (e *GinHttpEngine) Run(option engine.ServerRuntimeOption) {
 	// Send the stop flag (if the server stops)
 	defer func() { e.stopFlag <- "stopped" }()
 
-	port := option.Port
+	// Create an http server with the limits of the engine option
+	e.server = nethttp.NewServer(e.option, e.requests.Handler(e.handler()))
 
-	if port == 0 {
-		e.logger.Warn("Port is not set. Defaulting to 8080")
-		port = 8080
+	// Create the listeners (host and port, unix socket, given listener or systemd socket, with TLS and the redirects to https)
+	listeners, err := engine.OpenListeners(option)
+	if err != nil {
+		e.logger.Fatalf("Failed to create the listeners: %s", err)
 	}
+	listeners.Log(e.logger.Logf)
+
+	// The connections are accepted from here (queued until the server starts)
+	e.address.Ready(option, listeners.Addr())
+	defer e.address.Set(nil)
 
-	// Create an http server
-	e.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: e.engine,
+	// Start the server (blocking)
+	if err := listeners.ServeHTTP(e.server, e.option.GetShutdownTimeout()); err != nil && err != http.ErrServerClosed {
+		e.logger.Fatalf("Failed to start the http engine: %s", err)
 	}
 
-	// Split the case for TLS and non-TLS
-	if option.TLSOption != nil {
-		e.logger.Logf("Starting the http engine with TLS on port %d", port)
+}
 
-		// If the config is given directly, use it, else load the cert/key files
-		var config *tls.Config
-		if option.TLSOption.Config != nil {
-			// Use the given tls config directly
-			config = option.TLSOption.Config
-		} else {
-			config = &tls.Config{
-				MinVersion: tls.VersionTLS12,
-			}
-		}
+func (e *GinHttpEngine) Stop() {
+	timeout := e.option.GetShutdownTimeout()
+	e.logger.Logf("Stopping the http engine (Max %s)", timeout)
 
-		// Only load the cert/key files if the config does not have a certificate
-		if option.TLSOption.CertFile != "" && option.TLSOption.KeyFile != "" && config.Certificates == nil {
-			var err error
-			cert, err := tls.LoadX509KeyPair(option.TLSOption.CertFile, option.TLSOption.KeyFile)
-			if err != nil {
-				e.logger.Fatalf("Failed to load TLS config: %s", err)
-			}
+	if err := nethttp.Shutdown(e.server, timeout, e.stopFlag); err != nil {
+		e.logger.Warnf("Server failed to shutdown gracefully within %s, the remaining connections are closed: %s", timeout, err)
+		return
+	}
 
-			config = &tls.Config{
-				MinVersion:   tls.VersionTLS12,
-				Certificates: []tls.Certificate{cert},
-			}
-		}
+	e.logger.Log("Server stopped gracefully")
+}
 
-		// If the certificates are not loaded, panic
-		if config.Certificates == nil {
-			e.logger.Fatalf(
-				"Failed to load TLS config: At least one of tls.Config.Certificates or 'CertFile and KeyFile' are required",
-			)
-		}
+// Get the address the server listens on. (nil if not running)
+func (e *GinHttpEngine) Addr() net.Addr {
+	return e.address.Get()
+}
 
-		// Create a listener with the tls config
-		tlsListener, err := tls.Listen("tcp", e.server.Addr, config)
-		if err != nil {
-			e.logger.Fatalf("Failed to create a tls listener: %s", err)
-		}
+// Get the counts of the requests served by Run
+func (e *GinHttpEngine) Requests() engine.RequestStats {
+	return e.requests.Stats()
+}
+
+func (e *GinHttpEngine) GetGlobalApiPrefix() string {
+	return e.globalApiPrefix
+}
+
+func (e *GinHttpEngine) SetPathRewriter(rewriter engine.PathRewriter) {
+	e.pathRewriter = rewriter
+}
+
+// Attach the metadata of the matched route to the context, so the middlewares can read it with the reflector.
+func (e *GinHttpEngine) attachMetadata(c *gin.Context) {
+	if metadata := e.metadata.Get(c.Request.Method, c.FullPath()); metadata != nil {
+		c.Set(engine.MetadataContextKey, metadata)
+	}
+
+	c.Next()
+}
+
+// Get the http handler of the engine. Gin routes before running the middlewares, so the path is rewritten before gin handles the request.
+func (e *GinHttpEngine) handler() http.Handler {
+	if e.pathRewriter == nil {
+		return e.engine
+	}
 
-		// Run the server with the tls listener
-		if err := e.server.Serve(tlsListener); err != nil && err != http.ErrServerClosed {
-			e.logger.Fatalf("Failed to start the http engine: %s", err)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path := e.pathRewriter(r.Method, r.URL.Path, r.Header.Get); path != r.URL.Path {
+			r.URL.Path = path
+			r.URL.RawPath = ""
 		}
 
+		e.engine.ServeHTTP(w, r)
+	})
+}
+
+// The StaticOption is served when no route matches. (so a static path on / does not conflict with the routes)
+func (e *GinHttpEngine) AddStatic(prefix, root string, config ...interface{}) {
+	option, ok := engine.StaticOptionOf(config)
+	if !ok {
+		// NOTE: Gin does not support other configs for static file serving
+		e.engine.Static(prefix, root)
 		return
 	}
 
-	e.logger.Logf("Starting the http engine on port %d", port)
+	files, err := engine.NewStaticFiles(prefix, root, option)
+	if err != nil {
+		e.logger.Panicf("Failed to add static files to the engine: %s", err)
+	}
+	e.statics = e.statics.Add(files)
+}
 
-	// Start the server. Http mode with no TLS
-	if err := e.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		e.logger.Fatalf("Failed to start the http engine: %s", err)
+// Mount the handler on the prefix for all methods. The prefix and the sub paths are registered as 2 routes.
+//
+// A handler mounted on the root serves the requests no route matches, as gin does not accept a catch-all route on the root next to the other routes.
+func (e *GinHttpEngine) Mount(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	h := engine.MountHandler(prefix, handler)
+
+	if prefix == "" {
+		if e.rootMount != nil {
+			e.logger.Panic("A handler is already mounted on the root")
+		}
+		e.rootMount = h
+		return
 	}
 
+	e.engine.Any(prefix, gin.WrapH(h))
+	e.engine.Any(prefix+"/*path", gin.WrapH(h))
 }
 
-func (e *GinHttpEngine) Stop() {
-	e.logger.Log("Stopping the http engine (Max 5 seconds)")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := e.server.Shutdown(ctx); err != nil {
-		e.logger.Fatalf("Failed to shutdown the http engine: %s", err)
+// Serve the requests no route matches with the root mount or the static files. Others are written in the same format as the exception filters.
+func (e *GinHttpEngine) noRoute(c *gin.Context) {
+	if e.rootMount != nil {
+		// gin sets the status of the unmatched routes to 404 before the handlers
+		c.Status(http.StatusOK)
+		e.rootMount.ServeHTTP(c.Writer, c.Request)
+		return
 	}
 
-	select {
-	case <-ctx.Done(): // Timeout
-		e.logger.Warn("Server failed to shutdown gracefully with in 5 seconds")
-	case <-e.stopFlag: // Graceful stop
-		e.logger.Log("Server stopped gracefully")
+	if !e.statics.Serve(c.Writer, c.Request) {
+		exception.Handle(&ginExecutionContext{ctx: c}, nil, exception.NotFound())
 	}
 }
 
-func (e *GinHttpEngine) AddStatic(prefix, root string, config ...interface{}) {
-	// NOTE: Gin does not support config for static file serving
-	e.engine.Static(prefix, root)
+// Get the engine as an http.Handler. (same as the handler of the server started by Run)
+func (e *GinHttpEngine) Handler() http.Handler {
+	return e.handler()
 }
 
 // Internal function to create a new gin engine
@@ -195,9 +330,14 @@ func createGinHttpEngine(logger ecl.Logger) (e *gin.Engine) {
 	gin.SetMode(gin.ReleaseMode)
 
 	e = gin.New()
-	e.Use(gin.Recovery())
 	e.Use(gin.LoggerWithWriter(&GinLogger{logger: logger}))
 
+	// Panics outside the routes (e.g. middlewares) are written in the same format as the exception filters
+	e.Use(gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		exception.Handle(&ginExecutionContext{ctx: c}, nil, exception.FromPanic(recovered))
+		c.Abort()
+	}))
+
 	return
 }
 
@@ -217,10 +357,20 @@ func NewGinHttpEngine(options ...engine.ServerEngineOption) *GinHttpEngine {
 	// Create gin engine with the logger
 	e := createGinHttpEngine(l)
 
-	return &GinHttpEngine{
+	ge := &GinHttpEngine{
 		engine:          e,
 		logger:          l,
 		globalApiPrefix: option.GlobalApiPrefix,
+		option:          option,
+		metadata:        engine.RouteMetadataTable{},
 		stopFlag:        make(chan string),
 	}
+
+	// Runs before the middlewares added by the user
+	e.Use(ge.attachMetadata)
+
+	// Unmatched routes (see noRoute)
+	e.NoRoute(ge.noRoute)
+
+	return ge
 }
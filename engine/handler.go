@@ -0,0 +1,267 @@
+// File: handler.go
+//
+// This file defines the engine neutral utilities to run controller handlers.
+// The engines use these to support handlers that return values and interceptors.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/interceptor"
+)
+
+type (
+	// Handler that returns the result value instead of writing the response directly.
+	ValueHandler func(ctx controller.ExecutionContext) (interface{}, error)
+
+	// Metadata of the registered routes by the method and the path in the engine syntax.
+	// Used by the engines to find the metadata of the matched route for the middlewares.
+	RouteMetadataTable map[string]map[string]interface{}
+
+	// Key of the route metadata in the request context (net/http based engines)
+	metadataContextKey struct{}
+)
+
+const (
+	// Key of the route metadata in the native context of the engines. (e.g. gin.Context.Get, fiber.Ctx.Locals)
+	MetadataContextKey = "gimbap.metadata"
+)
+
+var (
+	errorType            = reflect.TypeOf((*error)(nil)).Elem()
+	executionContextType = reflect.TypeOf((*controller.ExecutionContext)(nil)).Elem()
+)
+
+// Cast a handler that returns the result value to a ValueHandler.
+//
+// The following forms are accepted. (T is any type, Req is a struct or a pointer to a struct)
+//
+//   - func(<native context>) (T, error)
+//   - func(<native context>, Req) (T, error)
+//   - func(controller.ExecutionContext) (T, error)
+//   - func(controller.ExecutionContext, Req) (T, error)
+//
+// If the handler has the request parameter, the request is bound with ExecutionContext.Bind before calling the handler.
+// Returns false if the handler does not match the forms.
+func CastToValueHandler(handler interface{}, nativeContextType reflect.Type) (ValueHandler, bool) {
+	// Engine neutral handlers do not need reflection
+	if h, ok := handler.(controller.HandlerFunc); ok {
+		return ValueHandler(h), true
+	}
+
+	handlerType := reflect.TypeOf(handler)
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
+		return nil, false
+	}
+
+	if handlerType.NumIn() == 0 || handlerType.NumIn() > 2 {
+		return nil, false
+	}
+
+	isNeutral := handlerType.In(0) == executionContextType
+	if handlerType.In(0) != nativeContextType && !isNeutral {
+		return nil, false
+	}
+
+	if handlerType.NumOut() != 2 || handlerType.Out(1) != errorType {
+		return nil, false
+	}
+
+	requestType, _ := HandlerTypes(handler)
+	if handlerType.NumIn() == 2 && requestType == nil {
+		return nil, false
+	}
+
+	handlerValue := reflect.ValueOf(handler)
+
+	return func(ctx controller.ExecutionContext) (interface{}, error) {
+		args := []reflect.Value{reflect.ValueOf(ctx.Native())}
+		if isNeutral {
+			args[0] = reflect.ValueOf(&ctx).Elem()
+		}
+
+		// Bind the request to a new value of the request type
+		if requestType != nil {
+			request := reflect.New(requestType)
+			if err := ctx.Bind(request.Interface()); err != nil {
+				// The http exceptions of the binding are kept as they are (e.g. 413 of the body limit)
+				var httpException *exception.HttpException
+				if errors.As(err, &httpException) {
+					return nil, err
+				}
+
+				return nil, exception.BadRequest(err.Error()).WithCause(err)
+			}
+
+			if handlerType.In(1).Kind() == reflect.Ptr {
+				args = append(args, request)
+			} else {
+				args = append(args, request.Elem())
+			}
+		}
+
+		out := handlerValue.Call(args)
+
+		var err error
+		if !out[1].IsNil() {
+			err = out[1].Interface().(error)
+		}
+
+		// Typed nil values are treated as no result
+		switch out[0].Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			if out[0].IsNil() {
+				return nil, err
+			}
+		}
+
+		return out[0].Interface(), err
+	}, true
+}
+
+// Get the request and the response types of the handler.
+//
+// The request type is the struct type of the second parameter (pointers are dereferenced),
+// and the response type is the first return value of the handlers returning (T, error).
+// nil is returned for the types that the handler does not have.
+func HandlerTypes(handler interface{}) (requestType reflect.Type, responseType reflect.Type) {
+	handlerType := reflect.TypeOf(handler)
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
+		return nil, nil
+	}
+
+	if handlerType.NumIn() == 2 {
+		t := handlerType.In(1)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() == reflect.Struct {
+			requestType = t
+		}
+	}
+
+	if handlerType.NumOut() == 2 && handlerType.Out(1) == errorType {
+		responseType = handlerType.Out(0)
+	}
+
+	return
+}
+
+// Get all interceptors of the route. (controller interceptors first, then the route interceptors)
+func (s ControllerSpec) RouteInterceptors(route controller.RouteSpec) []interceptor.IInterceptor {
+	interceptors := make([]interceptor.IInterceptor, 0, len(s.Interceptors)+len(route.Interceptors))
+	interceptors = append(interceptors, s.Interceptors...)
+
+	for _, i := range route.Interceptors {
+		casted, ok := i.(interceptor.IInterceptor)
+		if !ok {
+			panic(fmt.Sprintf("Route interceptor does not implement IInterceptor: %s", reflect.TypeOf(i).String()))
+		}
+
+		interceptors = append(interceptors, casted)
+	}
+
+	return interceptors
+}
+
+// Get the metadata of the route. (controller metadata, overridden by the route metadata)
+func (s ControllerSpec) RouteMetadata(route controller.RouteSpec) map[string]interface{} {
+	if len(s.Metadata) == 0 && len(route.Metadata) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]interface{}, len(s.Metadata)+len(route.Metadata))
+	for k, v := range s.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range route.Metadata {
+		metadata[k] = v
+	}
+
+	return metadata
+}
+
+// Add the metadata of the route. Routes without metadata are not added.
+func (t RouteMetadataTable) Set(method, path string, metadata map[string]interface{}) {
+	if len(metadata) > 0 {
+		t[method+" "+path] = metadata
+	}
+}
+
+// Get the metadata of the route. Returns nil if the route has no metadata.
+func (t RouteMetadataTable) Get(method, path string) map[string]interface{} {
+	return t[method+" "+path]
+}
+
+// Add the route metadata to the request context. Used by the net/http based engines, as the request is their native context.
+func WithRouteMetadata(ctx context.Context, metadata map[string]interface{}) context.Context {
+	return context.WithValue(ctx, metadataContextKey{}, metadata)
+}
+
+// Get the route metadata from the request context. Returns nil if the context does not have the metadata.
+func RouteMetadataFromContext(ctx context.Context) map[string]interface{} {
+	metadata, _ := ctx.Value(metadataContextKey{}).(map[string]interface{})
+	return metadata
+}
+
+// Get all exception filters of the route. (route filters first, then the controller filters)
+func (s ControllerSpec) RouteExceptionFilters(route controller.RouteSpec) []exception.IExceptionFilter {
+	filters := make([]exception.IExceptionFilter, 0, len(s.ExceptionFilters)+len(route.ExceptionFilters))
+
+	for _, f := range route.ExceptionFilters {
+		casted, ok := f.(exception.IExceptionFilter)
+		if !ok {
+			panic(fmt.Sprintf("Route exception filter does not implement IExceptionFilter: %s", reflect.TypeOf(f).String()))
+		}
+
+		filters = append(filters, casted)
+	}
+
+	return append(filters, s.ExceptionFilters...)
+}
+
+// Execute the handler through the interceptors and write the result value to the response.
+//
+// If the response is already written by the handler, the result value is ignored.
+// The results implementing controller.Responder write the response themselves, and the other results are written as JSON.
+// Errors and panics of the handler (and the interceptors) are passed to the exception filters,
+// so the same failure results in the same response regardless of the engine.
+// Requests not matching the parameter constraints of the path are handled as not found before the interceptors run.
+func ExecuteHandler(
+	ctx controller.ExecutionContext,
+	path *RoutePath,
+	interceptors []interceptor.IInterceptor,
+	filters []exception.IExceptionFilter,
+	handler interceptor.CallHandler,
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			exception.Handle(ctx, filters, exception.FromPanic(r))
+		}
+	}()
+
+	if err := path.CheckConstraints(ctx.Param); err != nil {
+		exception.Handle(ctx, filters, err)
+		return
+	}
+
+	result, err := interceptor.Run(ctx, interceptors, handler)
+	if err == nil && result != nil && !ctx.Written() {
+		if responder, ok := result.(controller.Responder); ok {
+			err = responder.Respond(ctx)
+		} else {
+			err = ctx.JSON(http.StatusOK, result)
+		}
+	}
+
+	if err != nil {
+		exception.Handle(ctx, filters, err)
+	}
+}
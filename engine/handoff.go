@@ -0,0 +1,304 @@
+// File: handoff.go
+//
+// This file hands the listeners of the process over to a new process of the executable, for the restarts without dropped connections.
+// The new process gets the sockets as inherited files, and reports that it is ready through a pipe.
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Environment variables of the handoff. (keys of the inherited sockets, and the pipe to report the readiness)
+	handoffKeysEnv = "GIMBAP_HANDOFF_KEYS"
+	readyEnv       = "GIMBAP_READY_FD"
+)
+
+type (
+	// Socket opened by the listeners, to hand over on a restart
+	handoffSocket struct {
+		listener net.Listener
+		conn     net.PacketConn
+
+		// Listener served by the engine, to stop accepting once the new process is ready
+		accepting *handoffListener
+	}
+
+	// Listener that stops accepting once the sockets are handed over, without closing the socket of the new process.
+	// (the connections of the socket are accepted by the new process only)
+	handoffListener struct {
+		net.Listener
+
+		paused    chan struct{}
+		closed    chan struct{}
+		pauseOnce sync.Once
+		closeOnce sync.Once
+	}
+
+	// Socket that can be duplicated as a file (*net.TCPListener, *net.UnixListener, *net.UDPConn)
+	fileSocket interface {
+		File() (*os.File, error)
+	}
+)
+
+var handoff struct {
+	mutex sync.Mutex
+
+	// Sockets opened by this process, by the key of their option
+	opened map[string]handoffSocket
+
+	// Sockets inherited from the parent process, by the key of their option (removed once used)
+	loadOnce  sync.Once
+	inherited map[string]handoffSocket
+	ready     *os.File
+}
+
+// Get the key of the listener to find it in the new process. Empty if the listener cannot be handed over. (given by the user)
+// The order of the listeners with the same key is appended by the Listeners.
+func (o ListenerOption) handoffKey() string {
+	switch {
+	case o.Listener != nil:
+		return ""
+	case o.SocketActivation:
+		return "systemd:" + o.SocketActivationName
+	default:
+		return o.ListenNetwork() + ":" + o.ListenAddress()
+	}
+}
+
+// Keep the socket opened for the key, to hand it over on a restart
+func registerHandoff(key string, socket handoffSocket) {
+	if key == "" {
+		return
+	}
+
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+
+	if handoff.opened == nil {
+		handoff.opened = map[string]handoffSocket{}
+	}
+	handoff.opened[key] = socket
+}
+
+// Forget the sockets of the keys once they are closed
+func unregisterHandoff(keys []string) {
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(handoff.opened, key)
+	}
+}
+
+// Take the socket of the key inherited from the parent process. (nil if none)
+func inheritedSocket(key string) *handoffSocket {
+	handoff.loadOnce.Do(loadHandoff)
+
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+
+	socket, ok := handoff.inherited[key]
+	if !ok {
+		return nil
+	}
+
+	delete(handoff.inherited, key)
+	return &socket
+}
+
+// Load the sockets inherited from the parent process. The environment variables are removed, so the child processes do not inherit them.
+func loadHandoff() {
+	keysEnv, readyFd := os.Getenv(handoffKeysEnv), os.Getenv(readyEnv)
+	os.Unsetenv(handoffKeysEnv)
+	os.Unsetenv(readyEnv)
+
+	if fd, err := strconv.Atoi(readyFd); err == nil {
+		handoff.ready = os.NewFile(uintptr(fd), "gimbap-ready")
+	}
+
+	var keys []string
+	if keysEnv == "" || json.Unmarshal([]byte(keysEnv), &keys) != nil {
+		return
+	}
+
+	handoff.inherited = make(map[string]handoffSocket, len(keys))
+	for i, key := range keys {
+		// The duplicated sockets replace the inherited files, which are closed
+		file := os.NewFile(uintptr(listenFdsStart+i), key)
+
+		var socket handoffSocket
+		var err error
+		if strings.HasPrefix(key, "udp:") {
+			socket.conn, err = net.FilePacketConn(file)
+		} else {
+			socket.listener, err = net.FileListener(file)
+		}
+		file.Close()
+
+		if err == nil {
+			handoff.inherited[key] = socket
+		}
+	}
+}
+
+// Report to the parent process that this process serves the requests. (e.g. so the parent of Restart drains and exits)
+//
+// The inherited sockets not used by this process are closed. Does nothing if the process was not started by Restart or StartProcess.
+func NotifyReady() {
+	handoff.loadOnce.Do(loadHandoff)
+
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+
+	for key, socket := range handoff.inherited {
+		socket.close()
+		delete(handoff.inherited, key)
+	}
+
+	if handoff.ready != nil {
+		handoff.ready.Write([]byte("ready"))
+		handoff.ready.Close()
+		handoff.ready = nil
+	}
+}
+
+// Start a new process of the executable (with the same arguments) that inherits the listeners of this process,
+// and wait until it reports that it is ready. (NotifyReady)
+//
+// The new process is killed if it is not ready within the timeout. Once the new process is ready, this process stops accepting
+// the connections, but its listeners must still be closed by stopping the engine. (the new process keeps its copies of the sockets)
+func Restart(timeout time.Duration) (*Process, error) {
+	keys, files, err := handoffFiles()
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no listener to hand over")
+	}
+
+	encodedKeys, _ := json.Marshal(keys)
+	process, err := startProcess([]string{handoffKeysEnv + "=" + string(encodedKeys)}, files)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-process.Ready():
+		stopAccepting()
+		return process, nil
+	case <-process.Done():
+		return nil, fmt.Errorf("the new process exited before it was ready: %v", process.Err())
+	case <-time.After(timeout):
+		process.Kill()
+		return nil, fmt.Errorf("the new process was not ready within %s", timeout)
+	}
+}
+
+// Get the files of the sockets opened by this process, with their keys
+func handoffFiles() (keys []string, files []*os.File, err error) {
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+
+	for key, socket := range handoff.opened {
+		var s interface{} = socket.listener
+		if socket.conn != nil {
+			s = socket.conn
+		}
+
+		fs, ok := s.(fileSocket)
+		if !ok {
+			continue
+		}
+
+		file, err := fs.File()
+		if err != nil {
+			return keys, files, fmt.Errorf("failed to hand over the socket %s: %w", key, err)
+		}
+
+		keys = append(keys, key)
+		files = append(files, file)
+	}
+
+	return keys, files, nil
+}
+
+// Stop accepting the connections on the sockets handed over to the new process.
+// The socket files of the Unix domain sockets are kept, as the new process serves them.
+func stopAccepting() {
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+
+	for _, socket := range handoff.opened {
+		if ln, ok := socket.listener.(*net.UnixListener); ok {
+			ln.SetUnlinkOnClose(false)
+		}
+		if socket.accepting != nil {
+			socket.accepting.pause()
+		}
+	}
+}
+
+func newHandoffListener(ln net.Listener) *handoffListener {
+	return &handoffListener{Listener: ln, paused: make(chan struct{}), closed: make(chan struct{})}
+}
+
+// Accept the connections until the listener is paused, then block until it is closed. (as the server stops on the errors of Accept)
+func (l *handoffListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		select {
+		case <-l.paused:
+			<-l.closed
+			return nil, net.ErrClosed
+		default:
+		}
+	}
+
+	return conn, err
+}
+
+// Close the descriptor of this process. The socket stays open in the new process.
+func (l *handoffListener) pause() {
+	l.pauseOnce.Do(func() {
+		close(l.paused)
+		l.Listener.Close()
+	})
+}
+
+func (l *handoffListener) Close() (err error) {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+
+		// The socket is closed already if paused
+		select {
+		case <-l.paused:
+		default:
+			err = l.Listener.Close()
+		}
+	})
+
+	return err
+}
+
+func (s handoffSocket) close() {
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
@@ -0,0 +1,92 @@
+// File: binding.go
+//
+// Binding of the requests for the net/http based engines, as net/http does not have a binder.
+package nethttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/exception"
+)
+
+// Max memory of the multipart forms kept in memory while binding (the rest is stored in temporary files)
+const maxMultipartMemory = 32 << 20
+
+// Bind the request to the value.
+//
+// The JSON body ("json" tag) or the form body ("form" tag) is bound first,
+// then the path parameters, the query and the headers with the engine neutral tags. (see engine.BindParameters)
+func Bind(r *http.Request, v interface{}, param func(name string) string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("bind target must be a non-nil pointer: %T", v)
+	}
+
+	// Body first, so the parameters are not overwritten by the body fields
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case r.ContentLength == 0:
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+			return bodyError("Invalid JSON body", err)
+		}
+	case contentType == "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return bodyError("Invalid form body", err)
+		}
+	case contentType == "multipart/form-data":
+		if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return bodyError("Invalid form body", err)
+		}
+	}
+
+	if r.PostForm != nil && rv.Elem().Kind() == reflect.Struct {
+		err := engine.BindFields(rv.Elem(), func(f reflect.StructField) ([]string, bool) {
+			name := engine.TagName(f, "form")
+			values, ok := r.PostForm[name]
+			return values, ok && name != ""
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	query := r.URL.Query()
+
+	return engine.BindParameters(v, engine.BindSource{
+		Param: param,
+		Query: func(name string) []string { return query[name] },
+		Header: func(name string) []string {
+			return r.Header[http.CanonicalHeaderKey(name)]
+		},
+	})
+}
+
+// Error of a body that failed to read. 413 if the body exceeds the limit of the server (see LimitRequestBody), 400 otherwise.
+func bodyError(message string, err error) error {
+	if tooLarge := BodyLimitError(err); tooLarge != err {
+		return tooLarge
+	}
+
+	return exception.BadRequest(message).WithCause(err)
+}
+
+// Convert the error of a body exceeding the limit of the server to 413 Payload Too Large. The other errors are returned as they are.
+//
+// Used by the engines binding the body with the binders of the framework, which report the limit as a bind error.
+func BodyLimitError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return exception.PayloadTooLarge(fmt.Sprintf("The request body exceeds %d bytes", tooLarge.Limit)).WithCause(err)
+	}
+
+	return err
+}
@@ -0,0 +1,218 @@
+// File: engine.go
+//
+// Base of the server engines routing the requests with a net/http router. (net/http, chi)
+package nethttp
+
+import (
+	"net"
+	"net/http"
+	"reflect"
+
+	"github.com/jhseong7/ecl"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+)
+
+type (
+	// Base of the server engines routing the requests with a net/http router.
+	//
+	// Runs the server, and keeps the global middlewares, the metadata of the routes and the static files.
+	// The engines embed it with their router, and implement the registration of the routes, the mounts and the static directories.
+	Engine struct {
+		Logger ecl.Logger
+
+		// Limits and timeouts of the server
+		Option engine.ServerEngineOption
+
+		// Routing of the requests, run inside the global middlewares of the engine. (set by the engine)
+		// Returns the handler of the router, and if the global middlewares are already chained inside the router.
+		Router func() (router http.Handler, chained bool)
+
+		// Metadata of the route matching the request (set by the engine)
+		MetadataOf func(r *http.Request) map[string]interface{}
+
+		// Key of the catch-all parameter in the path values of the router. (the name of the parameter if empty)
+		CatchAllKey string
+
+		// Global middlewares
+		Middlewares []Middleware
+
+		// Set once the handler is built. The middlewares cannot be added after. (see AddMiddleware)
+		built bool
+
+		// Metadata of the registered routes by the method and the path pattern of the router
+		Metadata engine.RouteMetadataTable
+
+		// Static files of the StaticOption, served when no route matches
+		Statics engine.Statics
+
+		validator    Validator
+		pathRewriter engine.PathRewriter
+
+		// Server of Run
+		server *http.Server
+
+		// Address of the listener (set once the server listens)
+		address engine.ServerAddress
+
+		// Requests served by the server of Run
+		requests engine.RequestCounter
+
+		// server stop flag
+		stopFlag chan string
+	}
+)
+
+// Check if the middleware is valid and cast it to func(http.Handler) http.Handler.
+func (e *Engine) checkAndCastToMiddleware(m interface{}) Middleware {
+	casted, ok := ToMiddleware(m)
+	if !ok {
+		e.Logger.Panicf("Middleware must be func(http.Handler) http.Handler: %s", reflect.TypeOf(m).String())
+	}
+
+	return casted
+}
+
+// Cast the middlewares to func(http.Handler) http.Handler
+func (e *Engine) CastMiddlewares(middlewares []interface{}) []Middleware {
+	casted := make([]Middleware, 0, len(middlewares))
+	for _, m := range middlewares {
+		casted = append(casted, e.checkAndCastToMiddleware(m))
+	}
+
+	return casted
+}
+
+// Create the handler of the route.
+func (e *Engine) RouteHandler(spec engine.ControllerSpec, routeSpec controller.RouteSpec, fullPath string) http.Handler {
+	path, _ := engine.ParsePath(fullPath) // Validated by the translation
+
+	handler, ok := RouteHandler(spec, routeSpec, &Route{
+		ControllerName: spec.Name,
+		Spec:           routeSpec,
+		RoutePath:      fullPath,
+		Path:           path,
+		Metadata:       spec.RouteMetadata(routeSpec),
+		Validator:      e.validator,
+		CatchAllKey:    e.CatchAllKey,
+	})
+	if !ok {
+		e.Logger.Panicf("Handler must be http.HandlerFunc, func(*http.Request[, Req]) (T, error) or controller.HandlerFunc: %s", reflect.TypeOf(routeSpec.Handler).String())
+	}
+
+	return handler
+}
+
+// Add middleware to the engine.
+//
+// The middlewares are chained when the handler is built by Run or Handler, so they must be added before.
+func (e *Engine) AddMiddleware(middlewares ...interface{}) {
+	if e.built {
+		e.Logger.Panic("The middlewares must be added before Run or Handler. The handler of the engine is already built")
+	}
+	e.Middlewares = append(e.Middlewares, e.CastMiddlewares(middlewares)...)
+}
+
+// Add the static files of the StaticOption, served when no route matches. (so a static path on / does not conflict with the routes)
+//
+// Returns false if the config is not a StaticOption, for the engine to serve the directory with its router.
+func (e *Engine) AddStaticFiles(prefix, root string, config []interface{}) bool {
+	option, ok := engine.StaticOptionOf(config)
+	if !ok {
+		return false
+	}
+
+	files, err := engine.NewStaticFiles(prefix, root, option)
+	if err != nil {
+		e.Logger.Panicf("Failed to add static files to the engine: %s", err)
+	}
+	e.Statics = e.Statics.Add(files)
+
+	return true
+}
+
+func (e *Engine) Run(option engine.ServerRuntimeOption) {
+	// Send the stop flag (if the server stops)
+	defer func() { e.stopFlag <- "stopped" }()
+
+	// Create an http server with the limits of the engine option
+	e.server = NewServer(e.Option, e.requests.Handler(e.Handler()))
+
+	// Create the listeners (host and port, unix socket, given listener or systemd socket, with TLS and the redirects to https)
+	listeners, err := engine.OpenListeners(option)
+	if err != nil {
+		e.Logger.Fatalf("Failed to create the listeners: %s", err)
+	}
+	listeners.Log(e.Logger.Logf)
+
+	// The connections are accepted from here (queued until the server starts)
+	e.address.Ready(option, listeners.Addr())
+	defer e.address.Set(nil)
+
+	// Start the server (blocking)
+	if err := listeners.ServeHTTP(e.server, e.Option.GetShutdownTimeout()); err != nil && err != http.ErrServerClosed {
+		e.Logger.Fatalf("Failed to start the http engine: %s", err)
+	}
+}
+
+func (e *Engine) Stop() {
+	timeout := e.Option.GetShutdownTimeout()
+	e.Logger.Logf("Stopping the http engine (Max %s)", timeout)
+
+	if err := Shutdown(e.server, timeout, e.stopFlag); err != nil {
+		e.Logger.Warnf("Server failed to shutdown gracefully within %s, the remaining connections are closed: %s", timeout, err)
+		return
+	}
+
+	e.Logger.Log("Server stopped gracefully")
+}
+
+// Get the address the server listens on. (nil if not running)
+func (e *Engine) Addr() net.Addr {
+	return e.address.Get()
+}
+
+// Get the counts of the requests served by Run
+func (e *Engine) Requests() engine.RequestStats {
+	return e.requests.Stats()
+}
+
+func (e *Engine) GetGlobalApiPrefix() string {
+	return e.Option.GlobalApiPrefix
+}
+
+func (e *Engine) SetPathRewriter(rewriter engine.PathRewriter) {
+	e.pathRewriter = rewriter
+}
+
+// Get the engine as an http.Handler. (same as the handler of the server started by Run)
+func (e *Engine) Handler() http.Handler {
+	e.built = true
+
+	router, chained := e.Router()
+	if !chained {
+		router = Chain(router, e.Middlewares)
+	}
+
+	return ServerHandler(router, e.pathRewriter, e.metadataOf, e.Logger.Logf)
+}
+
+// Get the metadata of the route matching the request. (no lookup if no route has metadata)
+func (e *Engine) metadataOf(r *http.Request) map[string]interface{} {
+	if len(e.Metadata) == 0 {
+		return nil
+	}
+
+	return e.MetadataOf(r)
+}
+
+// Create the base of an engine. The engine sets the router. (see Router and MetadataOf)
+func NewEngine(name string, option engine.ServerEngineOption, validator Validator) *Engine {
+	return &Engine{
+		Logger:    ecl.NewLogger(ecl.LoggerOption{Name: name}),
+		Option:    option,
+		Metadata:  engine.RouteMetadataTable{},
+		validator: validator,
+		stopFlag:  make(chan string),
+	}
+}
@@ -0,0 +1,169 @@
+// File: execution-context.go
+//
+// The execution context and the response writer shared by the net/http based engines. (net/http, chi)
+package nethttp
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+)
+
+type (
+	// Validator of the bound requests (same as the echo.Validator interface)
+	Validator interface {
+		Validate(i interface{}) error
+	}
+
+	// Route of the execution context. Shared by all requests of the route.
+	Route struct {
+		ControllerName string
+		Spec           controller.RouteSpec
+		RoutePath      string
+		Path           *engine.RoutePath // Parsed route path (nil outside of the routes)
+		Metadata       map[string]interface{}
+		Validator      Validator
+
+		// Key of the catch-all parameter in the path values of the request, if it differs from the name. (e.g. "*" on chi)
+		CatchAllKey string
+	}
+
+	// net/http implementation of the controller.ExecutionContext
+	ExecutionContext struct {
+		controller.ExecutionContext
+
+		w     *ResponseWriter
+		r     *http.Request
+		route *Route
+	}
+
+	// Response writer that records whether the response has been written.
+	ResponseWriter struct {
+		http.ResponseWriter
+
+		status  int
+		written bool
+	}
+
+	// Records the status written by a handler, discarding the body.
+	StatusRecorder struct {
+		header http.Header
+		status int
+	}
+)
+
+// Route of the requests outside of the routes (e.g. unmatched routes)
+var noRoute = &Route{}
+
+// Create the execution context of the request. The route can be nil for the requests outside of the routes.
+func NewExecutionContext(w http.ResponseWriter, r *http.Request, route *Route) *ExecutionContext {
+	if route == nil {
+		route = noRoute
+	}
+
+	return &ExecutionContext{w: WrapResponseWriter(w), r: r, route: route}
+}
+
+// Wrap the response writer. Returns the writer itself if it is already wrapped.
+func WrapResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+
+	return &ResponseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status of the response (200 if not written)
+func (w *ResponseWriter) Status() int { return w.status }
+
+func (w *ResponseWriter) WriteHeader(status int) {
+	if !w.written {
+		w.status = status
+		w.written = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush the response if the underlying writer supports it. (e.g. streaming responses)
+func (w *ResponseWriter) Flush() {
+	w.written = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Get the underlying writer. (used by http.ResponseController)
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Create a recorder sharing the header of the response writer.
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{header: w.Header()}
+}
+
+func (r *StatusRecorder) Status() int                 { return r.status }
+func (r *StatusRecorder) Header() http.Header         { return r.header }
+func (r *StatusRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (r *StatusRecorder) WriteHeader(status int)      { r.status = status }
+
+// The request is the native context of net/http.
+func (c *ExecutionContext) Native() interface{}              { return c.r }
+func (c *ExecutionContext) ControllerName() string           { return c.route.ControllerName }
+func (c *ExecutionContext) Route() controller.RouteSpec      { return c.route.Spec }
+func (c *ExecutionContext) Metadata() map[string]interface{} { return c.route.Metadata }
+func (c *ExecutionContext) RoutePath() string                { return c.route.RoutePath }
+func (c *ExecutionContext) Method() string                   { return c.r.Method }
+func (c *ExecutionContext) Path() string                     { return c.r.URL.Path }
+func (c *ExecutionContext) Header(key string) string         { return c.r.Header.Get(key) }
+func (c *ExecutionContext) TLS() *tls.ConnectionState        { return c.r.TLS }
+func (c *ExecutionContext) SetHeader(key, value string)      { c.w.Header().Set(key, value) }
+func (c *ExecutionContext) Written() bool                    { return c.w.written }
+func (c *ExecutionContext) JSON(status int, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(status, "application/json; charset=utf-8", data)
+}
+
+func (c *ExecutionContext) Blob(status int, contentType string, data []byte) error {
+	c.w.Header().Set("Content-Type", contentType)
+	c.w.WriteHeader(status)
+	_, err := c.w.Write(data)
+	return err
+}
+
+func (c *ExecutionContext) Stream(status int, contentType string, stream func(w controller.StreamWriter)) error {
+	return Stream(c.w, c.r, status, contentType, stream)
+}
+
+// Get the path parameter from the path values of the request.
+func (c *ExecutionContext) Param(name string) string {
+	if c.route.CatchAllKey != "" && name != "" && name == c.route.Path.CatchAll() {
+		name = c.route.CatchAllKey
+	}
+
+	return c.r.PathValue(name)
+}
+
+// Bind the path parameters, query, headers and body. Validates the value if a validator is given to the engine.
+func (c *ExecutionContext) Bind(v interface{}) error {
+	if err := Bind(c.r, v, c.Param); err != nil {
+		return err
+	}
+
+	if c.route.Validator != nil {
+		return c.route.Validator.Validate(v)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+// File: handler.go
+//
+// Handlers and middlewares of the net/http based engines.
+package nethttp
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/middleware"
+)
+
+type (
+	// Native middleware of net/http
+	Middleware = func(http.Handler) http.Handler
+)
+
+// Native context type of the value handlers. (func(*http.Request[, Req]) (T, error))
+var RequestType = reflect.TypeOf(&http.Request{})
+
+// Cast the middleware (or a middleware instance) to func(http.Handler) http.Handler. Returns false if it is not a net/http middleware.
+func ToMiddleware(m interface{}) (Middleware, bool) {
+	casted, ok := middleware.ToNative(m).(func(http.Handler) http.Handler)
+	return casted, ok
+}
+
+// Wrap the handler with the middlewares. The first middleware runs first.
+func Chain(handler http.Handler, middlewares []Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// Get the native handler of the route. Returns false if the handler is not a net/http handler.
+func ToNativeHandler(handler interface{}) (http.Handler, bool) {
+	switch h := handler.(type) {
+	case http.HandlerFunc:
+		return h, true
+	case func(http.ResponseWriter, *http.Request):
+		return http.HandlerFunc(h), true
+	case http.Handler:
+		return h, true
+	}
+
+	return nil, false
+}
+
+// Create the handler of the route.
+//
+// The handler is wrapped to run through the interceptors, and the result value is written as the response.
+// Errors and panics are handled by the exception filters. Returns false if the handler is not supported.
+func RouteHandler(spec engine.ControllerSpec, routeSpec controller.RouteSpec, route *Route) (http.Handler, bool) {
+	interceptors := spec.RouteInterceptors(routeSpec)
+	filters := spec.RouteExceptionFilters(routeSpec)
+
+	nativeHandler, isNative := ToNativeHandler(routeSpec.Handler)
+	valueHandler, isValue := engine.CastToValueHandler(routeSpec.Handler, RequestType)
+
+	if !isNative && !isValue {
+		return nil, false
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewExecutionContext(w, r, route)
+
+		engine.ExecuteHandler(ctx, route.Path, interceptors, filters, func() (interface{}, error) {
+			if isNative {
+				nativeHandler.ServeHTTP(ctx.w, r)
+				return nil, nil
+			}
+
+			return valueHandler(ctx)
+		})
+	}), true
+}
+
+// Write the error of the requests outside of the routes in the same format as the exception filters.
+func HandleError(w http.ResponseWriter, r *http.Request, err error) {
+	exception.Handle(NewExecutionContext(w, r, nil), nil, err)
+}
+
+// Create the outermost handler of the engine.
+//
+// The path is rewritten and the metadata of the matched route is attached to the request before the handler,
+// as the middlewares of net/http run before the routing. Panics outside the routes are recovered and the requests are logged.
+func ServerHandler(
+	handler http.Handler,
+	rewriter engine.PathRewriter,
+	metadataOf func(r *http.Request) map[string]interface{},
+	log func(format string, args ...interface{}),
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := WrapResponseWriter(w)
+
+		defer func() {
+			// Panics outside the routes (e.g. middlewares) are written in the same format as the exception filters
+			if recovered := recover(); recovered != nil {
+				HandleError(rw, r, exception.FromPanic(recovered))
+			}
+
+			log("%s %s --> %d (%s)", r.Method, r.URL.Path, rw.status, time.Since(start))
+		}()
+
+		if rewriter != nil {
+			if path := rewriter(r.Method, r.URL.Path, r.Header.Get); path != r.URL.Path {
+				r.URL.Path = path
+				r.URL.RawPath = ""
+			}
+		}
+
+		if metadata := metadataOf(r); metadata != nil {
+			r = r.WithContext(engine.WithRouteMetadata(r.Context(), metadata))
+		}
+
+		handler.ServeHTTP(rw, r)
+	})
+}
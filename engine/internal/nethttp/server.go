@@ -0,0 +1,62 @@
+// File: server.go
+//
+// This file creates the http servers of the engines based on net/http with the limits of the engine option, and stops them.
+package nethttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/exception"
+)
+
+// Create the http server of the engine with the timeouts and the size limits of the option.
+// The streamed responses end when the server shuts down.
+func NewServer(option engine.ServerEngineOption, handler http.Handler) *http.Server {
+	if option.MaxBodyBytes > 0 {
+		handler = LimitRequestBody(handler, option.MaxBodyBytes)
+	}
+
+	return withShutdownContext(&http.Server{
+		Handler:           handler,
+		ReadTimeout:       option.ReadTimeout,
+		ReadHeaderTimeout: option.ReadHeaderTimeout,
+		WriteTimeout:      option.WriteTimeout,
+		IdleTimeout:       option.IdleTimeout,
+		MaxHeaderBytes:    option.MaxHeaderBytes,
+	})
+}
+
+// Reject the requests with a body larger than the limit with 413 Payload Too Large.
+//
+// The bodies without a length (chunked) fail to read after the limit instead.
+func LimitRequestBody(handler http.Handler, limit int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			HandleError(w, r, exception.PayloadTooLarge(fmt.Sprintf("The request body exceeds %d bytes", limit)))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Stop the server gracefully within the timeout, then wait until Run returns. (the stopped channel)
+//
+// The connections still open after the timeout are closed, and the error of the shutdown is returned.
+func Shutdown(server *http.Server, timeout time.Duration, stopped <-chan string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		server.Close()
+	}
+
+	<-stopped
+	return err
+}
@@ -0,0 +1,86 @@
+// File: stream.go
+//
+// The streamed responses of the engines based on net/http. (ExecutionContext.Stream)
+package nethttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jhseong7/gimbap/controller"
+)
+
+type (
+	// Writer of the streamed responses, flushed with http.ResponseController
+	streamWriter struct {
+		w          http.ResponseWriter
+		controller *http.ResponseController
+		request    *http.Request
+		done       chan struct{}
+	}
+
+	// Key of the channel closed when the server shuts down, in the base context of the server
+	shutdownContextKey struct{}
+)
+
+// Set the base context of the server with the channel closed when the server shuts down,
+// so the streams end on the shutdown instead of being cut off by the shutdown timeout.
+func withShutdownContext(server *http.Server) *http.Server {
+	shutdown := make(chan struct{})
+	server.RegisterOnShutdown(func() { close(shutdown) })
+
+	ctx := context.WithValue(context.Background(), shutdownContextKey{}, (<-chan struct{})(shutdown))
+	server.BaseContext = func(_ net.Listener) context.Context { return ctx }
+
+	return server
+}
+
+// Write the headers of the streamed response, then run the stream function until it returns.
+//
+// The write deadline of the server is cleared, as the streams are not limited by the write timeout.
+func Stream(w http.ResponseWriter, r *http.Request, status int, contentType string, stream func(w controller.StreamWriter)) error {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	writer := &streamWriter{w: w, controller: http.NewResponseController(w), request: r, done: make(chan struct{})}
+	writer.controller.SetWriteDeadline(time.Time{})
+	if err := writer.controller.Flush(); err != nil {
+		return err
+	}
+
+	// Done is closed by the end of the request (disconnection) or the shutdown of the server
+	finished := make(chan struct{})
+	defer close(finished)
+
+	shutdown, _ := r.Context().Value(shutdownContextKey{}).(<-chan struct{})
+	go func() {
+		defer close(writer.done)
+
+		select {
+		case <-r.Context().Done():
+		case <-shutdown:
+		case <-finished:
+		}
+	}()
+
+	stream(writer)
+	return nil
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	return w.w.Write(b)
+}
+
+func (w *streamWriter) Flush() error {
+	if err := w.request.Context().Err(); err != nil {
+		return err
+	}
+
+	return w.controller.Flush()
+}
+
+func (w *streamWriter) Done() <-chan struct{} {
+	return w.done
+}
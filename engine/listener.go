@@ -0,0 +1,511 @@
+// File: listener.go
+//
+// This file creates the listeners of the engines from the runtime option, so all engines bind the same way.
+// (host and port, Unix domain sockets, pre-created listeners, systemd socket activation, TLS, the redirect to https and the UDP sockets of HTTP/3)
+// The sockets inherited from a parent process (see Restart) are used instead of new sockets.
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// Port of the app when no runtime option is given. (Port 0 of the runtime option is an ephemeral port)
+	DefaultPort = 8080
+
+	// First file descriptor passed by systemd socket activation (SD_LISTEN_FDS_START)
+	listenFdsStart = 3
+)
+
+type (
+	// Listeners of a server, created from the runtime option by OpenListeners
+	Listeners struct {
+		// Listeners serving the app. (wrapped with TLS if the listener has a TLS option)
+		App []net.Listener
+		tls []bool
+
+		// Listeners redirecting the requests to https, and their servers
+		redirects       []net.Listener
+		redirectServers []*http.Server
+
+		// UDP sockets of HTTP/3 on the ports of the TLS listeners, and their TLS configs
+		quic      []net.PacketConn
+		quicTLS   []*tls.Config
+		protocols ProtocolOption
+
+		// Keys of the sockets to hand over on a restart, and the number of listeners of each option key
+		handoffKeys  []string
+		handoffCount map[string]int
+	}
+
+	// Address of the listener of a running server. Safe for concurrent use. (nil if the server is not listening)
+	ServerAddress struct {
+		mutex sync.RWMutex
+		addr  net.Addr
+	}
+
+	// Listener passed by systemd socket activation
+	systemdListener struct {
+		name     string
+		listener net.Listener
+		used     bool
+	}
+)
+
+var systemd struct {
+	once      sync.Once
+	mutex     sync.Mutex
+	listeners []*systemdListener
+	err       error
+}
+
+// Get the options of all listeners of the server. (the Listeners, or a single listener of the address options)
+func (o ServerRuntimeOption) ListenerOptions() []ListenerOption {
+	if len(o.Listeners) > 0 {
+		return o.Listeners
+	}
+
+	return []ListenerOption{{
+		Port:                 o.Port,
+		Host:                 o.Host,
+		Network:              o.Network,
+		SocketPath:           o.SocketPath,
+		Listener:             o.Listener,
+		SocketActivation:     o.SocketActivation,
+		SocketActivationName: o.SocketActivationName,
+		TLSOption:            o.TLSOption,
+	}}
+}
+
+// Get the network of the listener. (tcp by default, unix if the socket path is set)
+func (o ListenerOption) ListenNetwork() string {
+	switch {
+	case o.Network != "":
+		return o.Network
+	case o.SocketPath != "":
+		return "unix"
+	default:
+		return "tcp"
+	}
+}
+
+// Get the address to listen on. (host:port or the socket path)
+func (o ListenerOption) ListenAddress() string {
+	if strings.HasPrefix(o.ListenNetwork(), "unix") {
+		return o.SocketPath
+	}
+
+	return net.JoinHostPort(o.Host, strconv.Itoa(o.Port))
+}
+
+// Set the address once the server listens, and call the OnReady callback of the option.
+func (a *ServerAddress) Ready(option ServerRuntimeOption, addr net.Addr) {
+	a.Set(addr)
+
+	if option.OnReady != nil {
+		option.OnReady(addr)
+	}
+}
+
+func (a *ServerAddress) Set(addr net.Addr) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.addr = addr
+}
+
+func (a *ServerAddress) Get() net.Addr {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.addr
+}
+
+// Create the listener of the option. (without TLS)
+//
+// The given listener is used as is, then the socket of systemd (if enabled), then a new listener on the network and the address.
+func Listen(option ListenerOption) (net.Listener, error) {
+	if option.Listener != nil {
+		return option.Listener, nil
+	}
+
+	if option.SocketActivation {
+		return SystemdListener(option.SocketActivationName)
+	}
+
+	network, address := option.ListenNetwork(), option.ListenAddress()
+	config := net.ListenConfig{}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		if option.ReusePort {
+			config.Control = reusePort
+		}
+	case "unix":
+		if option.ReusePort {
+			return nil, errors.New("SO_REUSEPORT is supported on TCP only. Listen on a port to share it between processes")
+		}
+
+		if address == "" {
+			return nil, errors.New("the socket path is required to listen on a Unix domain socket")
+		}
+
+		if err := removeStaleSocket(address); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported network: %s. Must be one of (tcp, tcp4, tcp6, unix)", network)
+	}
+
+	return config.Listen(context.Background(), network, address)
+}
+
+// Remove the socket file left by a previous run. (the socket is removed on close, but not if the process crashed)
+//
+// Only a socket refusing the connections is removed. A socket in use by another process and the other files are kept.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to check the socket %s: %w", path, err)
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket. Remove it or listen on another socket path", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("the socket %s is in use by another process", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to check the socket %s: %w", path, err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove the stale socket %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Create all listeners of the runtime option. The listeners with a TLS option are wrapped with TLS.
+//
+// The listeners created before an error are closed.
+func OpenListeners(option ServerRuntimeOption) (listeners *Listeners, err error) {
+	listeners = &Listeners{protocols: option.Protocols, handoffCount: map[string]int{}}
+	defer func() {
+		if err != nil {
+			listeners.Close()
+		}
+	}()
+
+	// The app listeners first, as the redirects go to the port of the first TLS listener
+	// The options are copied, as the listeners of the runtime option belong to the caller (and may be shared by several engines)
+	options := slices.Clone(option.ListenerOptions())
+	for i := range options {
+		options[i].ReusePort = options[i].ReusePort || option.ReusePort
+	}
+
+	redirectPort := 0
+	for _, o := range options {
+		if o.RedirectToHTTPS {
+			continue
+		}
+
+		ln, err := listeners.listen(o)
+		if err != nil {
+			return listeners, err
+		}
+
+		if o.TLSOption != nil {
+			config, err := o.TLSOption.TLSConfig()
+			if err != nil {
+				ln.Close()
+				return listeners, err
+			}
+
+			ln = tls.NewListener(ln, config)
+			if addr, ok := ln.Addr().(*net.TCPAddr); ok && redirectPort == 0 {
+				redirectPort = addr.Port
+			}
+
+			// HTTP/3 on the same port of UDP (not for Unix domain sockets)
+			if addr, ok := ln.Addr().(*net.TCPAddr); ok && option.Protocols.HTTP3 {
+				conn, err := listeners.listenPacket(addr.String(), o.ReusePort)
+				if err != nil {
+					ln.Close()
+					return listeners, fmt.Errorf("failed to listen on %s for HTTP/3: %w", addr, err)
+				}
+
+				listeners.quic = append(listeners.quic, conn)
+				listeners.quicTLS = append(listeners.quicTLS, config)
+			}
+		}
+
+		listeners.App = append(listeners.App, ln)
+		listeners.tls = append(listeners.tls, o.TLSOption != nil)
+	}
+
+	if len(listeners.App) == 0 {
+		return listeners, errors.New("at least one listener must serve the app (all listeners redirect to https)")
+	}
+	if option.Protocols.HTTP3 && len(listeners.quic) == 0 {
+		return listeners, errors.New("HTTP/3 requires a TLS listener on TCP. Set the TLSOption of the listener")
+	}
+
+	for _, o := range options {
+		if !o.RedirectToHTTPS {
+			continue
+		}
+
+		port := o.RedirectPort
+		if port == 0 {
+			port = redirectPort
+		}
+		if port == 0 {
+			return listeners, errors.New("no TLS listener to redirect to. Set the RedirectPort of the listener")
+		}
+
+		ln, err := listeners.listen(o)
+		if err != nil {
+			return listeners, err
+		}
+
+		// The HTTP-01 challenges of ACME are answered before the redirect
+		handler := RedirectHandler(port)
+		for _, app := range options {
+			if app.TLSOption != nil && app.TLSOption.ACME != nil && !app.RedirectToHTTPS {
+				handler = app.TLSOption.ACME.HTTPHandler(handler)
+				break
+			}
+		}
+
+		listeners.redirects = append(listeners.redirects, ln)
+		listeners.redirectServers = append(listeners.redirectServers, &http.Server{Handler: handler})
+	}
+
+	return listeners, nil
+}
+
+// Create the listener of the option, or take the socket of the option inherited from the parent process.
+// The listener is kept to hand it over on a restart.
+func (l *Listeners) listen(option ListenerOption) (net.Listener, error) {
+	key := option.handoffKey()
+	if key == "" {
+		return Listen(option)
+	}
+
+	// Several listeners of the same option (e.g. port 0) are told apart by their order
+	l.handoffCount[key]++
+	key = fmt.Sprintf("%s#%d", key, l.handoffCount[key])
+
+	ln, err := net.Listener(nil), error(nil)
+	if socket := inheritedSocket(key); socket != nil && socket.listener != nil {
+		ln = socket.listener
+	} else if ln, err = Listen(option); err != nil {
+		return nil, err
+	}
+
+	accepting := newHandoffListener(ln)
+	l.register(key, handoffSocket{listener: ln, accepting: accepting})
+
+	return accepting, nil
+}
+
+// Create the UDP socket of HTTP/3 on the address, or take the socket inherited from the parent process.
+func (l *Listeners) listenPacket(address string, reuse bool) (net.PacketConn, error) {
+	key := "udp:" + address
+	if socket := inheritedSocket(key); socket != nil && socket.conn != nil {
+		l.register(key, handoffSocket{conn: socket.conn})
+		return socket.conn, nil
+	}
+
+	config := net.ListenConfig{}
+	if reuse {
+		config.Control = reusePort
+	}
+
+	conn, err := config.ListenPacket(context.Background(), "udp", address)
+	if err == nil {
+		l.register(key, handoffSocket{conn: conn})
+	}
+
+	return conn, err
+}
+
+func (l *Listeners) register(key string, socket handoffSocket) {
+	registerHandoff(key, socket)
+	l.handoffKeys = append(l.handoffKeys, key)
+}
+
+// Get the address of the first app listener
+func (l *Listeners) Addr() net.Addr {
+	return l.App[0].Addr()
+}
+
+// Log the addresses of the listeners
+func (l *Listeners) Log(logf func(format string, args ...interface{})) {
+	for i, ln := range l.App {
+		switch {
+		case l.tls[i]:
+			logf("Starting the http engine with TLS on %s", ln.Addr())
+		case l.protocols.H2C:
+			logf("Starting the http engine with h2c on %s", ln.Addr())
+		default:
+			logf("Starting the http engine on %s", ln.Addr())
+		}
+	}
+
+	for _, conn := range l.quic {
+		logf("Starting the http engine with HTTP/3 on udp %s", conn.LocalAddr())
+	}
+
+	for _, ln := range l.redirects {
+		logf("Redirecting the http requests on %s to https", ln.Addr())
+	}
+}
+
+// Serve the app listeners with the serve function of the engine, and the redirect listeners with the redirect servers.
+//
+// Blocks until all app listeners are closed (e.g. by the shutdown of the engine), then closes the redirect servers.
+// Returns the first error of the serve function.
+func (l *Listeners) Serve(serve func(ln net.Listener) error) error {
+	for i, ln := range l.redirects {
+		go l.redirectServers[i].Serve(ln)
+	}
+	defer func() {
+		for _, server := range l.redirectServers {
+			server.Close()
+		}
+		unregisterHandoff(l.handoffKeys)
+	}()
+
+	errs := make(chan error, len(l.App))
+	for _, ln := range l.App {
+		go func(ln net.Listener) { errs <- serve(ln) }(ln)
+	}
+
+	var first error
+	for range l.App {
+		if err := <-errs; first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// Close all listeners (used if the server does not start)
+func (l *Listeners) Close() {
+	for _, ln := range append(append([]net.Listener{}, l.App...), l.redirects...) {
+		ln.Close()
+	}
+	for _, conn := range l.quic {
+		conn.Close()
+	}
+	unregisterHandoff(l.handoffKeys)
+}
+
+// Handler redirecting all requests to https on the port. (308, so the method and the body are kept)
+func RedirectHandler(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+
+		target := host
+		if port != 443 {
+			target = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if strings.Contains(host, ":") {
+			target = "[" + host + "]"
+		}
+
+		http.Redirect(w, r, "https://"+target+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// Get a listener passed by systemd socket activation. (LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES)
+//
+// If the name is empty, the first listener not used yet is returned. Otherwise, the listener of the name (FileDescriptorName of the socket unit).
+func SystemdListener(name string) (net.Listener, error) {
+	systemd.once.Do(func() {
+		systemd.listeners, systemd.err = loadSystemdListeners()
+	})
+	if systemd.err != nil {
+		return nil, systemd.err
+	}
+
+	systemd.mutex.Lock()
+	defer systemd.mutex.Unlock()
+
+	for _, l := range systemd.listeners {
+		if l.used || (name != "" && l.name != name) {
+			continue
+		}
+
+		l.used = true
+		return l.listener, nil
+	}
+
+	if name != "" {
+		return nil, fmt.Errorf("no unused socket named %q was passed by systemd", name)
+	}
+	return nil, errors.New("no unused socket was passed by systemd")
+}
+
+// Load the listeners passed by systemd. The environment variables are removed, so the child processes do not inherit them.
+func loadSystemdListeners() ([]*systemdListener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("no socket was passed by systemd (LISTEN_PID is not set to this process)")
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, errors.New("no socket was passed by systemd (LISTEN_FDS is not set)")
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]*systemdListener, 0, count)
+	for i := 0; i < count; i++ {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+
+		// FileListener duplicates the descriptor, so the original file is closed
+		file := os.NewFile(uintptr(listenFdsStart+i), name)
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to use the socket %d passed by systemd: %w", listenFdsStart+i, err)
+		}
+
+		listeners = append(listeners, &systemdListener{name: name, listener: listener})
+	}
+
+	return listeners, nil
+}
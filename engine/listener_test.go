@@ -0,0 +1,176 @@
+package engine_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/jhseong7/gimbap/engine"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listen", func() {
+	It("should bind the host and the port", func() {
+		ln, err := engine.Listen(engine.ListenerOption{Host: "127.0.0.1", Port: freePort()})
+		Expect(err).To(BeNil())
+		defer ln.Close()
+
+		Expect(ln.Addr().(*net.TCPAddr).IP.String()).To(Equal("127.0.0.1"))
+	})
+
+	It("should use the given listener as is", func() {
+		given, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		defer given.Close()
+
+		ln, err := engine.Listen(engine.ListenerOption{Listener: given, Port: 1})
+		Expect(err).To(BeNil())
+		Expect(ln).To(BeIdenticalTo(given))
+	})
+
+	It("should listen on a Unix domain socket and replace a stale socket", func() {
+		dir, err := os.MkdirTemp("", "gimbap")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		socket := filepath.Join(dir, "http.sock")
+
+		// A socket file left by a crashed process
+		stale, err := net.Listen("unix", socket)
+		Expect(err).To(BeNil())
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		stale.Close()
+
+		ln, err := engine.Listen(engine.ListenerOption{SocketPath: socket})
+		Expect(err).To(BeNil())
+		defer ln.Close()
+
+		Expect(ln.Addr().Network()).To(Equal("unix"))
+		Expect(ln.Addr().String()).To(Equal(socket))
+	})
+
+	It("should keep a socket in use and a file that is not a socket", func() {
+		dir, err := os.MkdirTemp("", "gimbap")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+
+		// A socket of a running process
+		socket := filepath.Join(dir, "http.sock")
+		running, err := net.Listen("unix", socket)
+		Expect(err).To(BeNil())
+		defer running.Close()
+
+		_, err = engine.Listen(engine.ListenerOption{SocketPath: socket})
+		Expect(err).To(MatchError(ContainSubstring("in use")))
+		Expect(socket).To(BeAnExistingFile())
+
+		// A regular file on the socket path
+		file := filepath.Join(dir, "data.sock")
+		Expect(os.WriteFile(file, []byte("data"), 0o600)).To(Succeed())
+
+		_, err = engine.Listen(engine.ListenerOption{SocketPath: file})
+		Expect(err).To(MatchError(ContainSubstring("is not a socket")))
+		Expect(os.ReadFile(file)).To(Equal([]byte("data")))
+	})
+
+	It("should share the port between the listeners with ReusePort", func() {
+		option := engine.ListenerOption{Host: "127.0.0.1", Port: freePort(), ReusePort: true}
+
+		first, err := engine.Listen(option)
+		Expect(err).To(BeNil())
+		defer first.Close()
+
+		second, err := engine.Listen(option)
+		Expect(err).To(BeNil())
+		defer second.Close()
+
+		_, err = engine.Listen(engine.ListenerOption{Host: "127.0.0.1", Port: option.Port})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should reject an unsupported network", func() {
+		_, err := engine.Listen(engine.ListenerOption{Network: "udp", Port: freePort()})
+		Expect(err).To(MatchError(ContainSubstring("unsupported network")))
+	})
+
+	It("should fail the socket activation without the sockets of systemd", func() {
+		_, err := engine.Listen(engine.ListenerOption{SocketActivation: true})
+		Expect(err).To(MatchError(ContainSubstring("systemd")))
+	})
+})
+
+var _ = Describe("OpenListeners", func() {
+	It("should open the app and the redirect listeners", func() {
+		listeners, err := engine.OpenListeners(engine.ServerRuntimeOption{
+			Port: 1, // Ignored, as the listeners are given
+			Listeners: []engine.ListenerOption{
+				{Host: "127.0.0.1"},
+				{Host: "127.0.0.1", RedirectToHTTPS: true, RedirectPort: 8443},
+			},
+		})
+		Expect(err).To(BeNil())
+		defer listeners.Close()
+
+		Expect(listeners.App).To(HaveLen(1))
+		Expect(listeners.Addr().(*net.TCPAddr).Port).NotTo(BeZero())
+	})
+
+	It("should fail without a listener serving the app", func() {
+		_, err := engine.OpenListeners(engine.ServerRuntimeOption{
+			Listeners: []engine.ListenerOption{{Host: "127.0.0.1", RedirectToHTTPS: true, RedirectPort: 8443}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("at least one listener")))
+	})
+
+	It("should fail HTTP/3 without a TLS listener", func() {
+		_, err := engine.OpenListeners(engine.ServerRuntimeOption{
+			Host:      "127.0.0.1",
+			Protocols: engine.ProtocolOption{HTTP3: true},
+		})
+		Expect(err).To(MatchError(ContainSubstring("HTTP/3 requires a TLS listener")))
+	})
+
+	It("should fail a redirect without the target port", func() {
+		_, err := engine.OpenListeners(engine.ServerRuntimeOption{
+			Listeners: []engine.ListenerOption{{Host: "127.0.0.1"}, {Host: "127.0.0.1", RedirectToHTTPS: true}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("RedirectPort")))
+	})
+})
+
+var _ = Describe("ProtocolOption", func() {
+	It("should name the protocols the engine cannot serve", func() {
+		Expect(engine.ProtocolOption{}.Unsupported("fiber")).To(BeNil())
+		Expect(engine.ProtocolOption{H2C: true}.Unsupported("fiber")).To(MatchError(HavePrefix("h2c is not supported by the fiber engine")))
+		Expect(engine.ProtocolOption{H2C: true, HTTP3: true}.Unsupported("fiber")).To(MatchError(HavePrefix("h2c and HTTP/3 are not supported")))
+	})
+})
+
+var _ = Describe("RedirectHandler", func() {
+	redirect := func(port int, target string) string {
+		w := httptest.NewRecorder()
+		engine.RedirectHandler(port).ServeHTTP(w, httptest.NewRequest("POST", target, nil))
+
+		Expect(w.Code).To(Equal(http.StatusPermanentRedirect))
+		return w.Header().Get("Location")
+	}
+
+	It("should redirect to the https port with the path and the query", func() {
+		Expect(redirect(8443, "http://example.com:8080/users?page=2")).To(Equal("https://example.com:8443/users?page=2"))
+	})
+
+	It("should omit the default https port", func() {
+		Expect(redirect(443, "http://example.com/users")).To(Equal("https://example.com/users"))
+		Expect(redirect(443, "http://[::1]:80/")).To(Equal("https://[::1]/"))
+	})
+})
+
+func freePort() int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
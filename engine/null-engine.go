@@ -1,6 +1,9 @@
 package engine
 
 import (
+	"net"
+	"net/http"
+
 	"github.com/jhseong7/ecl"
 	"github.com/jhseong7/gimbap/controller"
 )
@@ -15,13 +18,23 @@ type (
 	}
 )
 
-func (e *NullEngine) RegisterController(rootPath string, instance controller.IController) {
+// Deprecated: the app registers the controllers with RegisterControllerSpec.
+func (e *NullEngine) RegisterController(rootPath string, c controller.IController) {
+	e.RegisterControllerSpec(NewControllerSpec(rootPath, c))
+}
+
+func (e *NullEngine) RegisterControllerSpec(spec ControllerSpec) {
 	e.logger.Warn("NullEngine does not support controller registration. Please check if this is intended.")
 }
 
 func (e *NullEngine) Run(option ServerRuntimeOption) {
 	e.stopFlag = make(chan string)
 
+	// NullEngine does not listen, so it is ready without an address
+	if option.OnReady != nil {
+		option.OnReady(nil)
+	}
+
 	// Wait for the stop signal (blocking)
 	<-e.stopFlag
 
@@ -35,6 +48,30 @@ func (e *NullEngine) Stop() {
 	return
 }
 
+func (e *NullEngine) Addr() net.Addr {
+	return nil
+}
+
+// NullEngine does not serve any request
+func (e *NullEngine) Requests() RequestStats {
+	return RequestStats{}
+}
+
+func (e *NullEngine) GetGlobalApiPrefix() string {
+	return ""
+}
+
+func (e *NullEngine) SetPathRewriter(rewriter PathRewriter) {}
+
+func (e *NullEngine) Mount(prefix string, handler http.Handler) {
+	e.logger.Warn("NullEngine does not support mounting handlers. Please check if this is intended.")
+}
+
+// NullEngine does not serve any route, so all requests are not found.
+func (e *NullEngine) Handler() http.Handler {
+	return http.NotFoundHandler()
+}
+
 func NewNullEngine() *NullEngine {
 	return &NullEngine{
 		logger: ecl.NewLogger(ecl.LoggerOption{
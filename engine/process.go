@@ -0,0 +1,106 @@
+// File: process.go
+//
+// This file starts the processes of the executable that report when they are ready. (the new process of Restart, or the prefork processes of the app)
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Process of the executable started by StartProcess or Restart
+	Process struct {
+		cmd *exec.Cmd
+
+		ready chan struct{}
+		done  chan struct{}
+		err   error
+	}
+)
+
+// Start a process of the executable with the same arguments and environment, and the given environment variables.
+//
+// The process reports that it is ready with NotifyReady (e.g. once the app listens), which closes the Ready channel.
+func StartProcess(env ...string) (*Process, error) {
+	return startProcess(env, nil)
+}
+
+// Start a process of the executable, with the files inherited from the file descriptor 3. The ready pipe is the descriptor after the files.
+func startProcess(env []string, files []*os.File) (*Process, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the executable: %w", err)
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the ready pipe: %w", err)
+	}
+
+	// The variables of the parent (e.g. if started by a previous handoff) are replaced
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, handoffKeysEnv+"=") && !strings.HasPrefix(e, readyEnv+"=") {
+			cmd.Env = append(cmd.Env, e)
+		}
+	}
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Env = append(cmd.Env, readyEnv+"="+strconv.Itoa(listenFdsStart+len(files)))
+	cmd.ExtraFiles = append(append([]*os.File{}, files...), readyWriter)
+
+	err = cmd.Start()
+	readyWriter.Close()
+	if err != nil {
+		readyReader.Close()
+		return nil, fmt.Errorf("failed to start the process: %w", err)
+	}
+
+	p := &Process{cmd: cmd, ready: make(chan struct{}), done: make(chan struct{})}
+
+	// The pipe is closed without a message if the process exits before it is ready
+	go func() {
+		defer readyReader.Close()
+
+		if n, _ := readyReader.Read(make([]byte, 5)); n > 0 {
+			close(p.ready)
+		}
+	}()
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+func (p *Process) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+// Get a channel closed once the process calls NotifyReady
+func (p *Process) Ready() <-chan struct{} {
+	return p.ready
+}
+
+// Get a channel closed once the process exits
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Get the exit error of the process. (nil if it exited with 0, valid once Done is closed)
+func (p *Process) Err() error {
+	return p.err
+}
+
+func (p *Process) Signal(sig os.Signal) error {
+	return p.cmd.Process.Signal(sig)
+}
+
+func (p *Process) Kill() error {
+	return p.cmd.Process.Kill()
+}
@@ -0,0 +1,100 @@
+// File: protocol.go
+//
+// This file serves the protocols in addition to HTTP/1.1 (h2c and HTTP/3) for the engines based on net/http.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Get the error of the protocols that the engine cannot serve. (nil if none is enabled)
+//
+// Used by the engines not based on net/http, which support none of the protocols.
+func (o ProtocolOption) Unsupported(engineName string) error {
+	protocols := []string{}
+	if o.H2C {
+		protocols = append(protocols, "h2c")
+	}
+	if o.HTTP3 {
+		protocols = append(protocols, "HTTP/3")
+	}
+
+	switch len(protocols) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s is not supported by the %s engine. Use an engine based on net/http (gin, echo, stdhttp or chi)", protocols[0], engineName)
+	default:
+		return fmt.Errorf("%s are not supported by the %s engine. Use an engine based on net/http (gin, echo, stdhttp or chi)", strings.Join(protocols, " and "), engineName)
+	}
+}
+
+// Serve the app listeners with the http server of an engine based on net/http, with the protocols of the runtime option.
+//
+// h2c is served on the listeners without TLS, and HTTP/3 on the UDP ports of the TLS listeners. (advertised by the Alt-Svc header)
+// The HTTP/3 servers are shut down with the http server (waiting for the requests up to the timeout), and Serve returns after them.
+func (l *Listeners) ServeHTTP(server *http.Server, shutdownTimeout time.Duration) error {
+	handler := server.Handler
+	if l.protocols.H2C {
+		server.Handler = h2c.NewHandler(server.Handler, &http2.Server{})
+	}
+
+	if len(l.quic) == 0 {
+		return l.Serve(server.Serve)
+	}
+
+	// HTTP/3 server of each TLS listener, advertised by the responses of the listener (by the port)
+	servers := make([]*http3.Server, len(l.quic))
+	ports := make(map[int]*http3.Server, len(l.quic))
+	for i, conn := range l.quic {
+		servers[i] = &http3.Server{Handler: handler, TLSConfig: http3.ConfigureTLSConfig(l.quicTLS[i])}
+		ports[conn.LocalAddr().(*net.UDPAddr).Port] = servers[i]
+
+		go servers[i].Serve(conn)
+	}
+	server.Handler = altSvcHandler(server.Handler, ports)
+
+	stopped := make(chan struct{})
+	server.RegisterOnShutdown(func() {
+		defer close(stopped)
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		for _, s := range servers {
+			s.Shutdown(ctx)
+		}
+	})
+
+	err := l.Serve(server.Serve)
+	if err == http.ErrServerClosed {
+		<-stopped
+	} else {
+		for _, s := range servers {
+			s.Close()
+		}
+	}
+
+	return err
+}
+
+// Advertise HTTP/3 on the TLS responses of the ports with an HTTP/3 server
+func altSvcHandler(next http.Handler, servers map[int]*http3.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := r.Context().Value(http.LocalAddrContextKey).(*net.TCPAddr); ok && r.TLS != nil {
+			if s, ok := servers[addr.Port]; ok {
+				s.SetQUICHeaders(w.Header())
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,50 @@
+// File: requests.go
+//
+// This file counts the requests of the engines, so the app reports the requests drained or cut off by the shutdown.
+package engine
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+type (
+	// Counts of the requests of an engine
+	RequestStats struct {
+		// Requests being handled
+		InFlight int64
+
+		// Requests handled since the engine was created
+		Completed int64
+	}
+
+	// Counter of the requests of an engine. Safe for concurrent use.
+	RequestCounter struct {
+		inFlight  atomic.Int64
+		completed atomic.Int64
+	}
+)
+
+// Count a request that starts. End must be called when the request is done.
+func (c *RequestCounter) Begin() {
+	c.inFlight.Add(1)
+}
+
+func (c *RequestCounter) End() {
+	c.completed.Add(1)
+	c.inFlight.Add(-1)
+}
+
+func (c *RequestCounter) Stats() RequestStats {
+	return RequestStats{InFlight: c.inFlight.Load(), Completed: c.completed.Load()}
+}
+
+// Count the requests of the handler. (for the engines based on net/http)
+func (c *RequestCounter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Begin()
+		defer c.End()
+
+		next.ServeHTTP(w, r)
+	})
+}
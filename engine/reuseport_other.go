@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package engine
+
+import (
+	"errors"
+	"syscall"
+)
+
+// SO_REUSEPORT is not supported on this platform. (see ServerRuntimeOption.ReusePort)
+const ReusePortSupported = false
+
+func reusePort(network, address string, conn syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this platform")
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package engine
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// SO_REUSEPORT is supported on this platform. (see ServerRuntimeOption.ReusePort)
+const ReusePortSupported = true
+
+// Set SO_REUSEPORT on the socket before it binds, so several processes listen on the same port
+func reusePort(network, address string, conn syscall.RawConn) error {
+	var err error
+	if cerr := conn.Control(func(fd uintptr) {
+		err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	}); cerr != nil {
+		return cerr
+	}
+
+	return err
+}
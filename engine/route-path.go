@@ -0,0 +1,437 @@
+// File: route-path.go
+//
+// This file defines the engine neutral path syntax of the routes and translates it to the syntax of each engine.
+//
+// Syntax:
+//
+//	/users/{id}            named parameter (":id" is also accepted)
+//	/users/{id:int}        parameter with a type constraint (int, uint, float, bool, alpha, alnum, uuid, string)
+//	/users/{id?}           optional parameter (last segment only)
+//	/files/{path...}       catch-all parameter (last segment only, "*path" is also accepted)
+//	/files/{name}.{ext}    parameters mixed with static text in a segment (only on engines that support it)
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jhseong7/gimbap/exception"
+)
+
+type (
+	PathTokenKind int
+
+	// Static text or a parameter in a path segment
+	PathToken struct {
+		Kind       PathTokenKind
+		Value      string // Static text, or the name of the parameter
+		Constraint string // Type constraint of the parameter (empty if none)
+		Optional   bool
+	}
+
+	// A segment between the slashes of a path
+	PathSegment []PathToken
+
+	// Parsed route path in the gimbap syntax
+	RoutePath struct {
+		Raw      string
+		Segments []PathSegment
+	}
+
+	// Syntax of the engine's router. The parsed paths are translated with the syntax.
+	PathSyntax struct {
+		Engine string // Name of the engine (used in the error messages)
+
+		Param    func(name string) string
+		CatchAll func(name string) string
+
+		// Format of the optional parameter. If nil, optional parameters are registered as 2 routes (with and without the parameter)
+		Optional func(name string) string
+
+		// Format of the parameter name with the type constraint, for the engines with native constraints. (e.g. id<int>)
+		// The result is given to Param and Optional. If nil, the constraints are checked before the handler only.
+		Constraint func(name, constraint string) string
+
+		// Whether parameters can be mixed with static text in a segment
+		MixedSegments bool
+
+		// Characters the static text after a parameter must start with. (empty = any)
+		ParamDelimiters string
+	}
+)
+
+const (
+	StaticToken PathTokenKind = iota
+	ParamToken
+	CatchAllToken
+)
+
+var (
+	paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// Patterns of the type constraints
+	pathConstraints = map[string]*regexp.Regexp{
+		"int":    regexp.MustCompile(`^-?[0-9]+$`),
+		"uint":   regexp.MustCompile(`^[0-9]+$`),
+		"float":  regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`),
+		"bool":   regexp.MustCompile(`^(true|false)$`),
+		"alpha":  regexp.MustCompile(`^[A-Za-z]+$`),
+		"alnum":  regexp.MustCompile(`^[A-Za-z0-9]+$`),
+		"uuid":   regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+		"string": nil,
+	}
+)
+
+// Parse the parameter definition inside the braces. (e.g. id:int?, path...)
+func parseParam(def string) (PathToken, error) {
+	token := PathToken{Kind: ParamToken}
+
+	if strings.HasSuffix(def, "...") {
+		token.Kind = CatchAllToken
+		def = strings.TrimSuffix(def, "...")
+	} else if strings.HasSuffix(def, "?") {
+		token.Optional = true
+		def = strings.TrimSuffix(def, "?")
+	}
+
+	name, constraint, hasConstraint := strings.Cut(def, ":")
+	if !paramNamePattern.MatchString(name) {
+		return token, fmt.Errorf("invalid parameter name '%s'", name)
+	}
+	token.Value = name
+
+	if hasConstraint {
+		if token.Kind == CatchAllToken {
+			return token, fmt.Errorf("catch-all parameter '%s' cannot have a constraint", name)
+		}
+
+		if _, ok := pathConstraints[constraint]; !ok {
+			return token, fmt.Errorf("unknown constraint '%s' of parameter '%s'", constraint, name)
+		}
+		token.Constraint = constraint
+	}
+
+	return token, nil
+}
+
+// Parse a segment of the path
+func parseSegment(s string) (PathSegment, error) {
+	// Legacy syntax of the engines. (:name, :name?, *name)
+	switch {
+	case s[0] == ':':
+		return parseSegment("{" + s[1:] + "}")
+	case s == "*":
+		return PathSegment{{Kind: CatchAllToken, Value: "wildcard"}}, nil
+	case s[0] == '*':
+		return parseSegment("{" + s[1:] + "...}")
+	}
+
+	raw := s
+	segment := PathSegment{}
+	for len(s) > 0 {
+		start := strings.IndexByte(s, '{')
+		if start == -1 {
+			segment = append(segment, PathToken{Kind: StaticToken, Value: s})
+			break
+		}
+
+		if start > 0 {
+			segment = append(segment, PathToken{Kind: StaticToken, Value: s[:start]})
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed '{' in segment '%s'", raw)
+		}
+
+		token, err := parseParam(s[start+1 : start+end])
+		if err != nil {
+			return nil, err
+		}
+
+		// Adjacent parameters cannot be separated
+		if len(segment) > 0 && segment[len(segment)-1].Kind != StaticToken {
+			return nil, fmt.Errorf("parameters must be separated by static text in segment '%s'", raw)
+		}
+
+		segment = append(segment, token)
+		s = s[start+end+1:]
+	}
+
+	// Optional and catch-all parameters must be a whole segment
+	if len(segment) > 1 {
+		for _, t := range segment {
+			if t.Optional || t.Kind == CatchAllToken {
+				return nil, fmt.Errorf("optional and catch-all parameters must be a whole segment: '%s'", raw)
+			}
+		}
+	}
+
+	return segment, nil
+}
+
+// Parse the path in the gimbap syntax.
+func ParsePath(path string) (*RoutePath, error) {
+	p := &RoutePath{Raw: path, Segments: []PathSegment{}}
+	names := map[string]bool{}
+
+	for _, s := range strings.Split(strings.Trim(path, "/"), "/") {
+		if s == "" {
+			continue
+		}
+
+		segment, err := parseSegment(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path '%s': %w", path, err)
+		}
+
+		for _, t := range segment {
+			if t.Kind == StaticToken {
+				continue
+			}
+
+			if names[t.Value] {
+				return nil, fmt.Errorf("invalid path '%s': duplicate parameter name '%s'", path, t.Value)
+			}
+			names[t.Value] = true
+		}
+
+		p.Segments = append(p.Segments, segment)
+	}
+
+	// Optional and catch-all parameters are only allowed at the end
+	for i, segment := range p.Segments[:max(len(p.Segments)-1, 0)] {
+		if segment[0].Optional || segment[0].Kind == CatchAllToken {
+			return nil, fmt.Errorf("invalid path '%s': parameter '%s' must be the last segment", path, p.Segments[i][0].Value)
+		}
+	}
+
+	return p, nil
+}
+
+// Get the parameter tokens of the path
+func (p *RoutePath) Params() []PathToken {
+	params := []PathToken{}
+	for _, segment := range p.Segments {
+		for _, t := range segment {
+			if t.Kind != StaticToken {
+				params = append(params, t)
+			}
+		}
+	}
+
+	return params
+}
+
+// Get the name of the catch-all parameter. (empty if none)
+func (p *RoutePath) CatchAll() string {
+	if p == nil || len(p.Segments) == 0 {
+		return ""
+	}
+
+	last := p.Segments[len(p.Segments)-1]
+	if last[0].Kind == CatchAllToken {
+		return last[0].Value
+	}
+
+	return ""
+}
+
+// Check if the path has an optional or a catch-all parameter
+func (p *RoutePath) HasVariableLength() bool {
+	if len(p.Segments) == 0 {
+		return false
+	}
+
+	last := p.Segments[len(p.Segments)-1][0]
+	return last.Optional || last.Kind == CatchAllToken
+}
+
+// Expand the optional parameter into 2 paths (with and without the parameter). Returns the path itself if it has no optional parameter.
+func (p *RoutePath) Expand() []*RoutePath {
+	if len(p.Segments) == 0 || !p.Segments[len(p.Segments)-1][0].Optional {
+		return []*RoutePath{p}
+	}
+
+	last := p.Segments[len(p.Segments)-1][0]
+	last.Optional = false
+
+	with := &RoutePath{Raw: p.Raw, Segments: append(append([]PathSegment{}, p.Segments[:len(p.Segments)-1]...), PathSegment{last})}
+	without := &RoutePath{Raw: p.Raw, Segments: p.Segments[:len(p.Segments)-1]}
+
+	return []*RoutePath{with, without}
+}
+
+// Format the path with the given token formatter.
+func (p *RoutePath) Format(format func(t PathToken) string) string {
+	segments := make([]string, 0, len(p.Segments))
+	for _, segment := range p.Segments {
+		s := ""
+		for _, t := range segment {
+			s += format(t)
+		}
+		segments = append(segments, s)
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// Translate the path to the syntax of the engine.
+//
+// Multiple paths are returned if the engine does not support optional parameters.
+// Returns an error if the engine cannot express the path.
+func (p *RoutePath) Translate(syntax PathSyntax) ([]string, error) {
+	// Check the mixed segments
+	for _, segment := range p.Segments {
+		if len(segment) <= 1 {
+			continue
+		}
+
+		if !syntax.MixedSegments {
+			return nil, fmt.Errorf("path '%s' cannot be registered on %s: parameters mixed with static text in a segment are not supported", p.Raw, syntax.Engine)
+		}
+
+		for i, t := range segment[1:] {
+			if t.Kind == StaticToken && segment[i].Kind != StaticToken && syntax.ParamDelimiters != "" && !strings.ContainsRune(syntax.ParamDelimiters, rune(t.Value[0])) {
+				return nil, fmt.Errorf("path '%s' cannot be registered on %s: text after a parameter must start with one of '%s'", p.Raw, syntax.Engine, syntax.ParamDelimiters)
+			}
+		}
+	}
+
+	format := func(t PathToken) string {
+		name := t.Value
+		if t.Kind == ParamToken && t.Constraint != "" && syntax.Constraint != nil {
+			name = syntax.Constraint(t.Value, t.Constraint)
+		}
+
+		switch {
+		case t.Kind == StaticToken:
+			return t.Value
+		case t.Kind == CatchAllToken:
+			return syntax.CatchAll(name)
+		case t.Optional:
+			return syntax.Optional(name)
+		default:
+			return syntax.Param(name)
+		}
+	}
+
+	paths := []*RoutePath{p}
+	if syntax.Optional == nil {
+		paths = p.Expand()
+	}
+
+	translated := make([]string, 0, len(paths))
+	for _, path := range paths {
+		translated = append(translated, path.Format(format))
+	}
+
+	return translated, nil
+}
+
+// Get the regular expression matching the request paths of the route. The type constraints are not checked.
+func (p *RoutePath) Regexp() *regexp.Regexp {
+	alternatives := []string{}
+
+	for _, expanded := range p.Expand() {
+		pattern := ""
+		for _, segment := range expanded.Segments {
+			if segment[0].Kind == CatchAllToken {
+				pattern += "(?:/.*)?"
+				continue
+			}
+
+			pattern += "/"
+			for _, t := range segment {
+				if t.Kind == StaticToken {
+					pattern += regexp.QuoteMeta(t.Value)
+				} else {
+					pattern += "[^/]+?"
+				}
+			}
+		}
+
+		if pattern == "" {
+			pattern = "/"
+		}
+		alternatives = append(alternatives, pattern)
+	}
+
+	return regexp.MustCompile("^(?:" + strings.Join(alternatives, "|") + ")/?$")
+}
+
+// Check the type constraints of the parameters with the values of the request.
+//
+// Requests not matching the constraints are handled as not found, as if the route did not match.
+func (p *RoutePath) CheckConstraints(param func(name string) string) error {
+	if p == nil {
+		return nil
+	}
+
+	for _, t := range p.Params() {
+		pattern := pathConstraints[t.Constraint]
+		if pattern == nil {
+			continue
+		}
+
+		value := param(t.Value)
+		if value == "" && t.Optional {
+			continue
+		}
+
+		if !pattern.MatchString(value) {
+			return exception.NotFound()
+		}
+	}
+
+	return nil
+}
+
+// Translate the group path of the controller (global api prefix + root path) to the engine syntax.
+//
+// The root path cannot have optional or catch-all parameters, as it is shared by all routes of the controller.
+// Returns an empty string for the root group.
+func TranslateGroupPath(syntax PathSyntax, globalApiPrefix, rootPath string) (string, error) {
+	group, err := ParsePath(MergeRestPath(globalApiPrefix, rootPath))
+	if err != nil {
+		return "", err
+	}
+
+	if group.HasVariableLength() {
+		return "", fmt.Errorf("root path '%s' cannot have optional or catch-all parameters", rootPath)
+	}
+
+	groupPaths, err := group.Translate(syntax)
+	if err != nil {
+		return "", err
+	}
+
+	if groupPaths[0] == "/" {
+		return "", nil
+	}
+
+	return groupPaths[0], nil
+}
+
+// Translate the full path of the route to the engine syntax, relative to the translated group path.
+//
+// Multiple paths are returned if the engine does not support optional parameters.
+func TranslateRoutePath(syntax PathSyntax, groupPath string, fullPath string) ([]string, error) {
+	full, err := ParsePath(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPaths, err := full.Translate(syntax)
+	if err != nil {
+		return nil, err
+	}
+
+	relPaths := make([]string, 0, len(fullPaths))
+	for _, p := range fullPaths {
+		relPaths = append(relPaths, strings.TrimPrefix(p, groupPath))
+	}
+
+	return relPaths, nil
+}
@@ -0,0 +1,130 @@
+package engine_test
+
+import (
+	"github.com/jhseong7/gimbap/engine"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var (
+	colonSyntax = engine.PathSyntax{
+		Engine:   "colon",
+		Param:    func(name string) string { return ":" + name },
+		CatchAll: func(name string) string { return "*" + name },
+	}
+
+	mixedSyntax = engine.PathSyntax{
+		Engine:          "mixed",
+		Param:           func(name string) string { return ":" + name },
+		CatchAll:        func(name string) string { return "*" },
+		Optional:        func(name string) string { return ":" + name + "?" },
+		MixedSegments:   true,
+		ParamDelimiters: "-.",
+	}
+)
+
+func translate(syntax engine.PathSyntax, path string) []string {
+	p, err := engine.ParsePath(path)
+	Expect(err).To(BeNil())
+
+	paths, err := p.Translate(syntax)
+	Expect(err).To(BeNil())
+
+	return paths
+}
+
+var _ = Describe("RoutePath", func() {
+
+	Context("Test parsing", func() {
+		It("Parses the parameters and the constraints", func() {
+			p, err := engine.ParsePath("/users/{id:int}/files/{path...}")
+			Expect(err).To(BeNil())
+			Expect(p.Params()).To(Equal([]engine.PathToken{
+				{Kind: engine.ParamToken, Value: "id", Constraint: "int"},
+				{Kind: engine.CatchAllToken, Value: "path"},
+			}))
+			Expect(p.CatchAll()).To(Equal("path"))
+		})
+
+		It("Accepts the legacy syntax", func() {
+			p, err := engine.ParsePath("/users/:id/*")
+			Expect(err).To(BeNil())
+			Expect(p.Params()[0].Value).To(Equal("id"))
+			Expect(p.CatchAll()).To(Equal("wildcard"))
+		})
+
+		It("Rejects invalid paths", func() {
+			for _, path := range []string{
+				"/users/{id:number}",
+				"/users/{id?}/posts",
+				"/files/{path...}/meta",
+				"/users/{id}/{id}",
+				"/users/{a}{b}",
+				"/users/{id",
+				"/files/x{path...}",
+			} {
+				_, err := engine.ParsePath(path)
+				Expect(err).ToNot(BeNil(), path)
+			}
+		})
+	})
+
+	Context("Test translation", func() {
+		It("Translates the parameters", func() {
+			Expect(translate(colonSyntax, "/users/{id:int}/{path...}")).To(Equal([]string{"/users/:id/*path"}))
+			Expect(translate(mixedSyntax, "/users/{id:int}/{path...}")).To(Equal([]string{"/users/:id/*"}))
+		})
+
+		It("Expands the optional parameters if the engine does not support them", func() {
+			Expect(translate(colonSyntax, "/users/{id?}")).To(Equal([]string{"/users/:id", "/users"}))
+			Expect(translate(mixedSyntax, "/users/{id?}")).To(Equal([]string{"/users/:id?"}))
+		})
+
+		It("Gives the constraints to the engines with native constraints", func() {
+			constrained := mixedSyntax
+			constrained.Constraint = func(name, constraint string) string { return name + "<" + constraint + ">" }
+
+			Expect(translate(constrained, "/users/{id:int}/{name}")).To(Equal([]string{"/users/:id<int>/:name"}))
+			Expect(translate(constrained, "/users/{id:uuid?}")).To(Equal([]string{"/users/:id<uuid>?"}))
+		})
+
+		It("Rejects the paths the engine cannot express", func() {
+			p, _ := engine.ParsePath("/files/{name}.{ext}")
+			_, err := p.Translate(colonSyntax)
+			Expect(err).To(MatchError(ContainSubstring("cannot be registered on colon")))
+
+			Expect(translate(mixedSyntax, "/files/{name}.{ext}")).To(Equal([]string{"/files/:name.:ext"}))
+
+			p, _ = engine.ParsePath("/files/{name}_{ext}")
+			_, err = p.Translate(mixedSyntax)
+			Expect(err).To(MatchError(ContainSubstring("must start with one of '-.'")))
+		})
+
+		It("Translates the routes relative to the group", func() {
+			group, err := engine.TranslateGroupPath(colonSyntax, "api", "users/{userId}")
+			Expect(err).To(BeNil())
+			Expect(group).To(Equal("/api/users/:userId"))
+
+			paths, err := engine.TranslateRoutePath(colonSyntax, group, "/api/users/{userId}/posts/{id?}")
+			Expect(err).To(BeNil())
+			Expect(paths).To(Equal([]string{"/posts/:id", "/posts"}))
+
+			_, err = engine.TranslateGroupPath(colonSyntax, "", "files/{path...}")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("Test constraints", func() {
+		p, _ := engine.ParsePath("/users/{id:int}/{tag:alpha?}")
+
+		It("Accepts the matching values", func() {
+			params := map[string]string{"id": "42", "tag": ""}
+			Expect(p.CheckConstraints(func(name string) string { return params[name] })).To(Succeed())
+		})
+
+		It("Rejects the values not matching the constraints", func() {
+			params := map[string]string{"id": "abc"}
+			Expect(p.CheckConstraints(func(name string) string { return params[name] })).ToNot(Succeed())
+		})
+	})
+})
@@ -6,18 +6,31 @@ package engine
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
+	"net/http"
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/interceptor"
 )
 
 type (
 	// The engine must implement this interface to be used in the app.
+	//
+	// The features added after the first version of the interface are optional capabilities (e.g. IControllerSpecRegistrar, IMountable),
+	// checked by the app with type assertions. The engines without a capability keep working without the features using it.
+	// The engines of gimbap implement all of them.
 	IServerEngine interface {
 		// Registers a controller to the engine
+		//
+		// Deprecated: implement IControllerSpecRegistrar. The app calls this method only for the engines without it,
+		// with the routes of the spec and without the interceptors, the exception filters and the middlewares of the controller.
 		RegisterController(rootPath string, controller controller.IController)
 
 		// Run the server on the specified port
@@ -28,7 +41,7 @@ type (
 		Stop()
 
 		// Add middleware to the engine.
-		// This will be native to the engine's core
+		// This will be native to the engine's core, or a middleware instance (middleware.IMiddleware) that returns the native middleware
 		AddMiddleware(middleware ...interface{})
 
 		// Add static file serving to the engine.
@@ -36,8 +49,100 @@ type (
 		AddStatic(prefix, root string, config ...interface{})
 	}
 
+	// Engine registering the controller specs resolved by the app. (interceptors, exception filters, middlewares and metadata)
+	IControllerSpecRegistrar interface {
+		// Registers a controller to the engine
+		RegisterControllerSpec(spec ControllerSpec)
+	}
+
+	// Engine with a global api prefix. The routes are assumed to have no prefix without it.
+	IGlobalApiPrefixProvider interface {
+		// Get the global api prefix of the engine. (ServerEngineOption.GlobalApiPrefix)
+		GetGlobalApiPrefix() string
+	}
+
+	// Engine rewriting the request paths before the routing. Required by the header and the media type versioning.
+	IPathRewritable interface {
+		// Set the function to rewrite the request path before the routing. (e.g. header based versioning)
+		// The rewriter must run before all middlewares and routes.
+		SetPathRewriter(rewriter PathRewriter)
+	}
+
+	// Engine mounting http.Handlers. Required by GimbapApp.Mount and the readiness path of the staged shutdown.
+	IMountable interface {
+		// Mount an http.Handler on the path prefix. The handler serves all methods and sub paths of the prefix,
+		// with the prefix removed from the request path. (see MountHandler)
+		Mount(prefix string, handler http.Handler)
+	}
+
+	// Engine reporting its listening address. GimbapApp.Addr is nil without it.
+	IAddressReporter interface {
+		// Get the address the server listens on. nil if the server is not running yet. (use ServerRuntimeOption.OnReady to wait for it)
+		Addr() net.Addr
+	}
+
+	// Engine served as an http.Handler. Required by GimbapApp.Handler.
+	IHandlerProvider interface {
+		// Get the engine as an http.Handler with the routes and the middlewares registered so far.
+		// The handler can be served without Run. (e.g. httptest.NewServer or another http.Server)
+		Handler() http.Handler
+	}
+
+	// Engine counting its requests. The shutdown report has no request counts without it.
+	IRequestReporter interface {
+		// Get the counts of the requests served by Run. (used to report the requests drained or cut off by Stop)
+		Requests() RequestStats
+	}
+
+	// Controller registration spec given to the engine by the app.
+	//
+	// The injectable values of the spec (e.g. interceptor providers) are already resolved by the app.
+	ControllerSpec struct {
+		// Name of the controller
+		Name string
+
+		// Root path of the controller. The full path of each route will be RootPath + Path.
+		RootPath string
+
+		// Route specs of the controller instance
+		Routes []controller.RouteSpec
+
+		// Interceptors applied to all routes of the controller. (global interceptors first)
+		Interceptors []interceptor.IInterceptor
+
+		// Exception filters applied to all routes of the controller. (global filters last)
+		ExceptionFilters []exception.IExceptionFilter
+
+		// Engine native middlewares (or middleware instances) applied to all routes of the controller.
+		Middlewares []interface{}
+
+		// API versions of the controller. The versions are applied to the paths by the app before the registration.
+		Versions []string
+
+		// Metadata of all routes of the controller
+		Metadata map[string]interface{}
+	}
+
+	// Function to rewrite the request path before the routing. Returns the path to route the request with.
+	PathRewriter func(method, path string, header func(key string) string) string
+
 	ServerEngineOption struct {
 		GlobalApiPrefix string
+
+		// Max time to read a request with the body, to read the request headers, to write the response,
+		// and to keep an idle keep-alive connection. No limit if 0. (set ReadHeaderTimeout at least against slowloris attacks)
+		ReadTimeout       time.Duration
+		ReadHeaderTimeout time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+
+		// Max size of the request headers (1 MB if 0, 4 KB on fiber) and of the request body. (no limit if 0, 4 MB on fiber)
+		// The requests with a larger body are rejected with 413 Payload Too Large.
+		MaxHeaderBytes int
+		MaxBodyBytes   int64
+
+		// Max time to wait for the in-flight requests when the engine stops. The connections still open after it are closed. (5 seconds if 0)
+		ShutdownTimeout time.Duration
 	}
 
 	TLSOption struct {
@@ -49,15 +154,151 @@ type (
 		// If the Certificate is given through the config
 		//, In this case, the CertFile and KeyFile will be ignored.
 		Config *tls.Config
+
+		// Reload the certificate files when they change, without restarting the server. (disabled if 0)
+		// The files are not watched: the modification times are checked on a TLS handshake at most once per interval,
+		// so a rotated certificate is served from the first handshake after the next check.
+		ReloadInterval time.Duration
+
+		// CA bundle (PEM file) or pool to verify the client certificates. (mutual TLS)
+		ClientCAFile string
+		ClientCAs    *x509.CertPool
+
+		// Verification mode of the client certificates. tls.RequireAndVerifyClientCert if a client CA is set and the mode is not. (nil)
+		// Any mode can be set explicitly, including tls.NoClientCert with a client CA.
+		// The verified client is given to the handlers by ExecutionContext.TLS. (see PeerIdentityOf)
+		ClientAuth *tls.ClientAuthType
+
+		// Obtain and renew the certificates from an ACME server (e.g. Let's Encrypt) instead of the files or the config.
+		ACME *ACMEOption
+	}
+
+	// Option of the certificates obtained by ACME. Using the option accepts the terms of service of the ACME server.
+	//
+	// TLS-ALPN-01 challenges are answered by the TLS listeners, and HTTP-01 challenges by the listeners with RedirectToHTTPS.
+	ACMEOption struct {
+		// Directory URL of the ACME server. Let's Encrypt if empty. (e.g. https://localhost:14000/dir for Pebble)
+		DirectoryURL string
+
+		// Contact email of the account. (optional)
+		Email string
+
+		// Domains to obtain the certificates for. The handshakes of other server names are rejected.
+		Domains []string
+
+		// Directory to keep the account key and the certificates. Kept in memory only if empty. (obtained again on every start)
+		CacheDir string
+
+		// Obtain the certificates this long before they expire. (30 days if 0)
+		RenewBefore time.Duration
+
+		// Client of the ACME server. (e.g. to trust the CA of a test server) http.DefaultClient if nil.
+		HTTPClient *http.Client
+	}
+
+	// Address and TLS option of a listener of the server
+	ListenerOption struct {
+		// Port to listen on. An ephemeral port is chosen if 0. (see OnReady and IServerEngine.Addr for the actual port)
+		Port int
+
+		// Host or IP to bind. (e.g. 127.0.0.1) All interfaces if empty.
+		Host string
+
+		// Network of the listener: tcp (default), tcp4, tcp6 or unix
+		Network string
+
+		// Path of the Unix domain socket. The network is unix if set.
+		SocketPath string
+
+		// Listener created by the user. (e.g. for tests) Port, Host, Network and SocketPath are ignored if set.
+		Listener net.Listener
+
+		// Use the socket passed by systemd socket activation (LISTEN_FDS), instead of creating a listener.
+		// SocketActivationName selects the socket by the FileDescriptorName of the socket unit. (the first socket if empty)
+		SocketActivation     bool
+		SocketActivationName string
+
+		TLSOption *TLSOption
+
+		// Redirect all requests of the listener to https, instead of serving the app.
+		// The requests are redirected to RedirectPort, or to the port of the first TLS listener if not set.
+		RedirectToHTTPS bool
+		RedirectPort    int
+
+		// Listen with SO_REUSEPORT, so several processes share the port. (TCP only)
+		ReusePort bool
 	}
 
 	ServerRuntimeOption struct {
+		// Port to listen on. An ephemeral port is chosen if 0. (see OnReady and IServerEngine.Addr for the actual port)
 		Port int
 
+		// Host or IP to bind. (e.g. 127.0.0.1) All interfaces if empty.
+		Host string
+
+		// Network of the listener: tcp (default), tcp4, tcp6 or unix
+		Network string
+
+		// Path of the Unix domain socket. The network is unix if set.
+		SocketPath string
+
+		// Listener created by the user. (e.g. for tests) Port, Host, Network and SocketPath are ignored if set.
+		Listener net.Listener
+
+		// Use the socket passed by systemd socket activation (LISTEN_FDS), instead of creating a listener.
+		// SocketActivationName selects the socket by the FileDescriptorName of the socket unit. (the first socket if empty)
+		SocketActivation     bool
+		SocketActivationName string
+
 		TLSOption *TLSOption
+
+		// Serve the app on several listeners. (e.g. https on 443 and a redirect from http on 80)
+		// The address and TLS options above are ignored if set.
+		Listeners []ListenerOption
+
+		// Protocols served in addition to HTTP/1.1. (h2c and HTTP/3)
+		Protocols ProtocolOption
+
+		// Listen on all listeners with SO_REUSEPORT, so several processes share the ports. (e.g. the prefork processes of the app)
+		ReusePort bool
+
+		// Called with the address of the (first) listener once the server accepts connections.
+		OnReady func(addr net.Addr)
+	}
+
+	// Protocols of the server in addition to HTTP/1.1. Only the engines based on net/http (gin, echo, stdhttp, chi) support them.
+	ProtocolOption struct {
+		// Serve HTTP/2 without TLS (h2c) on the listeners without TLS. (with prior knowledge or the Upgrade header)
+		H2C bool
+
+		// Serve HTTP/3 (QUIC) on the UDP ports of the TLS listeners, and advertise it with the Alt-Svc header of the responses.
+		HTTP3 bool
 	}
 )
 
+// Wait time of the in-flight requests on Stop if ServerEngineOption.ShutdownTimeout is not set
+const DefaultShutdownTimeout = 5 * time.Second
+
+// Get the max time to wait for the in-flight requests on Stop
+func (o ServerEngineOption) GetShutdownTimeout() time.Duration {
+	if o.ShutdownTimeout > 0 {
+		return o.ShutdownTimeout
+	}
+
+	return DefaultShutdownTimeout
+}
+
+// Create the spec of a controller registered with the deprecated IServerEngine.RegisterController. The spec has the routes only.
+//
+// The engines implementing IControllerSpecRegistrar use it to implement RegisterController.
+func NewControllerSpec(rootPath string, c controller.IController) ControllerSpec {
+	return ControllerSpec{
+		Name:     reflect.TypeOf(c).String(),
+		RootPath: rootPath,
+		Routes:   c.GetRouteSpecs(),
+	}
+}
+
 // Common util functions
 func MergeRestPath(paths ...string) string {
 	processedPaths := make([]string, 0)
@@ -74,6 +315,23 @@ func MergeRestPath(paths ...string) string {
 	return "/" + strings.Join(processedPaths, "/")
 }
 
+// Wrap the handler mounted on the prefix, so the handler sees the paths relative to the prefix. (e.g. /legacy/users --> /users)
+func MountHandler(prefix string, handler http.Handler) http.Handler {
+	prefix = strings.TrimSuffix(MergeRestPath(prefix), "/")
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The prefix itself is served as the root of the handler
+		if !strings.HasPrefix(r.URL.Path, "/") {
+			r.URL.Path = "/" + r.URL.Path
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = "/" + r.URL.RawPath
+			}
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+}
+
 func CheckMethodValidity(method string) {
 	switch method {
 	case "GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "HEAD":
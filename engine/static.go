@@ -0,0 +1,404 @@
+// File: static.go
+//
+// This file serves the static files of the StaticOption, so all engines serve them the same way.
+// (fs.FS sources, SPA fallback, cache headers, precompressed assets and directory listing)
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// Option of the static files, honored by all engines. (given to AddStatic as the config)
+	//
+	// The static files are served for the GET and HEAD requests not matching any route.
+	StaticOption struct {
+		// Files to serve (e.g. an embed.FS). The root of AddStatic is a directory of the FS. (the FS itself if empty or ".")
+		// The root is a directory on the disk if not set.
+		FS fs.FS
+
+		// File served for the directories. (index.html if empty)
+		Index string
+
+		// File served for the paths without a file, relative to the root. (e.g. index.html of a single page app)
+		// Only the pages are served the fallback: the paths without an extension requested by the browsers (accepting text/html),
+		// so the unknown API paths and the missing assets are still not found. Not found if empty.
+		Fallback string
+
+		// List the files of the directories without an index file. (not found if false)
+		Browse bool
+
+		// Cache-Control header of the files (e.g. "public, max-age=31536000, immutable"), and of the index and the fallback files.
+		// Not set if empty. The index and the fallback use CacheControl if IndexCacheControl is empty.
+		CacheControl      string
+		IndexCacheControl string
+
+		// Set the ETag header from the content of the files. (e.g. for an embed.FS, without modification times)
+		// The conditional requests (If-None-Match) are answered with 304 Not Modified.
+		ETag bool
+
+		// Serve the precompressed files next to the files (.br then .gz) to the clients accepting them.
+		Precompressed bool
+	}
+
+	// Static files of a path prefix
+	StaticFiles struct {
+		prefix string
+		files  fs.FS
+		option StaticOption
+
+		// ETags of the files, by the name, the size and the modification time
+		etags sync.Map
+	}
+
+	// Static files of an engine, by the length of their prefix
+	Statics []*StaticFiles
+
+	// Encodings of the precompressed files, in the order of preference
+	staticEncoding struct {
+		name      string
+		extension string
+	}
+)
+
+var staticEncodings = []staticEncoding{{"br", ".br"}, {"gzip", ".gz"}}
+
+// Create the static files of the prefix from the root directory on the disk, or from the FS of the option.
+func NewStaticFiles(prefix, root string, option StaticOption) (*StaticFiles, error) {
+	files := option.FS
+	switch {
+	case files == nil:
+		files = os.DirFS(root)
+	case root != "" && root != ".":
+		sub, err := fs.Sub(files, strings.Trim(root, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid static root %s: %w", root, err)
+		}
+		files = sub
+	}
+
+	if option.Index == "" {
+		option.Index = "index.html"
+	}
+	if option.IndexCacheControl == "" {
+		option.IndexCacheControl = option.CacheControl
+	}
+
+	return &StaticFiles{prefix: strings.TrimSuffix(MergeRestPath(prefix), "/"), files: files, option: option}, nil
+}
+
+// Get the static option given as the config of AddStatic. (false if the config is not a StaticOption)
+func StaticOptionOf(config []interface{}) (StaticOption, bool) {
+	if len(config) == 0 {
+		return StaticOption{}, false
+	}
+
+	switch option := config[0].(type) {
+	case StaticOption:
+		return option, true
+	case *StaticOption:
+		return *option, option != nil
+	default:
+		return StaticOption{}, false
+	}
+}
+
+// Get the name of the file of the request path in the FS. (false if the request is not for the prefix)
+func (s *StaticFiles) name(method, requestPath string) (string, bool) {
+	if method != http.MethodGet && method != http.MethodHead {
+		return "", false
+	}
+
+	rest := strings.TrimPrefix(requestPath, s.prefix)
+	if len(rest) == len(requestPath) && s.prefix != "" || rest != "" && rest[0] != '/' {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+rest), "/")
+	if name == "" {
+		name = "."
+	}
+
+	return name, true
+}
+
+// Check if the request is served by the static files. (a file, a directory, or the fallback)
+func (s *StaticFiles) Has(method, requestPath, accept string) bool {
+	name, ok := s.name(method, requestPath)
+	if !ok {
+		return false
+	}
+	if s.fallsBack(requestPath, accept) {
+		return true
+	}
+
+	info, err := fs.Stat(s.files, name)
+	if err != nil {
+		return false
+	}
+	if !info.IsDir() || s.option.Browse {
+		return true
+	}
+
+	_, err = fs.Stat(s.files, path.Join(name, s.option.Index))
+	return err == nil
+}
+
+// Serve the file of the request. Returns false without writing the response if no file serves the request.
+func (s *StaticFiles) Serve(w http.ResponseWriter, r *http.Request) bool {
+	name, ok := s.name(r.Method, r.URL.Path)
+	if !ok {
+		return false
+	}
+
+	info, err := fs.Stat(s.files, name)
+	switch {
+	case err != nil:
+		return s.serveFallback(w, r)
+	case !info.IsDir():
+		// The index file is served by its directory, as http.FileServer does
+		if path.Base(name) == s.option.Index {
+			localRedirect(w, r, "./")
+			return true
+		}
+
+		s.serveFile(w, r, name, s.option.CacheControl)
+		return true
+	}
+
+	// Directories are served with a trailing slash, so the relative links of the index work
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		if _, err := fs.Stat(s.files, path.Join(name, s.option.Index)); err == nil || s.option.Browse {
+			localRedirect(w, r, path.Base(r.URL.Path)+"/")
+			return true
+		}
+
+		return s.serveFallback(w, r)
+	}
+
+	if index := path.Join(name, s.option.Index); isFile(s.files, index) {
+		s.serveFile(w, r, index, s.option.IndexCacheControl)
+		return true
+	}
+	if s.option.Browse {
+		s.serveDirectory(w, r, name)
+		return true
+	}
+
+	return s.serveFallback(w, r)
+}
+
+// Add the static files. The files of the longer prefixes are served first. (e.g. /docs before the fallback of /)
+func (s Statics) Add(files *StaticFiles) Statics {
+	i := sort.Search(len(s), func(i int) bool { return len(s[i].prefix) < len(files.prefix) })
+
+	return slices.Insert(s, i, files)
+}
+
+// Serve the request with the first static files serving it. Returns false if none serves it.
+func (s Statics) Serve(w http.ResponseWriter, r *http.Request) bool {
+	for _, files := range s {
+		if files.Serve(w, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check if any static files serve the request
+func (s Statics) Has(method, requestPath, accept string) bool {
+	for _, files := range s {
+		if files.Has(method, requestPath, accept) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check if the request is served the fallback: a page of the app (a path without an extension) requested by a browser
+func (s *StaticFiles) fallsBack(requestPath, accept string) bool {
+	if s.option.Fallback == "" || path.Ext(requestPath) != "" {
+		return false
+	}
+	if !strings.Contains(accept, "text/html") && !strings.Contains(accept, "application/xhtml+xml") {
+		return false
+	}
+
+	return isFile(s.files, s.option.Fallback)
+}
+
+func (s *StaticFiles) serveFallback(w http.ResponseWriter, r *http.Request) bool {
+	if !s.fallsBack(r.URL.Path, r.Header.Get("Accept")) {
+		return false
+	}
+
+	s.serveFile(w, r, s.option.Fallback, s.option.IndexCacheControl)
+	return true
+}
+
+// Serve the file, or its precompressed file accepted by the client
+func (s *StaticFiles) serveFile(w http.ResponseWriter, r *http.Request, name, cacheControl string) {
+	header := w.Header()
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	served := name
+	if s.option.Precompressed {
+		header.Add("Vary", "Accept-Encoding")
+
+		accepted := r.Header.Get("Accept-Encoding")
+		for _, encoding := range staticEncodings {
+			if acceptsEncoding(accepted, encoding.name) && isFile(s.files, name+encoding.extension) {
+				served = name + encoding.extension
+				header.Set("Content-Encoding", encoding.name)
+				if contentType == "" {
+					contentType = "application/octet-stream"
+				}
+				break
+			}
+		}
+	}
+
+	file, err := s.files.Open(served)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// fs.File does not have to seek (http.ServeContent requires it)
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	} else {
+		// Sniffed by http.ServeContent
+		header.Del("Content-Type")
+	}
+	if cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+	if s.option.ETag {
+		if etag, err := s.etag(served, info, content); err == nil {
+			header.Set("ETag", etag)
+		}
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// Get the ETag of the file. The hash of the content is computed once for each version of the file. (size and modification time)
+func (s *StaticFiles) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := fmt.Sprintf("%s|%d|%d", name, info.Size(), info.ModTime().UnixNano())
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	s.etags.Store(key, etag)
+
+	return etag, nil
+}
+
+// Write the listing of the directory
+func (s *StaticFiles) serveDirectory(w http.ResponseWriter, r *http.Request, name string) {
+	entries, err := fs.ReadDir(s.files, name)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// The status is explicit, as the not found handlers of some engines set 404 before serving
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	fmt.Fprintf(w, "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+
+		link := url.URL{Path: entryName}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(entryName))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+// Redirect to the path relative to the request path, keeping the query
+func localRedirect(w http.ResponseWriter, r *http.Request, target string) {
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+func isFile(files fs.FS, name string) bool {
+	info, err := fs.Stat(files, name)
+	return err == nil && !info.IsDir()
+}
+
+// Check if the Accept-Encoding header accepts the encoding. (not with q=0)
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			if key, value, _ := strings.Cut(strings.TrimSpace(param), "="); key == "q" {
+				q, err := strconv.ParseFloat(value, 64)
+				return err == nil && q > 0
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
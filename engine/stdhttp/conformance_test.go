@@ -0,0 +1,27 @@
+package stdhttp_engine_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/enginetest"
+	stdhttp_engine "github.com/jhseong7/gimbap/engine/stdhttp"
+)
+
+func TestConformance(t *testing.T) {
+	enginetest.Run(t, enginetest.Option{
+		New: func() engine.IServerEngine { return stdhttp_engine.NewStdHttpEngine() },
+		NewWithOption: func(option engine.ServerEngineOption) engine.IServerEngine {
+			return stdhttp_engine.NewStdHttpEngine(stdhttp_engine.StdHttpEngineOption{ServerEngineOption: option})
+		},
+		Middleware: func(name string) interface{} {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Add(enginetest.MiddlewareHeader, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		},
+	})
+}
@@ -0,0 +1,167 @@
+package stdhttp_engine
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/internal/nethttp"
+	"github.com/jhseong7/gimbap/exception"
+)
+
+type (
+	// Server engine built on the net/http package only. The routes are registered to http.ServeMux with the method and wildcard patterns.
+	//
+	// The server, the global middlewares and the static files are handled by nethttp.Engine.
+	StdHttpEngine struct {
+		*nethttp.Engine
+
+		// The underlying router
+		mux *http.ServeMux
+	}
+
+	StdHttpEngineOption struct {
+		engine.ServerEngineOption
+
+		// Validator of the requests bound with ExecutionContext.Bind (optional)
+		Validator Validator
+	}
+
+	// Validator of the requests bound with ExecutionContext.Bind (same as the echo.Validator interface)
+	Validator = nethttp.Validator
+)
+
+// Path syntax of http.ServeMux. ServeMux does not support optional parameters and parameters mixed with static text.
+var pathSyntax = engine.PathSyntax{
+	Engine:   "net/http",
+	Param:    func(name string) string { return "{" + name + "}" },
+	CatchAll: func(name string) string { return "{" + name + "...}" },
+}
+
+// Register the routes of the controller. (see engine.NewControllerSpec)
+//
+// Deprecated: the app registers the controllers with RegisterControllerSpec.
+func (e *StdHttpEngine) RegisterController(rootPath string, c controller.IController) {
+	e.RegisterControllerSpec(engine.NewControllerSpec(rootPath, c))
+}
+
+func (e *StdHttpEngine) RegisterControllerSpec(spec engine.ControllerSpec) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.Logger.Panicf("Failed to register controller to path: %s. %v", spec.RootPath, r)
+		}
+	}()
+
+	// ServeMux does not have route groups, so the routes are registered with the full paths.
+	// The root path is still checked, as it is shared by all routes of the controller.
+	if _, err := engine.TranslateGroupPath(pathSyntax, e.GetGlobalApiPrefix(), spec.RootPath); err != nil {
+		e.Logger.Panic(err.Error())
+	}
+	controllerMiddlewares := e.CastMiddlewares(spec.Middlewares)
+
+	for _, routeSpec := range spec.Routes {
+		engine.CheckMethodValidity(routeSpec.Method)
+		fullPath := engine.MergeRestPath(e.GetGlobalApiPrefix(), spec.RootPath, routeSpec.Path)
+
+		// Translate the path to the ServeMux syntax. (optional parameters are registered as 2 routes)
+		paths, err := engine.TranslateRoutePath(pathSyntax, "", fullPath)
+		if err != nil {
+			e.Logger.Panic(err.Error())
+		}
+
+		// Middlewares (controller --> route) then the handler
+		middlewares := append(append([]nethttp.Middleware{}, controllerMiddlewares...), e.CastMiddlewares(routeSpec.Middlewares)...)
+		handler := nethttp.Chain(e.RouteHandler(spec, routeSpec, fullPath), middlewares)
+
+		for _, path := range paths {
+			// "/" matches all paths on ServeMux. {$} matches the root only.
+			if path == "/" {
+				path = "/{$}"
+			}
+
+			e.mux.Handle(routeSpec.Method+" "+path, handler)
+			e.Metadata.Set(routeSpec.Method, path, spec.RouteMetadata(routeSpec))
+		}
+
+		// Get the name of the Handler function
+		handlerName := engine.RuntimeFuncName(routeSpec.Handler)
+
+		e.Logger.Logf("Registered route: %-8s %-20s --> %s", routeSpec.Method, fullPath, handlerName)
+	}
+}
+
+// The StaticOption is served when no pattern matches. (see nethttp.Engine.AddStaticFiles)
+func (e *StdHttpEngine) AddStatic(prefix, root string, config ...interface{}) {
+	if e.AddStaticFiles(prefix, root, config) {
+		return
+	}
+
+	// NOTE: net/http does not support other configs for static file serving
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	e.mux.Handle("GET "+prefix+"/", http.StripPrefix(prefix, http.FileServer(http.Dir(root))))
+}
+
+// Mount the handler on the prefix for all methods. The prefix and the sub paths are registered as 2 patterns.
+func (e *StdHttpEngine) Mount(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(engine.MergeRestPath(prefix), "/")
+	h := engine.MountHandler(prefix, handler)
+
+	if prefix != "" {
+		e.mux.Handle(prefix, h)
+	}
+	e.mux.Handle(prefix+"/", h)
+}
+
+// Route the request with the mux. Unmatched requests are written in the same format as the exception filters.
+func (e *StdHttpEngine) route(w http.ResponseWriter, r *http.Request) {
+	handler, pattern := e.mux.Handler(r)
+	if pattern != "" {
+		e.mux.ServeHTTP(w, r)
+		return
+	}
+
+	// Not found or method not allowed. The status (and the Allow header) of the mux is kept.
+	recorder := nethttp.NewStatusRecorder(w)
+	handler.ServeHTTP(recorder, r)
+	w.Header().Del("X-Content-Type-Options")
+
+	if recorder.Status() == http.StatusNotFound {
+		w.Header().Del("Content-Type")
+		if e.Statics.Serve(w, r) {
+			return
+		}
+	}
+
+	nethttp.HandleError(w, r, exception.New(recorder.Status()))
+}
+
+// Get the metadata of the route matching the request
+func (e *StdHttpEngine) metadataOf(r *http.Request) map[string]interface{} {
+	_, pattern := e.mux.Handler(r)
+	method, path, _ := strings.Cut(pattern, " ")
+	return e.Metadata.Get(method, path)
+}
+
+// Route the requests with the mux, inside the global middlewares
+func (e *StdHttpEngine) router() (http.Handler, bool) {
+	return http.HandlerFunc(e.route), false
+}
+
+// Create a new net/http engine
+func NewStdHttpEngine(options ...StdHttpEngineOption) *StdHttpEngine {
+	// Get the options
+	var option StdHttpEngineOption
+	if len(options) > 0 {
+		option = options[0]
+	}
+
+	e := &StdHttpEngine{
+		Engine: nethttp.NewEngine("StdHttpEngine", option.ServerEngineOption, option.Validator),
+		mux:    http.NewServeMux(),
+	}
+	e.Router = e.router
+	e.MetadataOf = e.metadataOf
+
+	return e
+}
@@ -0,0 +1,125 @@
+package stdhttp_engine_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	stdhttp_engine "github.com/jhseong7/gimbap/engine/stdhttp"
+	"github.com/jhseong7/gimbap/exception"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type itemRequest struct {
+	Name string `json:"name"`
+}
+
+// Native middleware adding the name to the X-Middleware header
+func named(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func freePort() int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func request(method, url, body string) (*http.Response, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	Expect(err).ToNot(HaveOccurred())
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	Expect(err).ToNot(HaveOccurred())
+	defer res.Body.Close()
+
+	data, _ := io.ReadAll(res.Body)
+	return res, string(data)
+}
+
+var _ = Describe("StdHttpEngine", func() {
+	var base string
+
+	BeforeEach(func() {
+		e := stdhttp_engine.NewStdHttpEngine()
+		e.AddMiddleware(named("global"))
+		e.RegisterControllerSpec(engine.ControllerSpec{
+			Name:        "ItemController",
+			RootPath:    "items",
+			Middlewares: []interface{}{named("controller")},
+			Routes: []controller.RouteSpec{
+				{
+					Method: "GET",
+					Path:   "{id}",
+					Handler: controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) {
+						return map[string]string{"id": ctx.Param("id")}, nil
+					}),
+					Middlewares: []interface{}{named("route")},
+				},
+				{
+					Method: "POST",
+					Path:   "",
+					Handler: func(ctx controller.ExecutionContext, req itemRequest) (interface{}, error) {
+						return map[string]string{"name": req.Name}, nil
+					},
+				},
+			},
+		})
+
+		port := freePort()
+		base = fmt.Sprintf("http://127.0.0.1:%d", port)
+
+		go e.Run(engine.ServerRuntimeOption{Port: port})
+		DeferCleanup(e.Stop)
+
+		Eventually(func() error {
+			conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			if err == nil {
+				conn.Close()
+			}
+			return err
+		}).Should(Succeed())
+	})
+
+	It("Serves the routes with the path parameters", func() {
+		res, body := request("GET", base+"/items/12", "")
+
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"id":"12"}`))
+	})
+
+	It("Binds the request of the typed handlers", func() {
+		res, body := request("POST", base+"/items", `{"name":"gimbap"}`)
+
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"name":"gimbap"}`))
+	})
+
+	It("Runs the global, the controller and the route middlewares in order", func() {
+		res, _ := request("GET", base+"/items/1", "")
+
+		Expect(res.Header.Values("X-Middleware")).To(Equal([]string{"global", "controller", "route"}))
+	})
+
+	It("Responds to the unknown routes with the problem details", func() {
+		res, _ := request("GET", base+"/unknown", "")
+
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(res.Header.Get("Content-Type")).To(HavePrefix(exception.ProblemJSONContentType))
+	})
+})
@@ -0,0 +1,13 @@
+package stdhttp_engine_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestStdHttpEngine(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "StdHttpEngine Suite")
+}
@@ -0,0 +1,251 @@
+// File: tls.go
+//
+// This file loads the TLS config of the listeners, shared by all engines.
+// (certificate files with the reload, certificates obtained by ACME, mutual TLS and the identity of the verified clients)
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+type (
+	// Identity of a client verified by mutual TLS
+	PeerIdentity struct {
+		// The client certificate and the verified chain (client certificate first)
+		Certificate *x509.Certificate
+		Chain       []*x509.Certificate
+
+		CommonName     string
+		DNSNames       []string
+		EmailAddresses []string
+		URIs           []*url.URL // e.g. SPIFFE IDs
+	}
+
+	// Certificate loaded from the files, reloaded when the files change
+	certificateReloader struct {
+		certFile, keyFile string
+		interval          time.Duration
+
+		mutex     sync.Mutex
+		cert      *tls.Certificate
+		modTime   time.Time
+		checkedAt time.Time
+	}
+)
+
+// Managers of the ACME options, so the listeners sharing an option share the certificates and the challenges
+var acmeManagers sync.Map // *ACMEOption -> *autocert.Manager
+
+// Get the tls config of the option.
+//
+// The given config is used if set. The certificate files are loaded if the config does not have a certificate.
+// With ACME, the certificates are obtained on the handshakes instead.
+func (o *TLSOption) TLSConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if o.Config != nil {
+		config = o.Config.Clone()
+	}
+
+	if o.ACME != nil {
+		manager, err := o.ACME.manager()
+		if err != nil {
+			return nil, err
+		}
+
+		config.Certificates = nil
+		config.GetCertificate = manager.GetCertificate
+
+		// The TLS-ALPN-01 challenges are negotiated with ALPN. http/1.1 is kept for the clients, as the engines serve it by default.
+		if len(config.NextProtos) == 0 {
+			config.NextProtos = []string{"http/1.1"}
+		}
+		if !slices.Contains(config.NextProtos, acme.ALPNProto) {
+			config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+		}
+	}
+
+	// Only load the cert/key files if the config does not have a certificate
+	if o.CertFile != "" && o.KeyFile != "" && len(config.Certificates) == 0 && config.GetCertificate == nil {
+		if o.ReloadInterval > 0 {
+			reloader, err := newCertificateReloader(o.CertFile, o.KeyFile, o.ReloadInterval)
+			if err != nil {
+				return nil, err
+			}
+
+			config.GetCertificate = reloader.GetCertificate
+		} else {
+			cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load TLS config: %w", err)
+			}
+
+			config.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	if len(config.Certificates) == 0 && config.GetCertificate == nil && config.GetConfigForClient == nil {
+		return nil, errors.New("failed to load TLS config: At least one of tls.Config.Certificates or 'CertFile and KeyFile' are required")
+	}
+
+	// Mutual TLS
+	if o.ClientCAFile != "" {
+		pem, err := os.ReadFile(o.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to load the client CA: no certificate in %s", o.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+	if o.ClientCAs != nil {
+		config.ClientCAs = o.ClientCAs
+	}
+
+	if o.ClientAuth != nil {
+		config.ClientAuth = *o.ClientAuth
+	} else if o.ClientCAFile != "" || o.ClientCAs != nil {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// Handler answering the HTTP-01 challenges of the ACME option. Other requests are passed to the fallback.
+//
+// The listeners with RedirectToHTTPS use it already. Use it to answer the challenges on another server.
+func (o *ACMEOption) HTTPHandler(fallback http.Handler) http.Handler {
+	manager, err := o.manager()
+	if err != nil {
+		return fallback
+	}
+
+	return manager.HTTPHandler(fallback)
+}
+
+// Get the manager of the option. (created once per option)
+func (o *ACMEOption) manager() (*autocert.Manager, error) {
+	if manager, ok := acmeManagers.Load(o); ok {
+		return manager.(*autocert.Manager), nil
+	}
+
+	if len(o.Domains) == 0 {
+		return nil, errors.New("failed to load TLS config: At least one domain is required to obtain the certificates by ACME")
+	}
+
+	directoryURL := o.DirectoryURL
+	if directoryURL == "" {
+		directoryURL = autocert.DefaultACMEDirectory
+	}
+
+	// The HTTP-01 requests have the port in the host, if the challenges are not answered on the port 80 (e.g. behind a port mapping)
+	whitelist := autocert.HostWhitelist(o.Domains...)
+	hostPolicy := func(ctx context.Context, host string) error {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		return whitelist(ctx, host)
+	}
+
+	manager := &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Email:       o.Email,
+		HostPolicy:  hostPolicy,
+		RenewBefore: o.RenewBefore,
+		Client:      &acme.Client{DirectoryURL: directoryURL, HTTPClient: o.HTTPClient},
+	}
+	if o.CacheDir != "" {
+		manager.Cache = autocert.DirCache(o.CacheDir)
+	}
+
+	actual, _ := acmeManagers.LoadOrStore(o, manager)
+	return actual.(*autocert.Manager), nil
+}
+
+// Get the identity of the client verified by mutual TLS. nil if the request is not TLS or the client certificate is not verified.
+func PeerIdentityOf(state *tls.ConnectionState) *PeerIdentity {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	chain := state.VerifiedChains[0]
+	cert := chain[0]
+
+	return &PeerIdentity{
+		Certificate:    cert,
+		Chain:          chain,
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		URIs:           cert.URIs,
+	}
+}
+
+func newCertificateReloader(certFile, keyFile string, interval time.Duration) (*certificateReloader, error) {
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	r.checkedAt = time.Now()
+	return r, nil
+}
+
+// Load the certificate files if they changed after the last load
+func (r *certificateReloader) reload() error {
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS config: %w", err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS config: %w", err)
+	}
+
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
+
+// Get the certificate for the handshake. The files are checked once per interval.
+//
+// NOTE: If the changed files fail to load (e.g. the key is not written yet), the previous certificate is used until the next check.
+func (r *certificateReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		_ = r.reload()
+	}
+
+	return r.cert, nil
+}
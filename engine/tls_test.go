@@ -0,0 +1,89 @@
+package engine_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/engine/enginetest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ACME", func() {
+	It("should require a domain", func() {
+		_, err := (&engine.TLSOption{ACME: &engine.ACMEOption{}}).TLSConfig()
+		Expect(err).To(MatchError(ContainSubstring("At least one domain")))
+	})
+
+	It("should get the certificates on the handshakes and negotiate the TLS-ALPN-01 challenges", func() {
+		config, err := (&engine.TLSOption{ACME: &engine.ACMEOption{Domains: []string{"example.com"}}}).TLSConfig()
+		Expect(err).To(BeNil())
+
+		Expect(config.GetCertificate).NotTo(BeNil())
+		Expect(config.NextProtos).To(Equal([]string{"http/1.1", "acme-tls/1"}))
+
+		// Rejected by the host policy, without contacting the ACME server
+		_, err = config.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+		Expect(err).To(MatchError(ContainSubstring("not configured")))
+	})
+
+	It("should answer the HTTP-01 challenges of the domains and pass the other requests", func() {
+		handler := (&engine.ACMEOption{Domains: []string{"example.com"}}).HTTPHandler(engine.RedirectHandler(8443))
+		serve := func(host, path string) int {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "http://"+host+path, nil))
+			return w.Code
+		}
+
+		Expect(serve("example.com:8080", "/.well-known/acme-challenge/unknown")).To(Equal(http.StatusNotFound))
+		Expect(serve("other.example.com", "/.well-known/acme-challenge/unknown")).To(Equal(http.StatusForbidden))
+		Expect(serve("example.com", "/users")).To(Equal(http.StatusPermanentRedirect))
+	})
+})
+
+var _ = Describe("TLSOption", func() {
+	var certFile, keyFile string
+
+	BeforeEach(func() {
+		cert, err := enginetest.GenerateCertificate()
+		Expect(err).To(BeNil())
+
+		dir := GinkgoT().TempDir()
+		certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+		Expect(os.WriteFile(certFile, cert.CertPEM, 0o600)).To(Succeed())
+		Expect(os.WriteFile(keyFile, cert.KeyPEM, 0o600)).To(Succeed())
+	})
+
+	It("should reload the certificate files with a reload interval", func() {
+		config, err := (&engine.TLSOption{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Minute}).TLSConfig()
+		Expect(err).To(BeNil())
+
+		Expect(config.Certificates).To(BeEmpty())
+		Expect(config.GetCertificate).NotTo(BeNil())
+	})
+
+	It("should require the client certificates with a client CA, unless the mode is set", func() {
+		config, err := (&engine.TLSOption{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile}).TLSConfig()
+		Expect(err).To(BeNil())
+		Expect(config.ClientAuth).To(Equal(tls.RequireAndVerifyClientCert))
+
+		mode := tls.NoClientCert
+		config, err = (&engine.TLSOption{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, ClientAuth: &mode}).TLSConfig()
+		Expect(err).To(BeNil())
+		Expect(config.ClientAuth).To(Equal(tls.NoClientCert))
+		Expect(config.ClientCAs).NotTo(BeNil())
+	})
+
+	It("should load the certificate files once by default", func() {
+		config, err := (&engine.TLSOption{CertFile: certFile, KeyFile: keyFile}).TLSConfig()
+		Expect(err).To(BeNil())
+
+		Expect(config.Certificates).To(HaveLen(1))
+		Expect(config.GetCertificate).To(BeNil())
+	})
+})
@@ -0,0 +1,198 @@
+// File: exception-filter.go
+//
+// This file defines the exception filter interface, its provider and the default exception filter.
+// Exception filters map the errors (and panics) of the handlers to the responses.
+package exception
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/jhseong7/ecl"
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/provider"
+)
+
+type (
+	// Interface the exception filters must implement.
+	IExceptionFilter interface {
+		// Catch the error of the handler.
+		//
+		// Write the response and return nil to mark the error as handled.
+		// Return an error (the same or a mapped one) to pass it to the next filter.
+		Catch(ctx controller.ExecutionContext, err error) error
+	}
+
+	// Function type that implements IExceptionFilter.
+	ExceptionFilterFunc func(ctx controller.ExecutionContext, err error) error
+
+	// Structure to define an exception filter with dependency injection support.
+	ExceptionFilterProvider struct {
+		provider.Provider
+	}
+
+	ExceptionFilterOption struct {
+		Name         string
+		Instantiator interface{}
+	}
+
+	// RFC 9457 problem details
+	ProblemDetails struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail,omitempty"`
+		Instance string `json:"instance,omitempty"`
+
+		// Extension members. These are flattened into the problem details object.
+		Extensions map[string]interface{} `json:"-"`
+	}
+
+	// The default exception filter. Writes the error as RFC 9457 "application/problem+json".
+	//
+	// Errors other than HttpException are written as 500 Internal Server Error, without exposing the error message.
+	ProblemDetailsFilter struct {
+		logger ecl.Logger
+	}
+)
+
+const (
+	HandlerName provider.ProviderHandlerName = "exception-filter"
+
+	ProblemJSONContentType = "application/problem+json"
+)
+
+var defaultFilter = NewProblemDetailsFilter()
+
+func (f ExceptionFilterFunc) Catch(ctx controller.ExecutionContext, err error) error {
+	return f(ctx, err)
+}
+
+// Flatten the extension members into the problem details object
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	m["type"] = p.Type
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+// Convert the http exception to the problem details
+func (e *HttpException) ToProblemDetails(instance string) ProblemDetails {
+	problemType := e.Type
+	if problemType == "" {
+		problemType = "about:blank"
+	}
+
+	return ProblemDetails{
+		Type:       problemType,
+		Title:      e.GetTitle(),
+		Status:     e.Status,
+		Detail:     e.Message,
+		Instance:   instance,
+		Extensions: e.Details,
+	}
+}
+
+func (f *ProblemDetailsFilter) Catch(ctx controller.ExecutionContext, err error) error {
+	exception := ToHttpException(err)
+
+	// Log the server errors, as they are not exposed to the client
+	if exception.Status >= http.StatusInternalServerError {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			f.logger.Errorf("Panic recovered on %s %s: %v\n%s", ctx.Method(), ctx.Path(), panicErr.Value, panicErr.Stack)
+		} else {
+			f.logger.Errorf("Error on %s %s: %s", ctx.Method(), ctx.Path(), err)
+		}
+	}
+
+	// Nothing can be done if the handler already wrote the response
+	if ctx.Written() {
+		return nil
+	}
+
+	body, marshalErr := json.Marshal(exception.ToProblemDetails(ctx.Path()))
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	return ctx.Blob(exception.Status, ProblemJSONContentType, body)
+}
+
+// Convert any error to an http exception.
+//
+// Errors other than HttpException are converted to 500 Internal Server Error with the error as the cause.
+func ToHttpException(err error) *HttpException {
+	var exception *HttpException
+	if errors.As(err, &exception) {
+		return exception
+	}
+
+	return InternalServerError().WithCause(err)
+}
+
+// Convert the recovered panic value to an http exception.
+func FromPanic(recovered interface{}) *HttpException {
+	// Keep the exception as is if the panic was an http exception
+	if exception, ok := recovered.(*HttpException); ok {
+		return exception
+	}
+
+	return InternalServerError().WithCause(&PanicError{Value: recovered, Stack: debug.Stack()})
+}
+
+// Pass the error through the exception filters.
+//
+// The filters are called in the given order until one handles the error.
+// If none of the filters handles the error, the default problem details filter writes the response.
+func Handle(ctx controller.ExecutionContext, filters []IExceptionFilter, err error) {
+	for _, f := range filters {
+		if err = f.Catch(ctx, err); err == nil {
+			return
+		}
+	}
+
+	if err := defaultFilter.Catch(ctx, err); err != nil {
+		defaultFilter.logger.Errorf("Failed to write the error response: %s", err)
+	}
+}
+
+// Define an exception filter
+func DefineExceptionFilter(option ExceptionFilterOption) *ExceptionFilterProvider {
+	// Check if the instantiator's result type implements IExceptionFilter.
+	returnType := reflect.TypeOf(option.Instantiator).Out(0)
+	if !returnType.Implements(reflect.TypeOf((*IExceptionFilter)(nil)).Elem()) {
+		panic(fmt.Sprintf("Exception filter %s's instantiator's result type does not implement IExceptionFilter", returnType))
+	}
+
+	return &ExceptionFilterProvider{
+		Provider: provider.Provider{
+			Name:         option.Name,
+			Instantiator: option.Instantiator,
+			Handler:      HandlerName,
+		},
+	}
+}
+
+// Create the default problem details filter
+func NewProblemDetailsFilter() *ProblemDetailsFilter {
+	return &ProblemDetailsFilter{
+		logger: ecl.NewLogger(ecl.LoggerOption{Name: "ExceptionFilter"}),
+	}
+}
@@ -0,0 +1,91 @@
+package exception_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/exception"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Execution context that records the written response
+type recordingContext struct {
+	controller.ExecutionContext
+
+	status      int
+	contentType string
+	body        []byte
+}
+
+func (c *recordingContext) Method() string { return "GET" }
+func (c *recordingContext) Path() string   { return "/users/1" }
+func (c *recordingContext) Written() bool  { return c.status != 0 }
+func (c *recordingContext) Blob(status int, contentType string, data []byte) error {
+	c.status, c.contentType, c.body = status, contentType, data
+	return nil
+}
+
+var _ = Describe("ExceptionFilter", func() {
+
+	Context("Test default problem details", func() {
+		It("Writes the http exception as problem details", func() {
+			ctx := &recordingContext{}
+			exception.Handle(ctx, nil, exception.NotFound("User not found").WithDetail("id", "1"))
+
+			Expect(ctx.status).To(Equal(404))
+			Expect(ctx.contentType).To(Equal(exception.ProblemJSONContentType))
+
+			body := map[string]interface{}{}
+			Expect(json.Unmarshal(ctx.body, &body)).To(Succeed())
+			Expect(body).To(Equal(map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Not Found",
+				"status":   float64(404),
+				"detail":   "User not found",
+				"instance": "/users/1",
+				"id":       "1",
+			}))
+		})
+
+		It("Hides the message of unknown errors", func() {
+			ctx := &recordingContext{}
+			exception.Handle(ctx, nil, errors.New("db password is wrong"))
+
+			Expect(ctx.status).To(Equal(500))
+			Expect(string(ctx.body)).ToNot(ContainSubstring("password"))
+		})
+
+		It("Converts panics to internal server errors", func() {
+			Expect(exception.FromPanic("boom").Status).To(Equal(500))
+			Expect(exception.FromPanic(exception.Conflict()).Status).To(Equal(409))
+		})
+	})
+
+	Context("Test filter chain", func() {
+		It("Passes the error to the next filter until handled", func() {
+			ctx := &recordingContext{}
+			calls := []string{}
+
+			mapper := exception.ExceptionFilterFunc(func(ctx controller.ExecutionContext, err error) error {
+				calls = append(calls, "mapper")
+				return exception.Conflict(err.Error())
+			})
+			handler := exception.ExceptionFilterFunc(func(ctx controller.ExecutionContext, err error) error {
+				calls = append(calls, "handler")
+				return ctx.Blob(exception.ToHttpException(err).Status, "text/plain", []byte(err.Error()))
+			})
+			skipped := exception.ExceptionFilterFunc(func(ctx controller.ExecutionContext, err error) error {
+				calls = append(calls, "skipped")
+				return err
+			})
+
+			exception.Handle(ctx, []exception.IExceptionFilter{mapper, handler, skipped}, errors.New("duplicate"))
+
+			Expect(calls).To(Equal([]string{"mapper", "handler"}))
+			Expect(ctx.status).To(Equal(409))
+			Expect(ctx.contentType).To(Equal("text/plain"))
+		})
+	})
+})
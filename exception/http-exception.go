@@ -0,0 +1,193 @@
+// File: http-exception.go
+//
+// This file defines the typed http errors.
+// Returning these errors from the handlers (or interceptors) will result in the matching http response on every engine.
+package exception
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type (
+	// Error with the http status code and the details to describe the problem.
+	//
+	// The fields are mapped to the RFC 9457 problem details by the default exception filter.
+	HttpException struct {
+		// HTTP status code of the response
+		Status int
+
+		// Short summary of the problem type. Defaults to the status text of the status code.
+		Title string
+
+		// Human readable explanation of the problem. (the "detail" of the problem details)
+		Message string
+
+		// URI reference that identifies the problem type. Defaults to "about:blank".
+		Type string
+
+		// Extra members of the problem details. (e.g. validation errors)
+		Details map[string]interface{}
+
+		// The underlying error. This is not exposed to the client.
+		Cause error
+	}
+
+	// Error to wrap the value of a recovered panic.
+	PanicError struct {
+		Value interface{}
+		Stack []byte
+	}
+)
+
+func (e *HttpException) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%d %s: %s (%s)", e.Status, e.GetTitle(), e.Message, e.Cause)
+	}
+
+	return fmt.Sprintf("%d %s: %s", e.Status, e.GetTitle(), e.Message)
+}
+
+func (e *HttpException) Unwrap() error {
+	return e.Cause
+}
+
+// Get the title of the exception. Defaults to the status text of the status code.
+func (e *HttpException) GetTitle() string {
+	if e.Title != "" {
+		return e.Title
+	}
+
+	return http.StatusText(e.Status)
+}
+
+// Add a detail member to the exception. Returns the exception itself for chaining.
+func (e *HttpException) WithDetail(key string, value interface{}) *HttpException {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+
+	e.Details[key] = value
+	return e
+}
+
+// Set the underlying cause of the exception. Returns the exception itself for chaining.
+func (e *HttpException) WithCause(err error) *HttpException {
+	e.Cause = err
+	return e
+}
+
+// Set the problem type URI of the exception. Returns the exception itself for chaining.
+func (e *HttpException) WithType(problemType string) *HttpException {
+	e.Type = problemType
+	return e
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Create a new http exception with the given status code.
+//
+// If the message is not given, the status text of the status code is used.
+func New(status int, message ...string) *HttpException {
+	msg := http.StatusText(status)
+	if len(message) > 0 {
+		msg = message[0]
+	}
+
+	return &HttpException{
+		Status:  status,
+		Message: msg,
+	}
+}
+
+// 400 Bad Request
+func BadRequest(message ...string) *HttpException {
+	return New(http.StatusBadRequest, message...)
+}
+
+// 401 Unauthorized
+func Unauthorized(message ...string) *HttpException {
+	return New(http.StatusUnauthorized, message...)
+}
+
+// 403 Forbidden
+func Forbidden(message ...string) *HttpException {
+	return New(http.StatusForbidden, message...)
+}
+
+// 404 Not Found
+func NotFound(message ...string) *HttpException {
+	return New(http.StatusNotFound, message...)
+}
+
+// 405 Method Not Allowed
+func MethodNotAllowed(message ...string) *HttpException {
+	return New(http.StatusMethodNotAllowed, message...)
+}
+
+// 406 Not Acceptable
+func NotAcceptable(message ...string) *HttpException {
+	return New(http.StatusNotAcceptable, message...)
+}
+
+// 408 Request Timeout
+func RequestTimeout(message ...string) *HttpException {
+	return New(http.StatusRequestTimeout, message...)
+}
+
+// 409 Conflict
+func Conflict(message ...string) *HttpException {
+	return New(http.StatusConflict, message...)
+}
+
+// 410 Gone
+func Gone(message ...string) *HttpException {
+	return New(http.StatusGone, message...)
+}
+
+// 413 Payload Too Large
+func PayloadTooLarge(message ...string) *HttpException {
+	return New(http.StatusRequestEntityTooLarge, message...)
+}
+
+// 415 Unsupported Media Type
+func UnsupportedMediaType(message ...string) *HttpException {
+	return New(http.StatusUnsupportedMediaType, message...)
+}
+
+// 422 Unprocessable Entity
+func UnprocessableEntity(message ...string) *HttpException {
+	return New(http.StatusUnprocessableEntity, message...)
+}
+
+// 429 Too Many Requests
+func TooManyRequests(message ...string) *HttpException {
+	return New(http.StatusTooManyRequests, message...)
+}
+
+// 500 Internal Server Error
+func InternalServerError(message ...string) *HttpException {
+	return New(http.StatusInternalServerError, message...)
+}
+
+// 501 Not Implemented
+func NotImplemented(message ...string) *HttpException {
+	return New(http.StatusNotImplemented, message...)
+}
+
+// 502 Bad Gateway
+func BadGateway(message ...string) *HttpException {
+	return New(http.StatusBadGateway, message...)
+}
+
+// 503 Service Unavailable
+func ServiceUnavailable(message ...string) *HttpException {
+	return New(http.StatusServiceUnavailable, message...)
+}
+
+// 504 Gateway Timeout
+func GatewayTimeout(message ...string) *HttpException {
+	return New(http.StatusGatewayTimeout, message...)
+}
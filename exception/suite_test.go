@@ -0,0 +1,13 @@
+package exception_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestException(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Exception Suite")
+}
@@ -5,9 +5,16 @@ import (
 	"github.com/jhseong7/gimbap/app"
 	"github.com/jhseong7/gimbap/controller"
 	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/exception"
+	"github.com/jhseong7/gimbap/interceptor"
 	"github.com/jhseong7/gimbap/microservice"
+	"github.com/jhseong7/gimbap/middleware"
 	"github.com/jhseong7/gimbap/module"
+	"github.com/jhseong7/gimbap/openapi"
 	"github.com/jhseong7/gimbap/provider"
+	"github.com/jhseong7/gimbap/route"
+	"github.com/jhseong7/gimbap/versioning"
+	"github.com/jhseong7/gimbap/view"
 )
 
 // type aliases for public apis
@@ -16,7 +23,15 @@ type (
 	AppOption      = app.AppOption
 	GimbapApp      = app.GimbapApp
 	RuntimeOptions = app.RuntimeOptions
+	ShutdownOption = app.ShutdownOption
+	ShutdownReport = app.ShutdownReport
+	RestartOption  = app.RestartOption
+	PreforkOption  = app.PreforkOption
 	TLSOption      = engine.TLSOption
+	ACMEOption     = engine.ACMEOption
+	ListenerOption = engine.ListenerOption
+	ProtocolOption = engine.ProtocolOption
+	StaticOption   = engine.StaticOption
 
 	// Module related
 	ModuleOption = module.ModuleOption
@@ -31,10 +46,59 @@ type (
 	ControllerOption = controller.ControllerOption
 	Controller       = controller.Controller
 	RouteSpec        = controller.RouteSpec
+	ExecutionContext = controller.ExecutionContext
+	StreamWriter     = controller.StreamWriter
+	Responder        = controller.Responder
+
+	// Interceptor related
+	IInterceptor        = interceptor.IInterceptor
+	InterceptorFunc     = interceptor.InterceptorFunc
+	CallHandler         = interceptor.CallHandler
+	InterceptorProvider = interceptor.InterceptorProvider
+	InterceptorOption   = interceptor.InterceptorOption
+
+	// Middleware related
+	IMiddleware        = middleware.IMiddleware
+	MiddlewareProvider = middleware.MiddlewareProvider
+	MiddlewareOption   = middleware.MiddlewareOption
+
+	// Exception related
+	HttpException           = exception.HttpException
+	IExceptionFilter        = exception.IExceptionFilter
+	ExceptionFilterFunc     = exception.ExceptionFilterFunc
+	ExceptionFilterProvider = exception.ExceptionFilterProvider
+	ExceptionFilterOption   = exception.ExceptionFilterOption
+	ProblemDetails          = exception.ProblemDetails
 
 	// Engine related
-	IServerEngine      = engine.IServerEngine
-	ServerEngineOption = engine.ServerEngineOption
+	IServerEngine            = engine.IServerEngine
+	IControllerSpecRegistrar = engine.IControllerSpecRegistrar
+	IGlobalApiPrefixProvider = engine.IGlobalApiPrefixProvider
+	IPathRewritable          = engine.IPathRewritable
+	IMountable               = engine.IMountable
+	IAddressReporter         = engine.IAddressReporter
+	IHandlerProvider         = engine.IHandlerProvider
+	IRequestReporter         = engine.IRequestReporter
+	ControllerSpec           = engine.ControllerSpec
+	ServerEngineOption       = engine.ServerEngineOption
+	PeerIdentity             = engine.PeerIdentity
+
+	// OpenAPI related
+	OpenApiOption        = openapi.Option
+	OpenApiDocument      = openapi.Document
+	ISecurityRequirement = openapi.ISecurityRequirement
+
+	// Route related
+	RouteInfo        = route.RouteInfo
+	RouteTableFormat = route.TableFormat
+
+	// Versioning related
+	VersioningOption = versioning.Option
+
+	// View related
+	ViewOption  = view.Option
+	View        = view.View
+	IViewEngine = view.IViewEngine
 
 	// Microservice related
 	IMicroService              = microservice.IMicroService
@@ -57,6 +121,13 @@ func GetProvider[T interface{}](a GimbapApp, prov T) (ret T) {
 	return app.GetProvider(a, prov)
 }
 
+// Get the identity of the client verified by mutual TLS.
+//
+// Returns nil if the request is not TLS or the client certificate is not verified.
+func GetPeerIdentity(ctx ExecutionContext) *PeerIdentity {
+	return engine.PeerIdentityOf(ctx.TLS())
+}
+
 // Define a module.
 //
 // This defines a module with the given option.
@@ -87,3 +158,24 @@ func DefineController(option ControllerOption) *Controller {
 func DefineMicroService(option MicroServiceProviderOption) *MicroServiceProvider {
 	return microservice.DefineMicroService(option)
 }
+
+// Define an interceptor
+//
+// Define a special provider for interceptors. The interceptor can be used in the app, controllers and routes.
+func DefineInterceptor(option InterceptorOption) *InterceptorProvider {
+	return interceptor.DefineInterceptor(option)
+}
+
+// Define an exception filter
+//
+// Define a special provider for exception filters. The filter can be used in the app, controllers and routes.
+func DefineExceptionFilter(option ExceptionFilterOption) *ExceptionFilterProvider {
+	return exception.DefineExceptionFilter(option)
+}
+
+// Define a middleware
+//
+// Define a special provider for middlewares. The instance provides the engine native middleware with dependency injection support.
+func DefineMiddleware(option MiddlewareOption) *MiddlewareProvider {
+	return middleware.DefineMiddleware(option)
+}
@@ -0,0 +1,94 @@
+// File: interceptor.go
+//
+// This file defines the interceptor interface and its provider.
+// Interceptors wrap the execution of controller handlers, and can run logic before and after the handler.
+package interceptor
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/provider"
+)
+
+type (
+	// Function to call the next interceptor in the chain (or the handler itself if it is the last one).
+	//
+	// Returns the result value of the handler and the error if any.
+	CallHandler func() (interface{}, error)
+
+	// Interface the interceptors must implement.
+	IInterceptor interface {
+		// Intercept the handler execution.
+		//
+		// Call next() to continue the execution. Skipping next() short-circuits the handler,
+		// and the returned value will be used as the response instead.
+		// The value returned by next() can be transformed before returning it.
+		Intercept(ctx controller.ExecutionContext, next CallHandler) (interface{}, error)
+	}
+
+	// Function type that implements IInterceptor.
+	// Useful to define simple interceptors without a struct.
+	InterceptorFunc func(ctx controller.ExecutionContext, next CallHandler) (interface{}, error)
+
+	// Structure to define an interceptor with dependency injection support.
+	InterceptorProvider struct {
+		provider.Provider
+	}
+
+	InterceptorOption struct {
+		Name         string
+		Instantiator interface{}
+	}
+)
+
+const (
+	HandlerName provider.ProviderHandlerName = "interceptor"
+)
+
+func (f InterceptorFunc) Intercept(ctx controller.ExecutionContext, next CallHandler) (interface{}, error) {
+	return f(ctx, next)
+}
+
+// Check if the input implements the IInterceptor interface.
+func IsInterceptor(i interface{}) bool {
+	_, ok := i.(IInterceptor)
+	return ok
+}
+
+// Run the handler through the interceptors.
+//
+// The interceptors are called in the given order. (the first one is the outermost)
+func Run(ctx controller.ExecutionContext, interceptors []IInterceptor, handler CallHandler) (interface{}, error) {
+	next := handler
+
+	// Wrap the handler from the innermost interceptor
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		current := interceptors[i]
+		inner := next
+
+		next = func() (interface{}, error) {
+			return current.Intercept(ctx, inner)
+		}
+	}
+
+	return next()
+}
+
+// Define an interceptor
+func DefineInterceptor(option InterceptorOption) *InterceptorProvider {
+	// Check if the instantiator's result type implements IInterceptor.
+	returnType := reflect.TypeOf(option.Instantiator).Out(0)
+	if !returnType.Implements(reflect.TypeOf((*IInterceptor)(nil)).Elem()) {
+		panic(fmt.Sprintf("Interceptor %s's instantiator's result type does not implement IInterceptor", returnType))
+	}
+
+	return &InterceptorProvider{
+		Provider: provider.Provider{
+			Name:         option.Name,
+			Instantiator: option.Instantiator,
+			Handler:      HandlerName,
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package interceptor_test
+
+import (
+	"errors"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/interceptor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Interceptor that records the call order
+func recorder(name string, calls *[]string) interceptor.IInterceptor {
+	return interceptor.InterceptorFunc(func(ctx controller.ExecutionContext, next interceptor.CallHandler) (interface{}, error) {
+		*calls = append(*calls, name+":before")
+		v, err := next()
+		*calls = append(*calls, name+":after")
+		return v, err
+	})
+}
+
+var _ = Describe("Interceptor", func() {
+
+	Context("Test Run", func() {
+		It("Runs the interceptors in order around the handler", func() {
+			calls := []string{}
+
+			result, err := interceptor.Run(nil, []interceptor.IInterceptor{
+				recorder("A", &calls),
+				recorder("B", &calls),
+			}, func() (interface{}, error) {
+				calls = append(calls, "handler")
+				return "value", nil
+			})
+
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal("value"))
+			Expect(calls).To(Equal([]string{"A:before", "B:before", "handler", "B:after", "A:after"}))
+		})
+
+		It("Short-circuits the handler if next is not called", func() {
+			handlerCalled := false
+
+			result, err := interceptor.Run(nil, []interceptor.IInterceptor{
+				interceptor.InterceptorFunc(func(ctx controller.ExecutionContext, next interceptor.CallHandler) (interface{}, error) {
+					return "cached", nil
+				}),
+			}, func() (interface{}, error) {
+				handlerCalled = true
+				return "value", nil
+			})
+
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal("cached"))
+			Expect(handlerCalled).To(BeFalse())
+		})
+
+		It("Transforms the result and observes the errors", func() {
+			handlerErr := errors.New("failed")
+			var observed error
+
+			envelope := interceptor.InterceptorFunc(func(ctx controller.ExecutionContext, next interceptor.CallHandler) (interface{}, error) {
+				v, err := next()
+				if err != nil {
+					observed = err
+					return nil, err
+				}
+				return map[string]interface{}{"data": v}, nil
+			})
+
+			result, err := interceptor.Run(nil, []interceptor.IInterceptor{envelope}, func() (interface{}, error) {
+				return 1, nil
+			})
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(map[string]interface{}{"data": 1}))
+
+			_, err = interceptor.Run(nil, []interceptor.IInterceptor{envelope}, func() (interface{}, error) {
+				return nil, handlerErr
+			})
+			Expect(err).To(Equal(handlerErr))
+			Expect(observed).To(Equal(handlerErr))
+		})
+	})
+})
@@ -0,0 +1,62 @@
+// File: middleware.go
+//
+// This file defines the middleware provider.
+// Middleware providers create the engine native middlewares with dependency injection support.
+package middleware
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jhseong7/gimbap/provider"
+)
+
+type (
+	// Interface the middleware providers' instances must implement.
+	IMiddleware interface {
+		// Returns the engine native middleware. (e.g. gin.HandlerFunc, echo.MiddlewareFunc, fiber.Handler)
+		Middleware() interface{}
+	}
+
+	// Structure to define a middleware with dependency injection support.
+	MiddlewareProvider struct {
+		provider.Provider
+	}
+
+	MiddlewareOption struct {
+		Name         string
+		Instantiator interface{}
+	}
+)
+
+const (
+	HandlerName provider.ProviderHandlerName = "middleware"
+)
+
+// Get the engine native middleware from the value.
+//
+// If the value implements IMiddleware, the native middleware it returns is used. Otherwise the value is returned as is.
+func ToNative(m interface{}) interface{} {
+	if casted, ok := m.(IMiddleware); ok {
+		return casted.Middleware()
+	}
+
+	return m
+}
+
+// Define a middleware
+func DefineMiddleware(option MiddlewareOption) *MiddlewareProvider {
+	// Check if the instantiator's result type implements IMiddleware.
+	returnType := reflect.TypeOf(option.Instantiator).Out(0)
+	if !returnType.Implements(reflect.TypeOf((*IMiddleware)(nil)).Elem()) {
+		panic(fmt.Sprintf("Middleware %s's instantiator's result type does not implement IMiddleware", returnType))
+	}
+
+	return &MiddlewareProvider{
+		Provider: provider.Provider{
+			Name:         option.Name,
+			Instantiator: option.Instantiator,
+			Handler:      HandlerName,
+		},
+	}
+}
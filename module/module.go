@@ -46,39 +46,6 @@ type (
 // Logger for the module initialization
 var log = ecl.NewLogger(ecl.LoggerOption{Name: "DefineModule", AppName: "GIMBAP"})
 
-// Extract the embedded provider from the interface. If the interface is a provider, return the provider.
-func extractEmbeddedProvider(p interface{}) (*provider.Provider, bool) {
-	// Return the provider if it is a provider
-	if p, ok := p.(*provider.Provider); ok {
-		return p, true
-	}
-
-	v := reflect.ValueOf(p)
-
-	if v.Kind() != reflect.Ptr {
-		return nil, false
-	}
-
-	// Dereference the pointer
-	v = v.Elem()
-
-	// For the fields of the struct, check if it is a provider
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := v.Type().Field(i)
-
-		// If the type is an embedded struct --> check if it is a provider
-		if fieldType.Anonymous {
-			if field.Type() == reflect.TypeOf(provider.Provider{}) {
-				prov := field.Interface().(provider.Provider)
-				return &prov, true
-			}
-		}
-	}
-
-	return nil, false
-}
-
 // Get the key of the provider.
 func getKeyFromProvider(p provider.Provider) ProviderKey {
 	// Get the return type of the Instantiator
@@ -108,7 +75,7 @@ func DefineModule(option ModuleOption) *Module {
 			}
 
 			for _, p := range providerMap {
-				casted, ok := extractEmbeddedProvider(p)
+				casted, ok := provider.ExtractProvider(p)
 				if !ok {
 					log.Panicf("Provider %v is not a provider", p)
 				}
@@ -0,0 +1,148 @@
+// File: document.go
+//
+// This file defines the OpenAPI 3.1 document model.
+// Only the parts of the specification used by the generator are defined.
+package openapi
+
+type (
+	Document struct {
+		OpenAPI    string                `json:"openapi"`
+		Info       Info                  `json:"info"`
+		Servers    []Server              `json:"servers,omitempty"`
+		Tags       []Tag                 `json:"tags,omitempty"`
+		Paths      map[string]*PathItem  `json:"paths"`
+		Components Components            `json:"components"`
+		Security   []SecurityRequirement `json:"security,omitempty"`
+	}
+
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+
+	Server struct {
+		URL         string `json:"url"`
+		Description string `json:"description,omitempty"`
+	}
+
+	Tag struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	}
+
+	PathItem struct {
+		Get     *Operation `json:"get,omitempty"`
+		Put     *Operation `json:"put,omitempty"`
+		Post    *Operation `json:"post,omitempty"`
+		Delete  *Operation `json:"delete,omitempty"`
+		Options *Operation `json:"options,omitempty"`
+		Head    *Operation `json:"head,omitempty"`
+		Patch   *Operation `json:"patch,omitempty"`
+	}
+
+	Operation struct {
+		Tags        []string              `json:"tags,omitempty"`
+		Summary     string                `json:"summary,omitempty"`
+		Description string                `json:"description,omitempty"`
+		OperationID string                `json:"operationId,omitempty"`
+		Parameters  []*Parameter          `json:"parameters,omitempty"`
+		RequestBody *RequestBody          `json:"requestBody,omitempty"`
+		Responses   map[string]*Response  `json:"responses"`
+		Security    []SecurityRequirement `json:"security,omitempty"`
+	}
+
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"` // path, query, header, cookie
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema,omitempty"`
+	}
+
+	RequestBody struct {
+		Description string               `json:"description,omitempty"`
+		Required    bool                 `json:"required,omitempty"`
+		Content     map[string]MediaType `json:"content"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	Components struct {
+		Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	SecurityScheme struct {
+		Type             string `json:"type"` // apiKey, http, mutualTLS, oauth2, openIdConnect
+		Description      string `json:"description,omitempty"`
+		Name             string `json:"name,omitempty"` // apiKey
+		In               string `json:"in,omitempty"`   // apiKey
+		Scheme           string `json:"scheme,omitempty"`
+		BearerFormat     string `json:"bearerFormat,omitempty"`
+		OpenIdConnectURL string `json:"openIdConnectUrl,omitempty"`
+	}
+
+	// Map of the security scheme names to the required scopes
+	SecurityRequirement map[string][]string
+
+	// JSON Schema (2020-12) used by OpenAPI 3.1
+	Schema struct {
+		Ref         string `json:"$ref,omitempty"`
+		Type        string `json:"type,omitempty"`
+		Format      string `json:"format,omitempty"`
+		Description string `json:"description,omitempty"`
+
+		// Object
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+		// Array
+		Items    *Schema `json:"items,omitempty"`
+		MinItems *int    `json:"minItems,omitempty"`
+		MaxItems *int    `json:"maxItems,omitempty"`
+
+		// String
+		MinLength *int   `json:"minLength,omitempty"`
+		MaxLength *int   `json:"maxLength,omitempty"`
+		Pattern   string `json:"pattern,omitempty"`
+
+		// Number
+		Minimum          *float64 `json:"minimum,omitempty"`
+		Maximum          *float64 `json:"maximum,omitempty"`
+		ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+		ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+
+		Enum []interface{} `json:"enum,omitempty"`
+	}
+)
+
+// Get the operation of the method in the path item
+func (p *PathItem) operation(method string) **Operation {
+	switch method {
+	case "GET":
+		return &p.Get
+	case "PUT":
+		return &p.Put
+	case "POST":
+		return &p.Post
+	case "DELETE":
+		return &p.Delete
+	case "OPTIONS":
+		return &p.Options
+	case "HEAD":
+		return &p.Head
+	case "PATCH":
+		return &p.Patch
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+// File: generator.go
+//
+// This file generates the OpenAPI document from the controller specs registered to the app.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/exception"
+)
+
+type (
+	// Interface for the guards (interceptors or middleware instances) to declare the security requirement of the routes they protect.
+	ISecurityRequirement interface {
+		SecurityRequirement() SecurityRequirement
+	}
+
+	UIType string
+
+	Option struct {
+		Title       string
+		Version     string
+		Description string
+		Servers     []Server
+
+		// Security schemes of the document. The keys are referenced by the security requirements.
+		SecuritySchemes map[string]*SecurityScheme
+
+		// Security requirements applied to all operations
+		Security []SecurityRequirement
+
+		// Path to serve the documentation UI. The document is served at Path + "/openapi.json".
+		// The document is not served if the path is empty.
+		Path string
+
+		// The documentation UI to serve. (default: SwaggerUI)
+		UI UIType
+	}
+)
+
+const (
+	OpenAPIVersion = "3.1.0"
+
+	SwaggerUI UIType = "swagger"
+	Redoc     UIType = "redoc"
+	NoUI      UIType = "none"
+
+	problemSchemaName = "ProblemDetails"
+)
+
+// Convert the route path to the OpenAPI path templates. (e.g. /users/{id:int} --> /users/{id})
+//
+// Routes with an optional parameter have 2 templates (with and without the parameter), as OpenAPI path parameters are always required.
+// Also returns the path parameters of each template.
+func ConvertPath(path string) ([]string, [][]engine.PathToken) {
+	parsed, err := engine.ParsePath(path)
+	if err != nil {
+		return []string{path}, [][]engine.PathToken{{}}
+	}
+
+	templates := []string{}
+	params := [][]engine.PathToken{}
+	for _, p := range parsed.Expand() {
+		templates = append(templates, p.Format(func(t engine.PathToken) string {
+			if t.Kind == engine.StaticToken {
+				return t.Value
+			}
+
+			return "{" + t.Value + "}"
+		}))
+		params = append(params, p.Params())
+	}
+
+	return templates, params
+}
+
+// Get the schema of the path parameter by the constraint
+func pathParamSchema(t engine.PathToken) *Schema {
+	switch t.Constraint {
+	case "int":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "uint":
+		min := 0.0
+		return &Schema{Type: "integer", Format: "int64", Minimum: &min}
+	case "float":
+		return &Schema{Type: "number", Format: "double"}
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	case "alpha":
+		return &Schema{Type: "string", Pattern: "^[A-Za-z]+$"}
+	case "alnum":
+		return &Schema{Type: "string", Pattern: "^[A-Za-z0-9]+$"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// Collect the security requirements declared by the guards
+func collectSecurity(guards ...[]interface{}) []SecurityRequirement {
+	security := []SecurityRequirement{}
+
+	for _, list := range guards {
+		for _, g := range list {
+			if s, ok := g.(ISecurityRequirement); ok {
+				security = append(security, s.SecurityRequirement())
+			}
+		}
+	}
+
+	return security
+}
+
+// Get a readable operation id from the handler name. (e.g. main.(*UserController).GetUser-fm --> UserController.GetUser)
+func operationID(handler interface{}) string {
+	name := engine.RuntimeFuncName(handler)
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+}
+
+// Build the parameters and the request body from the request struct of the handler
+func (g *schemaGenerator) requestOf(op *Operation, method string, requestType reflect.Type) {
+	hasBody, hasParameters := false, false
+
+	forEachField(requestType, func(f reflect.StructField) {
+		loc := locationOf(f)
+		if loc.in == "body" {
+			hasBody = true
+			return
+		}
+		hasParameters = true
+
+		schema := g.schemaOf(f.Type)
+		required := applyValidationTag(schema, f) || loc.in == "path"
+
+		// Replace the parameter derived from the path with the typed one
+		for i, p := range op.Parameters {
+			if p.In == loc.in && p.Name == loc.name {
+				op.Parameters = append(op.Parameters[:i], op.Parameters[i+1:]...)
+				break
+			}
+		}
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        loc.name,
+			In:          loc.in,
+			Required:    required,
+			Description: f.Tag.Get("description"),
+			Schema:      schema,
+		})
+	})
+
+	// GET, HEAD, DELETE requests do not have a body
+	if !hasBody || method == "GET" || method == "HEAD" || method == "DELETE" {
+		return
+	}
+
+	// The request struct is the body itself if none of its fields is a parameter. (the parameters of the path template are not in the struct)
+	var schema *Schema
+	if !hasParameters {
+		schema = g.schemaOf(requestType)
+	} else {
+		schema = g.structSchema(requestType, func(f reflect.StructField) bool { return locationOf(f).in == "body" })
+	}
+
+	op.RequestBody = &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// Build the operation of the route
+func (g *schemaGenerator) operationOf(spec engine.ControllerSpec, route controller.RouteSpec, pathParams []engine.PathToken) *Operation {
+	op := &Operation{
+		Tags:        []string{spec.Name},
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: operationID(route.Handler),
+		Parameters:  []*Parameter{},
+		Responses:   map[string]*Response{},
+	}
+
+	for _, t := range pathParams {
+		op.Parameters = append(op.Parameters, &Parameter{Name: t.Value, In: "path", Required: true, Schema: pathParamSchema(t)})
+	}
+
+	requestType, responseType := engine.HandlerTypes(route.Handler)
+	if requestType != nil {
+		g.requestOf(op, route.Method, requestType)
+	}
+
+	// Success response
+	success := &Response{Description: http.StatusText(http.StatusOK)}
+	if responseType != nil && responseType.Kind() != reflect.Interface {
+		success.Content = map[string]MediaType{"application/json": {Schema: g.schemaOf(responseType)}}
+	}
+	op.Responses["200"] = success
+
+	// Errors are written as problem details by the exception filters
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content: map[string]MediaType{
+			exception.ProblemJSONContentType: {Schema: &Schema{Ref: "#/components/schemas/" + problemSchemaName}},
+		},
+	}
+
+	op.Security = collectSecurity(
+		interceptorsOf(spec.Interceptors), spec.Middlewares,
+		route.Interceptors, route.Middlewares,
+	)
+
+	return op
+}
+
+func interceptorsOf[T any](list []T) []interface{} {
+	casted := make([]interface{}, 0, len(list))
+	for _, v := range list {
+		casted = append(casted, v)
+	}
+
+	return casted
+}
+
+// Generate the OpenAPI document of the controllers.
+func Generate(option Option, globalApiPrefix string, specs []engine.ControllerSpec) *Document {
+	g := newSchemaGenerator()
+
+	doc := &Document{
+		OpenAPI: OpenAPIVersion,
+		Info: Info{
+			Title:       option.Title,
+			Version:     option.Version,
+			Description: option.Description,
+		},
+		Servers:  option.Servers,
+		Paths:    map[string]*PathItem{},
+		Security: option.Security,
+		Components: Components{
+			SecuritySchemes: option.SecuritySchemes,
+		},
+	}
+
+	if doc.Info.Title == "" {
+		doc.Info.Title = "API"
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "1.0.0"
+	}
+
+	usedOperationIDs := map[string]int{}
+	usedTags := map[string]bool{}
+
+	for _, spec := range specs {
+		// Versioned controllers are given as a spec per version
+		if !usedTags[spec.Name] {
+			usedTags[spec.Name] = true
+			doc.Tags = append(doc.Tags, Tag{Name: spec.Name})
+		}
+
+		for _, route := range spec.Routes {
+			fullPath := engine.MergeRestPath(globalApiPrefix, spec.RootPath, route.Path)
+			paths, pathParams := ConvertPath(fullPath)
+
+			for i, path := range paths {
+				if _, ok := doc.Paths[path]; !ok {
+					doc.Paths[path] = &PathItem{}
+				}
+
+				op := doc.Paths[path].operation(route.Method)
+				if op == nil {
+					continue
+				}
+
+				*op = g.operationOf(spec, route, pathParams[i])
+
+				// Operation ids must be unique in the document
+				usedOperationIDs[(*op).OperationID]++
+				if count := usedOperationIDs[(*op).OperationID]; count > 1 {
+					(*op).OperationID = fmt.Sprintf("%s_%d", (*op).OperationID, count)
+				}
+			}
+		}
+	}
+
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	// Schema of the errors written by the default exception filter
+	g.schemas[problemSchemaName] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":     {Type: "string", Format: "uri-reference"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer", Format: "int32"},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string", Format: "uri-reference"},
+		},
+		Required: []string{"type", "title", "status"},
+	}
+	doc.Components.Schemas = g.schemas
+
+	return doc
+}
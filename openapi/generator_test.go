@@ -0,0 +1,138 @@
+package openapi_test
+
+import (
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/interceptor"
+	"github.com/jhseong7/gimbap/openapi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type (
+	User struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name" validate:"required,min=2"`
+		Email string `json:"email,omitempty" validate:"email"`
+	}
+
+	UpdateUserRequest struct {
+		ID    int    `path:"id"`
+		Force bool   `query:"force"`
+		Name  string `json:"name" binding:"required"`
+	}
+
+	Item struct {
+		Name  string `json:"name" validate:"required"`
+		Count int    `json:"count"`
+	}
+
+	authGuard struct{}
+
+	UserController struct{}
+
+	ItemController struct{}
+)
+
+func (authGuard) Intercept(ctx controller.ExecutionContext, next interceptor.CallHandler) (interface{}, error) {
+	return next()
+}
+
+func (authGuard) SecurityRequirement() openapi.SecurityRequirement {
+	return openapi.SecurityRequirement{"bearer": {}}
+}
+
+func (c *UserController) GetUser(ctx controller.ExecutionContext) (*User, error) { return nil, nil }
+
+func (c *UserController) CreateUser(ctx controller.ExecutionContext, req User) (*User, error) {
+	return nil, nil
+}
+
+func (c *UserController) UpdateUser(ctx controller.ExecutionContext, req *UpdateUserRequest) (*User, error) {
+	return nil, nil
+}
+
+func (c *ItemController) PutItem(ctx controller.ExecutionContext, req Item) (*Item, error) {
+	return nil, nil
+}
+
+var _ = Describe("Generate", func() {
+	c := &UserController{}
+	specs := []engine.ControllerSpec{
+		{
+			Name:     "UserController",
+			RootPath: "users",
+			Routes: []controller.RouteSpec{
+				{Method: "GET", Path: ":id", Handler: c.GetUser, Summary: "Get a user"},
+				{Method: "POST", Path: "", Handler: c.CreateUser},
+				{Method: "PUT", Path: ":id", Handler: c.UpdateUser, Interceptors: []interface{}{authGuard{}}},
+			},
+		},
+		// Another version of the controller (versioned controllers are split by the version)
+		{
+			Name:     "UserController",
+			RootPath: "v2/users",
+			Routes:   []controller.RouteSpec{{Method: "GET", Path: ":id", Handler: c.GetUser}},
+		},
+		// Body type without parameter fields on a path with a parameter
+		{
+			Name:     "ItemController",
+			RootPath: "items",
+			Routes:   []controller.RouteSpec{{Method: "POST", Path: "{id}", Handler: (&ItemController{}).PutItem}},
+		},
+	}
+
+	doc := openapi.Generate(openapi.Option{Title: "Test"}, "api", specs)
+
+	It("Converts the engine paths to path templates", func() {
+		paths, params := openapi.ConvertPath("/files/{id:int}/{path...}")
+		Expect(paths).To(Equal([]string{"/files/{id}/{path}"}))
+		Expect(params[0]).To(HaveLen(2))
+
+		paths, _ = openapi.ConvertPath("/users/:id?")
+		Expect(paths).To(Equal([]string{"/users/{id}", "/users"}))
+
+		Expect(doc.Paths).To(HaveKey("/api/users/{id}"))
+		Expect(doc.Paths).To(HaveKey("/api/users"))
+	})
+
+	It("Generates the operations from the route specs", func() {
+		get := doc.Paths["/api/users/{id}"].Get
+		Expect(get.Summary).To(Equal("Get a user"))
+		Expect(get.OperationID).To(Equal("UserController.GetUser"))
+		Expect(get.Tags).To(Equal([]string{"UserController"}))
+		Expect(doc.Tags).To(HaveLen(2))
+		Expect(get.Parameters).To(HaveLen(1))
+		Expect(get.Parameters[0].In).To(Equal("path"))
+		Expect(get.Responses["200"].Content["application/json"].Schema.Ref).To(Equal("#/components/schemas/User"))
+		Expect(get.Responses).To(HaveKey("default"))
+	})
+
+	It("Maps the request structs and the validation tags", func() {
+		user := doc.Components.Schemas["User"]
+		Expect(user).ToNot(BeNil())
+		Expect(user.Required).To(ConsistOf("name"))
+		Expect(user.Properties["email"].Format).To(Equal("email"))
+		Expect(*user.Properties["name"].MinLength).To(Equal(2))
+
+		post := doc.Paths["/api/users"].Post
+		Expect(post.RequestBody.Content["application/json"].Schema.Ref).To(Equal("#/components/schemas/User"))
+
+		put := doc.Paths["/api/users/{id}"].Put
+		Expect(put.Parameters).To(HaveLen(2))
+		Expect(put.Parameters[0].Schema.Type).To(Equal("integer"))
+		Expect(put.Parameters[1].In).To(Equal("query"))
+		Expect(put.RequestBody.Content["application/json"].Schema.Required).To(ConsistOf("name"))
+
+		// The parameters of the path template do not make the body a partial schema
+		item := doc.Paths["/api/items/{id}"].Post
+		Expect(item.Parameters).To(HaveLen(1))
+		Expect(item.RequestBody.Content["application/json"].Schema.Ref).To(Equal("#/components/schemas/Item"))
+		Expect(doc.Components.Schemas).To(HaveKey("Item"))
+	})
+
+	It("Adds the security requirements of the guards", func() {
+		Expect(doc.Paths["/api/users/{id}"].Put.Security).To(Equal([]openapi.SecurityRequirement{{"bearer": {}}}))
+		Expect(doc.Paths["/api/users/{id}"].Get.Security).To(BeEmpty())
+	})
+})
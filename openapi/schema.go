@@ -0,0 +1,298 @@
+// File: schema.go
+//
+// This file generates the JSON schemas from the go types.
+// The validation tags ("validate" and gin's "binding") are mapped to the schema constraints.
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhseong7/gimbap/engine"
+)
+
+type (
+	// Generates the schemas and registers the named struct types as components.
+	schemaGenerator struct {
+		schemas map[string]*Schema
+		names   map[reflect.Type]string
+	}
+
+	// Location of a struct field in the request
+	fieldLocation struct {
+		in   string // body, path, query, header
+		name string
+	}
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+
+	// Tags of the request fields by the location. (the binding tags of all engines, see engine.BindParameters)
+	parameterTags = []struct{ tag, in string }{
+		{engine.PathTag, "path"},
+		{engine.QueryTag, "query"},
+		{engine.HeaderTag, "header"},
+	}
+)
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// Get the component name of the named type. Types with the same name in different packages get the package name as a prefix.
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+
+	g.names[t] = name
+	return name
+}
+
+// Get the schema of the type. Named structs are registered as components and referenced.
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded as a base64 string
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, nil)
+		}
+
+		name := g.componentName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Reserve the name first to support recursive types
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t, nil)
+		}
+
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	// interface{} and other types accept any value
+	return &Schema{}
+}
+
+// Build the object schema of the struct. If the filter is given, only the fields that the filter returns true are included.
+func (g *schemaGenerator) structSchema(t reflect.Type, filter func(reflect.StructField) bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	forEachField(t, func(f reflect.StructField) {
+		if filter != nil && !filter(f) {
+			return
+		}
+
+		name, ok := jsonFieldName(f)
+		if !ok {
+			return
+		}
+
+		property := g.schemaOf(f.Type)
+		if applyValidationTag(property, f) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		if description := f.Tag.Get("description"); description != "" && property.Ref == "" {
+			property.Description = description
+		}
+
+		schema.Properties[name] = property
+	})
+
+	return schema
+}
+
+// Call the function for all exported fields of the struct. Embedded structs without a json name are flattened.
+func forEachField(t reflect.Type, fn func(reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				forEachField(ft, fn)
+				continue
+			}
+		}
+
+		fn(f)
+	}
+}
+
+// Get the json name of the field. Returns false if the field is not serialized.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+
+	return f.Name, true
+}
+
+// Get the location of the request field. Fields without a parameter tag are in the body.
+func locationOf(f reflect.StructField) fieldLocation {
+	for _, p := range parameterTags {
+		if name := strings.Split(f.Tag.Get(p.tag), ",")[0]; name != "" && name != "-" {
+			return fieldLocation{in: p.in, name: name}
+		}
+	}
+
+	name, _ := jsonFieldName(f)
+	return fieldLocation{in: "body", name: name}
+}
+
+// Apply the validation rules of the field to the schema. Returns true if the field is required.
+func applyValidationTag(schema *Schema, f reflect.StructField) bool {
+	tag := f.Tag.Get("validate")
+	if tag == "" {
+		tag = f.Tag.Get("binding")
+	}
+
+	required := false
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "datetime":
+			schema.Format = "date-time"
+		case "ip", "ipv4":
+			schema.Format = "ipv4"
+		case "ipv6":
+			schema.Format = "ipv6"
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				schema.Enum = append(schema.Enum, enumValue(schema, v))
+			}
+		case "len":
+			setBound(schema, value, true, false)
+			setBound(schema, value, false, false)
+		case "min", "gte":
+			setBound(schema, value, true, false)
+		case "max", "lte":
+			setBound(schema, value, false, false)
+		case "gt":
+			setBound(schema, value, true, true)
+		case "lt":
+			setBound(schema, value, false, true)
+		}
+	}
+
+	return required
+}
+
+// Set the lower or upper bound of the schema. The meaning of the bound depends on the type. (length, items or value)
+func setBound(schema *Schema, value string, lower bool, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string", "array":
+		i := int(n)
+		if exclusive {
+			if lower {
+				i++
+			} else {
+				i--
+			}
+		}
+
+		switch {
+		case schema.Type == "string" && lower:
+			schema.MinLength = &i
+		case schema.Type == "string":
+			schema.MaxLength = &i
+		case lower:
+			schema.MinItems = &i
+		default:
+			schema.MaxItems = &i
+		}
+	case "integer", "number":
+		switch {
+		case lower && exclusive:
+			schema.ExclusiveMinimum = &n
+		case lower:
+			schema.Minimum = &n
+		case exclusive:
+			schema.ExclusiveMaximum = &n
+		default:
+			schema.Maximum = &n
+		}
+	}
+}
+
+// Convert the enum value to the type of the schema
+func enumValue(schema *Schema, value string) interface{} {
+	switch schema.Type {
+	case "integer":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+
+	return value
+}
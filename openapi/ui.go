@@ -0,0 +1,90 @@
+// File: ui.go
+//
+// This file serves the generated document and the documentation UI.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+)
+
+const (
+	swaggerUITemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => { window.ui = SwaggerUIBundle({ url: '%s', dom_id: '#swagger-ui' }); };
+  </script>
+</body>
+</html>`
+
+	redocTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>%s</title>
+</head>
+<body>
+  <redoc spec-url="%s"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>`
+)
+
+// Get the HTML page of the documentation UI
+func UIPage(ui UIType, title, specUrl string) []byte {
+	switch ui {
+	case Redoc:
+		return []byte(fmt.Sprintf(redocTemplate, title, specUrl))
+	default:
+		return []byte(fmt.Sprintf(swaggerUITemplate, title, specUrl))
+	}
+}
+
+// Create the controller spec that serves the document and the UI at the option's path.
+//
+// The spec is relative to the global api prefix of the engine, which is also included in the document url used by the UI.
+func DocumentControllerSpec(option Option, globalApiPrefix string, doc *Document) engine.ControllerSpec {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal the OpenAPI document: %s", err))
+	}
+
+	specPath := engine.MergeRestPath(option.Path, "openapi.json")
+	routes := []controller.RouteSpec{
+		{
+			Method: http.MethodGet,
+			Path:   specPath,
+			Handler: controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) {
+				return nil, ctx.Blob(http.StatusOK, "application/json", body)
+			}),
+		},
+	}
+
+	if option.UI != NoUI {
+		page := UIPage(option.UI, doc.Info.Title, engine.MergeRestPath(globalApiPrefix, specPath))
+		routes = append(routes, controller.RouteSpec{
+			Method: http.MethodGet,
+			Path:   option.Path,
+			Handler: controller.HandlerFunc(func(ctx controller.ExecutionContext) (interface{}, error) {
+				return nil, ctx.Blob(http.StatusOK, "text/html; charset=utf-8", page)
+			}),
+		})
+	}
+
+	return engine.ControllerSpec{
+		Name:   "OpenApi",
+		Routes: routes,
+	}
+}
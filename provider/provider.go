@@ -3,7 +3,11 @@
 // This file defines the provider interface and its implementation.
 package provider
 
-import "github.com/jhseong7/ecl"
+import (
+	"reflect"
+
+	"github.com/jhseong7/ecl"
+)
 
 type (
 	Provider struct {
@@ -38,3 +42,37 @@ func DefineProvider(option ProviderOption) *Provider {
 		Handler:      HandlerName,
 	}
 }
+
+// Extract the embedded provider from the interface. If the interface is a provider, return the provider.
+//
+// Special providers (e.g. Controller, MicroServiceProvider) embed the Provider struct,
+// so this can be used to get the base provider definition from them.
+func ExtractProvider(p interface{}) (*Provider, bool) {
+	// Return the provider if it is a provider
+	if p, ok := p.(*Provider); ok {
+		return p, true
+	}
+
+	v := reflect.ValueOf(p)
+
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	// Dereference the pointer
+	v = v.Elem()
+
+	// For the fields of the struct, check if it is a provider
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := v.Type().Field(i)
+
+		// If the type is an embedded struct --> check if it is a provider
+		if fieldType.Anonymous && field.Type() == reflect.TypeOf(Provider{}) {
+			prov := field.Addr().Interface().(*Provider)
+			return prov, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,79 @@
+// File: reflector.go
+//
+// The reflector reads the metadata of the matched route at request time.
+// Guards and interceptors use it to decide by the metadata declared on the controllers and the routes. (e.g. required roles, public routes)
+package reflector
+
+import (
+	"context"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+)
+
+type (
+	// Native contexts of gin (*gin.Context)
+	keyValueContext interface {
+		Get(key string) (interface{}, bool)
+	}
+
+	// Native contexts of echo (echo.Context)
+	valueContext interface {
+		Get(key string) interface{}
+	}
+
+	// Native contexts of fiber (*fiber.Ctx)
+	localsContext interface {
+		Locals(key interface{}, value ...interface{}) interface{}
+	}
+
+	// Native contexts of the net/http based engines (*http.Request)
+	requestContext interface {
+		Context() context.Context
+	}
+)
+
+// Get all metadata of the matched route. (controller metadata merged with the route metadata)
+//
+// The context can be the ExecutionContext of the interceptors, or the native context of the engine in the middlewares.
+// Returns nil if the route does not have metadata.
+func GetAll(ctx interface{}) map[string]interface{} {
+	var value interface{}
+
+	switch c := ctx.(type) {
+	case controller.ExecutionContext:
+		return c.Metadata()
+	case keyValueContext:
+		value, _ = c.Get(engine.MetadataContextKey)
+	case valueContext:
+		value = c.Get(engine.MetadataContextKey)
+	case localsContext:
+		value = c.Locals(engine.MetadataContextKey)
+	case requestContext:
+		return engine.RouteMetadataFromContext(c.Context())
+	case context.Context:
+		return engine.RouteMetadataFromContext(c)
+	}
+
+	metadata, _ := value.(map[string]interface{})
+	return metadata
+}
+
+// Get the metadata value of the key. Returns false if the route does not have the key.
+func Get(ctx interface{}, key string) (interface{}, bool) {
+	value, ok := GetAll(ctx)[key]
+	return value, ok
+}
+
+// Get the metadata value of the key as T. Returns false if the route does not have the key or the value is not a T.
+func GetAs[T any](ctx interface{}, key string) (T, bool) {
+	value, _ := Get(ctx, key)
+	casted, ok := value.(T)
+	return casted, ok
+}
+
+// Check if the route has the metadata key. (e.g. a "public" flag)
+func Has(ctx interface{}, key string) bool {
+	_, ok := Get(ctx, key)
+	return ok
+}
@@ -0,0 +1,63 @@
+package reflector_test
+
+import (
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/reflector"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type (
+	// Context with the gin style getter
+	keyValueContext map[string]interface{}
+
+	// Context with the fiber style locals
+	localsContext map[interface{}]interface{}
+)
+
+func (c keyValueContext) Get(key string) (interface{}, bool) {
+	v, ok := c[key]
+	return v, ok
+}
+
+func (c localsContext) Locals(key interface{}, value ...interface{}) interface{} {
+	return c[key]
+}
+
+var _ = Describe("Reflector", func() {
+	spec := engine.ControllerSpec{Metadata: map[string]interface{}{"roles": []string{"user"}, "public": false}}
+	route := controller.RouteSpec{Metadata: map[string]interface{}{"roles": []string{"admin"}}}
+
+	It("should merge the route metadata over the controller metadata", func() {
+		metadata := spec.RouteMetadata(route)
+
+		Expect(metadata).To(HaveKeyWithValue("roles", []string{"admin"}))
+		Expect(metadata).To(HaveKeyWithValue("public", false))
+		Expect(engine.ControllerSpec{}.RouteMetadata(controller.RouteSpec{})).To(BeNil())
+	})
+
+	It("should read the metadata from the native contexts", func() {
+		metadata := spec.RouteMetadata(route)
+
+		for _, ctx := range []interface{}{
+			keyValueContext{engine.MetadataContextKey: metadata},
+			localsContext{engine.MetadataContextKey: metadata},
+		} {
+			roles, ok := reflector.GetAs[[]string](ctx, "roles")
+			Expect(ok).To(BeTrue())
+			Expect(roles).To(Equal([]string{"admin"}))
+			Expect(reflector.Has(ctx, "public")).To(BeTrue())
+		}
+	})
+
+	It("should return nothing for routes without metadata", func() {
+		for _, ctx := range []interface{}{keyValueContext{}, localsContext{}, "unknown"} {
+			Expect(reflector.GetAll(ctx)).To(BeNil())
+			Expect(reflector.Has(ctx, "roles")).To(BeFalse())
+		}
+
+		_, ok := reflector.GetAs[int](keyValueContext{engine.MetadataContextKey: map[string]interface{}{"roles": "admin"}}, "roles")
+		Expect(ok).To(BeFalse())
+	})
+})
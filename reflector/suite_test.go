@@ -0,0 +1,13 @@
+package reflector_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestReflector(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Reflector Suite")
+}
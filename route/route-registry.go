@@ -0,0 +1,179 @@
+// File: route-registry.go
+//
+// The route registry collects the routes of all controllers before they are registered to the engine.
+// The routes are validated in one place, so conflicts are reported the same way regardless of the server engine.
+package route
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jhseong7/gimbap/engine"
+)
+
+type (
+	// Information of a registered route
+	RouteInfo struct {
+		Method     string `json:"method"`
+		Path       string `json:"path"` // Full path of the route including the global api prefix
+		Controller string `json:"controller"`
+		Handler    string `json:"handler"`
+
+		Interceptors     int `json:"interceptors"`
+		ExceptionFilters int `json:"exceptionFilters"`
+		Middlewares      int `json:"middlewares"`
+	}
+
+	RouteRegistry struct {
+		globalApiPrefix string
+		routes          []RouteInfo
+		paths           []*engine.RoutePath // Parsed paths of the routes (same order as the routes)
+		errors          []error
+	}
+)
+
+var validMethods = []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "HEAD"}
+
+// Get the shape of the segment to compare with the other segments. The names of the parameters are replaced with the kind.
+func segmentShape(segment engine.PathSegment) string {
+	shape := ""
+	for _, t := range segment {
+		switch t.Kind {
+		case engine.StaticToken:
+			shape += t.Value
+		case engine.ParamToken:
+			shape += "{}"
+		case engine.CatchAllToken:
+			shape += "{...}"
+		}
+	}
+
+	return shape
+}
+
+// Get the names of the parameters in the segment
+func segmentParams(segment engine.PathSegment) string {
+	names := []string{}
+	for _, t := range segment {
+		if t.Kind != engine.StaticToken {
+			names = append(names, t.Value)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Check if the 2 paths conflict. Returns a description of the conflict, or an empty string if they do not conflict.
+//
+// Paths conflict if they match the same requests (duplicates), or if they have different parameters at the same position.
+// Optional parameters are compared as 2 paths (with and without the parameter).
+func checkConflict(a, b *engine.RoutePath) string {
+	for _, pa := range a.Expand() {
+		for _, pb := range b.Expand() {
+			if conflict := checkExpandedConflict(pa, pb); conflict != "" {
+				return conflict
+			}
+		}
+	}
+
+	return ""
+}
+
+func checkExpandedConflict(a, b *engine.RoutePath) string {
+	for i := 0; i < len(a.Segments) && i < len(b.Segments); i++ {
+		x, y := a.Segments[i], b.Segments[i]
+		sx, sy := segmentShape(x), segmentShape(y)
+
+		switch {
+		case sx == sy:
+			if px, py := segmentParams(x), segmentParams(y); px != py {
+				return fmt.Sprintf("conflicting parameter names '%s' and '%s' ('%s' and '%s')", px, py, a.Raw, b.Raw)
+			}
+		case len(x) == 1 && len(y) == 1 && x[0].Kind != engine.StaticToken && y[0].Kind != engine.StaticToken:
+			return fmt.Sprintf("parameter and catch-all at the same position ('%s' and '%s')", a.Raw, b.Raw)
+		default:
+			// Different static text, or static text that takes precedence over the parameters
+			return ""
+		}
+	}
+
+	if len(a.Segments) == len(b.Segments) {
+		return fmt.Sprintf("duplicate route ('%s' and '%s')", a.Raw, b.Raw)
+	}
+
+	return ""
+}
+
+func isValidMethod(method string) bool {
+	for _, m := range validMethods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func NewRouteRegistry(globalApiPrefix string) *RouteRegistry {
+	return &RouteRegistry{
+		globalApiPrefix: globalApiPrefix,
+		routes:          []RouteInfo{},
+		paths:           []*engine.RoutePath{},
+		errors:          []error{},
+	}
+}
+
+// Add the routes of the controller to the registry. The routes are checked against the already added routes.
+func (r *RouteRegistry) Add(spec engine.ControllerSpec) {
+	for _, rs := range spec.Routes {
+		info := RouteInfo{
+			Method:           rs.Method,
+			Path:             engine.MergeRestPath(r.globalApiPrefix, spec.RootPath, rs.Path),
+			Controller:       spec.Name,
+			Interceptors:     len(spec.RouteInterceptors(rs)),
+			ExceptionFilters: len(spec.RouteExceptionFilters(rs)),
+			Middlewares:      len(spec.Middlewares) + len(rs.Middlewares),
+		}
+
+		if rs.Handler == nil {
+			r.errors = append(r.errors, fmt.Errorf("%s %s (%s): handler is nil", info.Method, info.Path, info.Controller))
+			continue
+		}
+		info.Handler = engine.RuntimeFuncName(rs.Handler)
+
+		if !isValidMethod(info.Method) {
+			r.errors = append(r.errors, fmt.Errorf("%s %s (%s): invalid HTTP method. Must be one of (%s)", info.Method, info.Path, info.Controller, strings.Join(validMethods, ", ")))
+			continue
+		}
+
+		path, err := engine.ParsePath(info.Path)
+		if err != nil {
+			r.errors = append(r.errors, fmt.Errorf("%s %s (%s): %w", info.Method, info.Path, info.Controller, err))
+			continue
+		}
+
+		for i, existing := range r.routes {
+			if existing.Method != info.Method {
+				continue
+			}
+
+			if conflict := checkConflict(r.paths[i], path); conflict != "" {
+				r.errors = append(r.errors, fmt.Errorf("%s %s (%s): %s with %s", info.Method, info.Path, info.Controller, conflict, existing.Controller))
+			}
+		}
+
+		r.routes = append(r.routes, info)
+		r.paths = append(r.paths, path)
+	}
+}
+
+// Get the validation errors of the added routes. Returns nil if all routes are valid.
+func (r *RouteRegistry) Validate() error {
+	return errors.Join(r.errors...)
+}
+
+// Get the list of the routes in the order they were added.
+func (r *RouteRegistry) Routes() []RouteInfo {
+	return append([]RouteInfo{}, r.routes...)
+}
@@ -0,0 +1,92 @@
+package route_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/route"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func handler(ctx controller.ExecutionContext) (interface{}, error) { return nil, nil }
+
+func spec(name, root string, routes ...controller.RouteSpec) engine.ControllerSpec {
+	return engine.ControllerSpec{Name: name, RootPath: root, Routes: routes}
+}
+
+var _ = Describe("RouteRegistry", func() {
+
+	Context("Test validation", func() {
+		It("Accepts routes without conflicts", func() {
+			r := route.NewRouteRegistry("api")
+			r.Add(spec("UserController", "users",
+				controller.RouteSpec{Method: "GET", Path: "", Handler: handler},
+				controller.RouteSpec{Method: "GET", Path: ":id", Handler: handler},
+				controller.RouteSpec{Method: "GET", Path: "new", Handler: handler},
+				controller.RouteSpec{Method: "DELETE", Path: ":id", Handler: handler},
+				controller.RouteSpec{Method: "GET", Path: ":id/posts", Handler: handler},
+			))
+
+			Expect(r.Validate()).To(BeNil())
+			Expect(r.Routes()).To(HaveLen(5))
+			Expect(r.Routes()[1].Path).To(Equal("/api/users/:id"))
+		})
+
+		It("Detects duplicate routes across controllers", func() {
+			r := route.NewRouteRegistry("")
+			r.Add(spec("A", "users", controller.RouteSpec{Method: "GET", Path: ":id", Handler: handler}))
+			r.Add(spec("B", "", controller.RouteSpec{Method: "GET", Path: "users/:id", Handler: handler}))
+
+			Expect(r.Validate()).To(MatchError(ContainSubstring("duplicate route")))
+		})
+
+		It("Detects conflicting parameter names", func() {
+			r := route.NewRouteRegistry("")
+			r.Add(spec("A", "users",
+				controller.RouteSpec{Method: "GET", Path: ":id", Handler: handler},
+				controller.RouteSpec{Method: "GET", Path: ":name/posts", Handler: handler},
+				controller.RouteSpec{Method: "GET", Path: "*path", Handler: handler},
+			))
+
+			err := r.Validate()
+			Expect(err).To(MatchError(ContainSubstring("conflicting parameter names 'id' and 'name'")))
+			Expect(err).To(MatchError(ContainSubstring("parameter and catch-all")))
+		})
+
+		It("Detects invalid methods and nil handlers", func() {
+			r := route.NewRouteRegistry("")
+			r.Add(spec("A", "",
+				controller.RouteSpec{Method: "FETCH", Path: "a", Handler: handler},
+				controller.RouteSpec{Method: "GET", Path: "b"},
+			))
+
+			err := r.Validate()
+			Expect(err).To(MatchError(ContainSubstring("invalid HTTP method")))
+			Expect(err).To(MatchError(ContainSubstring("handler is nil")))
+		})
+	})
+
+	Context("Test printing", func() {
+		r := route.NewRouteRegistry("")
+		r.Add(spec("UserController", "users", controller.RouteSpec{Method: "GET", Path: ":id", Handler: handler}))
+
+		It("Writes the table", func() {
+			buf := &bytes.Buffer{}
+			Expect(route.Write(buf, r.Routes(), route.TextFormat)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("METHOD"))
+			Expect(buf.String()).To(MatchRegexp(`GET\s+/users/:id\s+UserController`))
+		})
+
+		It("Writes the JSON", func() {
+			buf := &bytes.Buffer{}
+			Expect(route.Write(buf, r.Routes(), route.JSONFormat)).To(Succeed())
+
+			routes := []route.RouteInfo{}
+			Expect(json.Unmarshal(buf.Bytes(), &routes)).To(Succeed())
+			Expect(routes).To(Equal(r.Routes()))
+		})
+	})
+})
@@ -0,0 +1,47 @@
+// File: route-table.go
+//
+// This file prints the route table of the registry.
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+type TableFormat string
+
+const (
+	TextFormat TableFormat = "text"
+	JSONFormat TableFormat = "json"
+)
+
+// Write the routes as a formatted table.
+func WriteTable(w io.Writer, routes []RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "METHOD\tPATH\tCONTROLLER\tHANDLER\tINTERCEPTORS\tFILTERS\tMIDDLEWARES")
+	for _, r := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\n", r.Method, r.Path, r.Controller, r.Handler, r.Interceptors, r.ExceptionFilters, r.Middlewares)
+	}
+
+	return tw.Flush()
+}
+
+// Write the routes as an indented JSON array.
+func WriteJSON(w io.Writer, routes []RouteInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(routes)
+}
+
+// Write the routes in the given format. (default: text)
+func Write(w io.Writer, routes []RouteInfo, format TableFormat) error {
+	if format == JSONFormat {
+		return WriteJSON(w, routes)
+	}
+
+	return WriteTable(w, routes)
+}
@@ -0,0 +1,215 @@
+// File: sse.go
+//
+// Server-sent events of the controllers.
+//
+// The handlers return a Stream of the events, which is written with ExecutionContext.Stream, so all engines send the events the same way.
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhseong7/gimbap/controller"
+)
+
+type (
+	// Event sent to the client
+	Event struct {
+		// ID of the event, sent back by the client as the Last-Event-ID header when it reconnects. (see LastEventID)
+		ID string
+
+		// Name of the event. (the "message" event of the client if empty)
+		Event string
+
+		// Data of the event. Strings and bytes are sent as they are, and the other values as JSON.
+		Data interface{}
+
+		// Time the client waits before reconnecting. Not sent if 0.
+		Retry time.Duration
+	}
+
+	// Stream of the events returned by a handler. The events are taken from the channel or the iterator, until it ends or the client disconnects.
+	Stream struct {
+		// Events to send, until the channel is closed
+		Events <-chan Event
+
+		// Events to send, instead of the channel. The iteration stops (yield returns false) once the client disconnects.
+		// Same as iter.Seq[Event], so an iter.Seq can be given on Go 1.23 while the module still builds with Go 1.22.
+		Iterator func(yield func(Event) bool)
+
+		// Interval of the keep-alive comments sent while there is no event. (default: 15 seconds, disabled if negative)
+		// The comments keep the proxies from closing the idle connection, and detect the disconnected clients.
+		KeepAlive time.Duration
+
+		// Time the client waits before reconnecting, sent at the start of the stream. Not sent if 0.
+		Retry time.Duration
+
+		// Called if the stream ends before the events: the client disconnects, the server shuts down or an event fails to encode.
+		// (e.g. to stop the producer of the channel)
+		OnDisconnect func()
+	}
+)
+
+const (
+	ContentType = "text/event-stream"
+
+	// Header of the ID of the last event received by the reconnecting client
+	LastEventIDHeader = "Last-Event-ID"
+
+	DefaultKeepAlive = 15 * time.Second
+)
+
+// Get the ID of the last event received by the client, sent when it reconnects. (empty on the first connection)
+func LastEventID(ctx controller.ExecutionContext) string {
+	return ctx.Header(LastEventIDHeader)
+}
+
+// Write the stream as the response. Called by the engines for the streams returned by the handlers.
+func (s Stream) Respond(ctx controller.ExecutionContext) error {
+	// The proxies must not cache or buffer the events (e.g. nginx)
+	ctx.SetHeader("Cache-Control", "no-cache")
+	ctx.SetHeader("X-Accel-Buffering", "no")
+
+	return ctx.Stream(http.StatusOK, ContentType, s.write)
+}
+
+// Send the events until they end or the client disconnects
+func (s Stream) write(w controller.StreamWriter) {
+	events := s.Events
+	if s.Iterator != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+
+		events = iterate(s.Iterator, stop)
+	}
+
+	keepAlive := s.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = DefaultKeepAlive
+	}
+
+	var ping <-chan time.Time
+	if keepAlive > 0 {
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+
+		ping = ticker.C
+	}
+
+	disconnected := func() {
+		if s.OnDisconnect != nil {
+			s.OnDisconnect()
+		}
+	}
+
+	if s.Retry > 0 {
+		if writeEvent(w, Event{Retry: s.Retry}) != nil || w.Flush() != nil {
+			disconnected()
+			return
+		}
+	}
+
+	for {
+		var err error
+
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			err = writeEvent(w, event)
+		case <-ping:
+			_, err = io.WriteString(w, ": ping\n\n")
+		case <-w.Done():
+			disconnected()
+			return
+		}
+
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			disconnected()
+			return
+		}
+	}
+}
+
+// Send the events of the iterator to the channel, until the iteration ends or stop is closed
+func iterate(seq func(yield func(Event) bool), stop <-chan struct{}) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		seq(func(event Event) bool {
+			select {
+			case events <- event:
+				return true
+			case <-stop:
+				return false
+			}
+		})
+	}()
+
+	return events
+}
+
+// Write the event in the text/event-stream format
+func writeEvent(w io.Writer, event Event) error {
+	var b strings.Builder
+
+	if event.ID != "" {
+		b.WriteString("id: " + singleLine(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + singleLine(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	if event.Data != nil {
+		data, err := eventData(event.Data)
+		if err != nil {
+			return err
+		}
+
+		// Each line of the data is a data field. (CRLF, LF and a lone CR all end a line in the event stream)
+		for _, line := range strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(data), "\n") {
+			b.WriteString("data: " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func eventData(data interface{}) (string, error) {
+	switch value := data.(type) {
+	case string:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode the event data: %w", err)
+		}
+
+		return string(encoded), nil
+	}
+}
+
+// Remove the line breaks, which would end the field
+func singleLine(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,138 @@
+package sse_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/sse"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Stream writer that fails to flush after the given number of flushes (never if negative)
+type streamWriter struct {
+	strings.Builder
+
+	flushes int
+	done    chan struct{}
+}
+
+func (w *streamWriter) Done() <-chan struct{} { return w.done }
+func (w *streamWriter) Flush() error {
+	if w.flushes == 0 {
+		return errors.New("disconnected")
+	}
+
+	w.flushes--
+	return nil
+}
+
+// Execution context that runs the stream with the writer
+type streamContext struct {
+	controller.ExecutionContext
+
+	writer      *streamWriter
+	header      map[string]string
+	status      int
+	contentType string
+}
+
+func (c *streamContext) Header(key string) string    { return c.header[key] }
+func (c *streamContext) SetHeader(key, value string) { c.header[key] = value }
+func (c *streamContext) Stream(status int, contentType string, stream func(w controller.StreamWriter)) error {
+	c.status, c.contentType = status, contentType
+	stream(c.writer)
+	return nil
+}
+
+func newContext(flushes int) *streamContext {
+	return &streamContext{writer: &streamWriter{flushes: flushes, done: make(chan struct{})}, header: map[string]string{}}
+}
+
+var _ = Describe("SSE", func() {
+
+	Context("Test the events", func() {
+		It("Writes the events in the event stream format", func() {
+			events := make(chan sse.Event, 3)
+			events <- sse.Event{ID: "1\n", Event: "update", Data: "a\r\nb", Retry: 1500 * time.Millisecond}
+			events <- sse.Event{Data: []int{1, 2}}
+			events <- sse.Event{Data: []byte("raw")}
+			close(events)
+
+			ctx := newContext(-1)
+			Expect(sse.Stream{Events: events}.Respond(ctx)).To(Succeed())
+
+			Expect(ctx.status).To(Equal(200))
+			Expect(ctx.contentType).To(Equal(sse.ContentType))
+			Expect(ctx.header).To(HaveKeyWithValue("Cache-Control", "no-cache"))
+			Expect(ctx.writer.String()).To(Equal("id: 1\nevent: update\nretry: 1500\ndata: a\ndata: b\n\ndata: [1,2]\n\ndata: raw\n\n"))
+		})
+
+		It("Splits the data on every line break", func() {
+			events := make(chan sse.Event, 1)
+			events <- sse.Event{Data: "a\rb\r\nc\n\rd"}
+			close(events)
+
+			ctx := newContext(-1)
+			Expect(sse.Stream{Events: events}.Respond(ctx)).To(Succeed())
+
+			Expect(ctx.writer.String()).To(Equal("data: a\ndata: b\ndata: c\ndata: \ndata: d\n\n"))
+		})
+
+		It("Gets the last event ID of the reconnecting client", func() {
+			ctx := newContext(-1)
+			ctx.header[sse.LastEventIDHeader] = "42"
+
+			Expect(sse.LastEventID(ctx)).To(Equal("42"))
+		})
+	})
+
+	Context("Test the disconnection", func() {
+		It("Stops the iterator once the flush fails", func() {
+			yielded, stopped := 0, make(chan bool, 1)
+			disconnected := false
+
+			ctx := newContext(2)
+			stream := sse.Stream{
+				Iterator: func(yield func(sse.Event) bool) {
+					for {
+						if !yield(sse.Event{Data: "tick"}) {
+							stopped <- true
+							return
+						}
+						yielded++
+					}
+				},
+				OnDisconnect: func() { disconnected = true },
+			}
+			Expect(stream.Respond(ctx)).To(Succeed())
+
+			Eventually(stopped).Should(Receive())
+			Expect(disconnected).To(BeTrue())
+			Expect(yielded).To(BeNumerically(">=", 3))
+			Expect(ctx.writer.String()).To(HavePrefix("data: tick\n\ndata: tick\n\ndata: tick\n\n"))
+		})
+
+		It("Ends the stream once the writer is done", func() {
+			disconnected := false
+
+			ctx := newContext(-1)
+			close(ctx.writer.done)
+			Expect(sse.Stream{Events: make(chan sse.Event), OnDisconnect: func() { disconnected = true }}.Respond(ctx)).To(Succeed())
+
+			Expect(disconnected).To(BeTrue())
+		})
+
+		It("Does not call OnDisconnect if the events end", func() {
+			events := make(chan sse.Event)
+			close(events)
+
+			disconnected := false
+			Expect(sse.Stream{Events: events, OnDisconnect: func() { disconnected = true }}.Respond(newContext(-1))).To(Succeed())
+
+			Expect(disconnected).To(BeFalse())
+		})
+	})
+})
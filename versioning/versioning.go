@@ -0,0 +1,234 @@
+// File: versioning.go
+//
+// API versioning of the controllers and the routes.
+//
+// Each version of a route is registered under its version path (e.g. /api/v1/users), so all engines serve the versions the same way.
+// For the header and the media type strategies, the request path is rewritten to the version path before the routing.
+package versioning
+
+import (
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+)
+
+type (
+	Type string
+
+	Option struct {
+		// Strategy to get the requested version. (default: URIVersioning)
+		Type Type
+
+		// Version of the controllers and the routes without a version.
+		// If empty, they are version neutral. (served without the version path)
+		DefaultVersion string
+
+		// Prefix of the version segment in the path. (default: "v" --> /v1/users)
+		Prefix string
+
+		// Header of the requested version for the header strategy. (default: Accept-Version)
+		Header string
+
+		// Parameter of the Accept media type for the media type strategy. (default: "v" --> Accept: application/json;v=1)
+		MediaTypeKey string
+
+		// Fallback chain of the versions. If the requested version does not have the route, the next version in the chain is tried.
+		// (e.g. {"3": "2", "2": "1"} --> requests for v3 are served by v2 or v1)
+		Fallbacks map[string]string
+	}
+
+	// Rewrites the request path to the path of the requested version
+	rewriter struct {
+		option          Option
+		globalApiPrefix string                      // Without the trailing slash (empty if no prefix)
+		routes          map[string][]*regexp.Regexp // Patterns of the registered paths by the method
+	}
+)
+
+const (
+	URIVersioning       Type = "uri"
+	HeaderVersioning    Type = "header"
+	MediaTypeVersioning Type = "media-type"
+
+	// Version of the routes served regardless of the requested version
+	Neutral = "*"
+)
+
+// Fill the default values of the option
+func (o Option) withDefaults() Option {
+	if o.Type == "" {
+		o.Type = URIVersioning
+	}
+	if o.Prefix == "" {
+		o.Prefix = "v"
+	}
+	if o.Header == "" {
+		o.Header = "Accept-Version"
+	}
+	if o.MediaTypeKey == "" {
+		o.MediaTypeKey = "v"
+	}
+
+	return o
+}
+
+// Get the versions of the route. (route --> controller --> default)
+func routeVersions(option Option, spec engine.ControllerSpec, route controller.RouteSpec) []string {
+	switch {
+	case len(route.Versions) > 0:
+		return route.Versions
+	case len(spec.Versions) > 0:
+		return spec.Versions
+	case option.DefaultVersion != "":
+		return []string{option.DefaultVersion}
+	default:
+		return []string{Neutral}
+	}
+}
+
+// Apply the versions to the controller specs.
+//
+// Each controller is split into a spec per version, with the version path added to the root path.
+// The routes of the neutral version keep the root path.
+func ApplyVersions(option Option, specs []engine.ControllerSpec) []engine.ControllerSpec {
+	option = option.withDefaults()
+	versioned := []engine.ControllerSpec{}
+
+	for _, spec := range specs {
+		order := []string{}
+		routes := map[string][]controller.RouteSpec{}
+
+		for _, route := range spec.Routes {
+			for _, version := range routeVersions(option, spec, route) {
+				if _, ok := routes[version]; !ok {
+					order = append(order, version)
+				}
+				routes[version] = append(routes[version], route)
+			}
+		}
+
+		for _, version := range order {
+			vs := spec
+			vs.Routes = routes[version]
+			vs.Versions = []string{version}
+
+			if version != Neutral {
+				vs.RootPath = engine.MergeRestPath(option.Prefix+version, spec.RootPath)
+			}
+
+			versioned = append(versioned, vs)
+		}
+	}
+
+	return versioned
+}
+
+// Create the path rewriter of the versioning.
+//
+// Returns nil if the rewriting is not needed. (URI versioning without fallbacks)
+// The specs must be the ones returned by ApplyVersions.
+func NewPathRewriter(option Option, globalApiPrefix string, specs []engine.ControllerSpec) engine.PathRewriter {
+	option = option.withDefaults()
+
+	if option.Type == URIVersioning && len(option.Fallbacks) == 0 {
+		return nil
+	}
+
+	r := &rewriter{
+		option:          option,
+		globalApiPrefix: strings.TrimSuffix(engine.MergeRestPath(globalApiPrefix), "/"),
+		routes:          map[string][]*regexp.Regexp{},
+	}
+
+	for _, spec := range specs {
+		for _, route := range spec.Routes {
+			path, err := engine.ParsePath(engine.MergeRestPath(globalApiPrefix, spec.RootPath, route.Path))
+			if err != nil {
+				continue // Reported by the route registry
+			}
+
+			r.routes[route.Method] = append(r.routes[route.Method], path.Regexp())
+		}
+	}
+
+	return r.rewrite
+}
+
+// Check if a route matches the path
+func (r *rewriter) matches(method, path string) bool {
+	for _, pattern := range r.routes[method] {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get the requested version from the header or the media type. Returns the default version if not requested.
+func (r *rewriter) requestedVersion(header func(key string) string) string {
+	switch r.option.Type {
+	case HeaderVersioning:
+		if v := strings.TrimSpace(header(r.option.Header)); v != "" {
+			return v
+		}
+	case MediaTypeVersioning:
+		for _, accept := range strings.Split(header("Accept"), ",") {
+			_, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+			if err == nil && params[r.option.MediaTypeKey] != "" {
+				return params[r.option.MediaTypeKey]
+			}
+		}
+	}
+
+	return r.option.DefaultVersion
+}
+
+// Find the path of the version (or the fallback versions) that has the route. Returns an empty string if none has it.
+func (r *rewriter) resolve(method, version string, pathOf func(version string) string) string {
+	visited := map[string]bool{}
+
+	for v := version; v != "" && !visited[v]; v = r.option.Fallbacks[v] {
+		visited[v] = true
+
+		if path := pathOf(v); r.matches(method, path) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+func (r *rewriter) rewrite(method, path string, header func(key string) string) string {
+	// Paths outside of the api prefix are not versioned
+	rest, ok := strings.CutPrefix(path, r.globalApiPrefix)
+	if !ok || (rest != "" && rest[0] != '/') {
+		return path
+	}
+
+	var version string
+	var pathOf func(version string) string
+
+	if r.option.Type == URIVersioning {
+		// The version is the first segment after the prefix. (e.g. /api/v3/users)
+		segment, remaining, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+		if !strings.HasPrefix(segment, r.option.Prefix) || r.matches(method, path) {
+			return path
+		}
+
+		version = strings.TrimPrefix(segment, r.option.Prefix)
+		pathOf = func(v string) string { return engine.MergeRestPath(r.globalApiPrefix, r.option.Prefix+v, remaining) }
+	} else {
+		version = r.requestedVersion(header)
+		pathOf = func(v string) string { return engine.MergeRestPath(r.globalApiPrefix, r.option.Prefix+v, rest) }
+	}
+
+	if resolved := r.resolve(method, version, pathOf); resolved != "" {
+		return resolved
+	}
+
+	return path
+}
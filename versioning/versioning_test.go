@@ -0,0 +1,96 @@
+package versioning_test
+
+import (
+	"net/http"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/engine"
+	"github.com/jhseong7/gimbap/versioning"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func handler(ctx controller.ExecutionContext) (interface{}, error) { return nil, nil }
+
+var specs = []engine.ControllerSpec{
+	{
+		Name:     "UserController",
+		RootPath: "users",
+		Versions: []string{"1"},
+		Routes: []controller.RouteSpec{
+			{Method: "GET", Path: "", Handler: handler},
+			{Method: "GET", Path: "{id}", Handler: handler, Versions: []string{"1", "2"}},
+			{Method: "GET", Path: "health/check", Handler: handler, Versions: []string{versioning.Neutral}},
+		},
+	},
+}
+
+func header(values map[string]string) func(string) string {
+	h := http.Header{}
+	for k, v := range values {
+		h.Set(k, v)
+	}
+
+	return h.Get
+}
+
+var _ = Describe("Versioning", func() {
+
+	Context("Test ApplyVersions", func() {
+		It("Splits the controllers by the versions", func() {
+			versioned := versioning.ApplyVersions(versioning.Option{}, specs)
+			Expect(versioned).To(HaveLen(3))
+
+			Expect(versioned[0].RootPath).To(Equal("/v1/users"))
+			Expect(versioned[0].Routes).To(HaveLen(2))
+			Expect(versioned[1].RootPath).To(Equal("/v2/users"))
+			Expect(versioned[1].Routes).To(HaveLen(1))
+			Expect(versioned[2].RootPath).To(Equal("users"))
+		})
+
+		It("Applies the default version to the routes without a version", func() {
+			versioned := versioning.ApplyVersions(versioning.Option{DefaultVersion: "3"}, []engine.ControllerSpec{
+				{Name: "A", RootPath: "a", Routes: []controller.RouteSpec{{Method: "GET", Path: "", Handler: handler}}},
+			})
+
+			Expect(versioned[0].RootPath).To(Equal("/v3/a"))
+		})
+	})
+
+	Context("Test path rewriting", func() {
+		It("Does not rewrite URI versioning without fallbacks", func() {
+			option := versioning.Option{}
+			Expect(versioning.NewPathRewriter(option, "api", versioning.ApplyVersions(option, specs))).To(BeNil())
+		})
+
+		It("Falls back to the previous versions with URI versioning", func() {
+			option := versioning.Option{Fallbacks: map[string]string{"3": "2", "2": "1"}}
+			rewrite := versioning.NewPathRewriter(option, "api", versioning.ApplyVersions(option, specs))
+			none := header(nil)
+
+			Expect(rewrite("GET", "/api/v3/users/7", none)).To(Equal("/api/v2/users/7"))
+			Expect(rewrite("GET", "/api/v3/users", none)).To(Equal("/api/v1/users"))
+			Expect(rewrite("GET", "/api/v2/users/7", none)).To(Equal("/api/v2/users/7"))
+			Expect(rewrite("GET", "/api/v9/users", none)).To(Equal("/api/v9/users"))
+			Expect(rewrite("GET", "/static/v3/users", none)).To(Equal("/static/v3/users"))
+		})
+
+		It("Routes by the version header", func() {
+			option := versioning.Option{Type: versioning.HeaderVersioning, DefaultVersion: "1", Fallbacks: map[string]string{"2": "1"}}
+			rewrite := versioning.NewPathRewriter(option, "api", versioning.ApplyVersions(option, specs))
+
+			Expect(rewrite("GET", "/api/users/7", header(map[string]string{"Accept-Version": "2"}))).To(Equal("/api/v2/users/7"))
+			Expect(rewrite("GET", "/api/users", header(map[string]string{"Accept-Version": "2"}))).To(Equal("/api/v1/users"))
+			Expect(rewrite("GET", "/api/users/7", header(nil))).To(Equal("/api/v1/users/7"))
+			Expect(rewrite("GET", "/api/users/health/check", header(map[string]string{"Accept-Version": "2"}))).To(Equal("/api/users/health/check"))
+		})
+
+		It("Routes by the media type parameter", func() {
+			option := versioning.Option{Type: versioning.MediaTypeVersioning}
+			rewrite := versioning.NewPathRewriter(option, "", versioning.ApplyVersions(option, specs))
+
+			Expect(rewrite("GET", "/users/7", header(map[string]string{"Accept": "text/html, application/json;v=2"}))).To(Equal("/v2/users/7"))
+			Expect(rewrite("GET", "/users/7", header(nil))).To(Equal("/users/7"))
+		})
+	})
+})
@@ -0,0 +1,143 @@
+// File: html-engine.go
+//
+// The default view engine, rendering the templates with html/template.
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+type (
+	// View engine of html/template.
+	//
+	// Each view is parsed with the partials and the layouts, so the views can define the blocks of the layouts.
+	// The layouts render the view with {{ yield }}, and the partials are included with {{ template "partials/header" . }}.
+	HTMLEngine struct {
+		option Option
+
+		mutex sync.RWMutex
+		views map[string]*template.Template
+	}
+)
+
+// Create the html/template engine of the option. The templates are not loaded until Load is called.
+func NewHTMLEngine(option Option) *HTMLEngine {
+	return &HTMLEngine{option: option.withDefaults()}
+}
+
+// Get the FS of the templates
+func (e *HTMLEngine) files() (fs.FS, error) {
+	switch root := strings.Trim(e.option.Root, "/"); {
+	case e.option.FS == nil:
+		return os.DirFS(e.option.Root), nil
+	case root != "" && root != ".":
+		return fs.Sub(e.option.FS, root)
+	default:
+		return e.option.FS, nil
+	}
+}
+
+// Parse the templates of the views. The views are replaced only if all templates are parsed.
+func (e *HTMLEngine) Load() error {
+	files, err := e.files()
+	if err != nil {
+		return fmt.Errorf("invalid view root %s: %w", e.option.Root, err)
+	}
+
+	// Sources of the templates by the name (the path without the extension)
+	shared, views := map[string]string{}, map[string]string{}
+	err = fs.WalkDir(files, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(name) != e.option.Extension {
+			return err
+		}
+
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+
+		templateName := strings.TrimSuffix(name, e.option.Extension)
+		if strings.HasPrefix(name, e.option.Layouts+"/") || strings.HasPrefix(name, e.option.Partials+"/") {
+			shared[templateName] = string(data)
+		} else {
+			views[templateName] = string(data)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the views: %w", err)
+	}
+
+	parsed := make(map[string]*template.Template, len(views))
+	for name, source := range views {
+		// yield is replaced for each render (see Render)
+		t := template.New(name).Funcs(template.FuncMap{"yield": func() (template.HTML, error) { return "", nil }}).Funcs(e.option.Funcs)
+
+		// The view is parsed last, so its blocks override the blocks of the layouts
+		for sharedName, sharedSource := range shared {
+			if _, err := t.New(sharedName).Parse(sharedSource); err != nil {
+				return fmt.Errorf("failed to parse the template %s: %w", sharedName, err)
+			}
+		}
+		if _, err := t.Parse(source); err != nil {
+			return fmt.Errorf("failed to parse the view %s: %w", name, err)
+		}
+
+		parsed[name] = t
+	}
+
+	e.mutex.Lock()
+	e.views = parsed
+	e.mutex.Unlock()
+
+	return nil
+}
+
+// Render the view. The layout renders the view where it calls {{ yield }}.
+//
+// The templates are loaded again before the render if the option reloads them.
+func (e *HTMLEngine) Render(w io.Writer, name string, data interface{}, layout string) error {
+	if e.option.Reload {
+		if err := e.Load(); err != nil {
+			return err
+		}
+	}
+
+	e.mutex.RLock()
+	view, ok := e.views[name]
+	e.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("view %s not found", name)
+	}
+
+	// The loaded templates are cloned, as the functions cannot be replaced on the templates shared by the renders
+	// (and html/template does not clone the templates once executed)
+	t, err := view.Clone()
+	if err != nil {
+		return err
+	}
+	if layout == "" {
+		return t.ExecuteTemplate(w, name, data)
+	}
+	if t.Lookup(layout) == nil {
+		return fmt.Errorf("layout %s of the view %s not found", layout, name)
+	}
+
+	t.Funcs(template.FuncMap{"yield": func() (template.HTML, error) {
+		var buffer bytes.Buffer
+		err := t.ExecuteTemplate(&buffer, name, data)
+
+		return template.HTML(buffer.String()), err
+	}})
+
+	return t.ExecuteTemplate(w, layout, data)
+}
@@ -0,0 +1,167 @@
+// File: view.go
+//
+// Server side rendering of the controllers.
+//
+// The handlers return a View, which is rendered by the view engine of the app and written with ExecutionContext.Blob,
+// so all engines render the views the same way. The view engine is html/template by default, and can be replaced with IViewEngine.
+package view
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/interceptor"
+)
+
+type (
+	// Interface the view engines must implement.
+	IViewEngine interface {
+		// Load the templates. Called once before the app serves the requests.
+		Load() error
+
+		// Render the view with the data into the writer. The view is rendered inside the layout if the layout is not empty.
+		Render(w io.Writer, name string, data interface{}, layout string) error
+	}
+
+	Option struct {
+		// View engine rendering the views. An html/template engine of the options below is used if nil.
+		Engine IViewEngine
+
+		// Templates to load (e.g. an embed.FS). The Root is a directory of the FS. (the FS itself if empty or ".")
+		// The Root is a directory on the disk if not set.
+		FS   fs.FS
+		Root string
+
+		// Extension of the template files. (default: .html)
+		Extension string
+
+		// Directories of the layouts and the partials under the root, which are available to all views. (default: layouts, partials)
+		Layouts  string
+		Partials string
+
+		// Functions available to the templates
+		Funcs template.FuncMap
+
+		// Load the templates again on each render, so the changes are shown without restarting the app. (for the development)
+		Reload bool
+
+		// Layout of the views without a layout. (e.g. layouts/main) No layout if empty.
+		Layout string
+
+		// Content type of the rendered views. (default: text/html; charset=utf-8)
+		ContentType string
+	}
+
+	// Result of a handler rendered as a view.
+	//
+	// The templates are named by their path under the root without the extension. (e.g. users/list, layouts/main)
+	View struct {
+		Name string
+		Data interface{}
+
+		// Layout of the view. The layout of the option is used if empty, and no layout is used for NoLayout.
+		Layout string
+
+		// Status of the response. (default: 200)
+		Status int
+	}
+
+	// Renders the views returned by the handlers. Added to the global interceptors by the app. (app.EnableViews)
+	Renderer struct {
+		option Option
+		engine IViewEngine
+	}
+)
+
+const (
+	// Layout of the views rendered without a layout, even if the option has a default layout
+	NoLayout = "-"
+)
+
+// Fill the default values of the option
+func (o Option) withDefaults() Option {
+	if o.Extension == "" {
+		o.Extension = ".html"
+	}
+	if o.Layouts == "" {
+		o.Layouts = "layouts"
+	}
+	if o.Partials == "" {
+		o.Partials = "partials"
+	}
+	if o.ContentType == "" {
+		o.ContentType = "text/html; charset=utf-8"
+	}
+
+	return o
+}
+
+// Create the renderer of the views. The templates are not loaded until Load is called.
+func New(option Option) *Renderer {
+	option = option.withDefaults()
+
+	engine := option.Engine
+	if engine == nil {
+		engine = NewHTMLEngine(option)
+	}
+
+	return &Renderer{option: option, engine: engine}
+}
+
+// Get the view engine of the renderer. (e.g. to render the views in the native handlers)
+func (r *Renderer) Engine() IViewEngine {
+	return r.engine
+}
+
+func (r *Renderer) Load() error {
+	return r.engine.Load()
+}
+
+// Render the view into the writer, with the layout of the option if the view does not have one.
+func (r *Renderer) Render(w io.Writer, v View) error {
+	layout := v.Layout
+	switch layout {
+	case "":
+		layout = r.option.Layout
+	case NoLayout:
+		layout = ""
+	}
+
+	return r.engine.Render(w, v.Name, v.Data, layout)
+}
+
+// Render the views returned by the handler. The other results are returned as they are.
+//
+// The view is rendered into a buffer before it is written, so a failed render is handled by the exception filters.
+func (r *Renderer) Intercept(ctx controller.ExecutionContext, next interceptor.CallHandler) (interface{}, error) {
+	result, err := next()
+	if err != nil {
+		return result, err
+	}
+
+	var v View
+	switch value := result.(type) {
+	case View:
+		v = value
+	case *View:
+		v = *value
+	default:
+		return result, nil
+	}
+
+	var buffer bytes.Buffer
+	if err := r.Render(&buffer, v); err != nil {
+		return nil, err
+	}
+
+	status := v.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return nil, ctx.Blob(status, r.option.ContentType, buffer.Bytes())
+}
@@ -0,0 +1,144 @@
+package view_test
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+
+	"github.com/jhseong7/gimbap/controller"
+	"github.com/jhseong7/gimbap/view"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Execution context that records the written response
+type recordingContext struct {
+	controller.ExecutionContext
+
+	status      int
+	contentType string
+	body        []byte
+}
+
+func (c *recordingContext) Blob(status int, contentType string, data []byte) error {
+	c.status, c.contentType, c.body = status, contentType, data
+	return nil
+}
+
+// View engine that writes the name, the layout and the data
+type echoEngine struct{}
+
+func (echoEngine) Load() error { return nil }
+func (echoEngine) Render(w io.Writer, name string, data interface{}, layout string) error {
+	_, err := io.WriteString(w, name+"|"+layout+"|"+data.(string))
+	return err
+}
+
+var templates = fstest.MapFS{
+	"views/layouts/main.html":   {Data: []byte(`<title>{{ block "title" . }}App{{ end }}</title>{{ template "partials/nav" . }}<main>{{ yield }}</main>`)},
+	"views/layouts/plain.html":  {Data: []byte(`<div>{{ yield }}</div>`)},
+	"views/partials/nav.html":   {Data: []byte(`<nav>{{ .User }}</nav>`)},
+	"views/users/list.html":     {Data: []byte(`{{ define "title" }}Users{{ end }}{{ range .Users }}<li>{{ upper . }}</li>{{ end }}`)},
+	"views/users/detail.html":   {Data: []byte(`<p>{{ .User }}</p>`)},
+	"views/users/readme.txt":    {Data: []byte(`not a template`)},
+	"views/errors/broken.other": {Data: []byte(`{{ broken`)},
+}
+
+func render(r *view.Renderer, v view.View) string {
+	var out strings.Builder
+	Expect(r.Render(&out, v)).To(Succeed())
+
+	return out.String()
+}
+
+var _ = Describe("View", func() {
+	data := map[string]interface{}{"User": "<admin>", "Users": []string{"kim", "lee"}}
+
+	Context("Test the html/template engine", func() {
+		renderer := view.New(view.Option{
+			FS:     templates,
+			Root:   "views",
+			Layout: "layouts/main",
+			Funcs:  template.FuncMap{"upper": strings.ToUpper},
+		})
+
+		BeforeEach(func() {
+			Expect(renderer.Load()).To(Succeed())
+		})
+
+		It("Renders the view in the layout, with the partials and the blocks of the view", func() {
+			Expect(render(renderer, view.View{Name: "users/list", Data: data})).To(Equal(
+				`<title>Users</title><nav>&lt;admin&gt;</nav><main><li>KIM</li><li>LEE</li></main>`,
+			))
+			Expect(render(renderer, view.View{Name: "users/detail", Data: data})).To(Equal(
+				`<title>App</title><nav>&lt;admin&gt;</nav><main><p>&lt;admin&gt;</p></main>`,
+			))
+		})
+
+		It("Renders the view with another layout or without a layout", func() {
+			Expect(render(renderer, view.View{Name: "users/detail", Data: data, Layout: "layouts/plain"})).To(Equal(`<div><p>&lt;admin&gt;</p></div>`))
+			Expect(render(renderer, view.View{Name: "users/detail", Data: data, Layout: view.NoLayout})).To(Equal(`<p>&lt;admin&gt;</p>`))
+		})
+
+		It("Fails for the unknown views and layouts", func() {
+			Expect(renderer.Render(io.Discard, view.View{Name: "users/readme"})).ToNot(Succeed())
+			Expect(renderer.Render(io.Discard, view.View{Name: "users/detail", Layout: "layouts/none"})).ToNot(Succeed())
+		})
+
+		It("Fails to load the invalid templates", func() {
+			broken := view.New(view.Option{FS: fstest.MapFS{"index.html": {Data: []byte(`{{ broken`)}}})
+			Expect(broken.Load()).ToNot(Succeed())
+		})
+	})
+
+	Context("Test the reload", func() {
+		It("Loads the changed templates from the disk on each render", func() {
+			root := GinkgoT().TempDir()
+			file := filepath.Join(root, "index.html")
+			Expect(os.WriteFile(file, []byte(`v1`), 0o644)).To(Succeed())
+
+			fixed := view.New(view.Option{Root: root})
+			reloaded := view.New(view.Option{Root: root, Reload: true})
+			Expect(fixed.Load()).To(Succeed())
+			Expect(reloaded.Load()).To(Succeed())
+
+			Expect(os.WriteFile(file, []byte(`v2`), 0o644)).To(Succeed())
+			Expect(render(fixed, view.View{Name: "index"})).To(Equal("v1"))
+			Expect(render(reloaded, view.View{Name: "index"})).To(Equal("v2"))
+		})
+	})
+
+	Context("Test the interceptor", func() {
+		renderer := view.New(view.Option{Engine: echoEngine{}, Layout: "main"})
+
+		It("Writes the views returned by the handler", func() {
+			ctx := &recordingContext{}
+			result, err := renderer.Intercept(ctx, func() (interface{}, error) {
+				return view.View{Name: "users/list", Data: "data", Status: 201}, nil
+			})
+
+			Expect(result).To(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctx.status).To(Equal(201))
+			Expect(ctx.contentType).To(Equal("text/html; charset=utf-8"))
+			Expect(string(ctx.body)).To(Equal("users/list|main|data"))
+		})
+
+		It("Returns the other results and the errors as they are", func() {
+			ctx := &recordingContext{}
+
+			result, err := renderer.Intercept(ctx, func() (interface{}, error) { return "json", nil })
+			Expect(result).To(Equal("json"))
+			Expect(err).ToNot(HaveOccurred())
+
+			failure := errors.New("failure")
+			_, err = renderer.Intercept(ctx, func() (interface{}, error) { return view.View{Name: "users/list"}, failure })
+			Expect(err).To(Equal(failure))
+			Expect(ctx.status).To(Equal(0))
+		})
+	})
+})